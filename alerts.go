@@ -0,0 +1,257 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mrxinu/gosolar/swql"
+)
+
+// AlertService queries and acts on active alerts and alert definitions
+//
+//	alerts, err := client.Alerts().Active(ctx, gosolar.AlertFilter{
+//		MinSeverity: gosolar.AlertSeverityMajor,
+//	})
+//	...
+//	err = client.Alerts().Acknowledge(ctx, "looking into it", alerts[0].AlertObjectID)
+type AlertService struct {
+	client *Client
+}
+
+// Alerts returns the alert service of the client
+func (c *Client) Alerts() *AlertService {
+	return &AlertService{client: c}
+}
+
+// AlertDefinition is an alert configured in Orion.AlertConfigurations
+type AlertDefinition struct {
+	AlertID     int    `json:"alertid" swis:"AlertID"`
+	URI         URI    `json:"uri" swis:"Uri"`
+	Name        string `json:"name" swis:"Name"`
+	Description string `json:"description" swis:"Description"`
+	Enabled     bool   `json:"enabled" swis:"Enabled"`
+	Severity    int    `json:"severity" swis:"Severity"`
+	ObjectType  string `json:"objecttype" swis:"ObjectType"`
+}
+
+// AlertFilter selects the active alerts Active returns. Empty fields match
+// every alert.
+type AlertFilter struct {
+	// AlertIDs are alert definition IDs
+	AlertIDs []int
+	NodeIDs  []int
+
+	// MinSeverity only returns alerts at least this severe
+	MinSeverity int
+
+	Acknowledged *bool
+
+	// Where adds further conditions. Orion.AlertActive is aliased aa,
+	// Orion.AlertObjects ao and Orion.AlertConfigurations ac.
+	Where []swql.Condition
+
+	// Limit caps the number of alerts returned (0: no limit)
+	Limit int
+}
+
+// AlertDefinitionFilter selects the alert definitions Definitions returns.
+// Empty fields match every definition.
+type AlertDefinitionFilter struct {
+	// Name is a SWQL LIKE pattern
+	Name string
+
+	Enabled    *bool
+	ObjectType string
+}
+
+var alertColumns = []interface{}{
+	"aa.AlertActiveID",
+	"aa.AlertObjectID",
+	"ao.AlertID",
+	swql.As("ac.Name", "AlertName"),
+	swql.As("aa.TriggeredMessage", "Message"),
+	"ac.Severity",
+	swql.As("ao.RelatedNodeId", "NodeID"),
+	swql.As("ao.EntityCaption", "ObjectName"),
+	"ao.EntityUri",
+	"ao.EntityType",
+	swql.As("aa.TriggeredDateTime", "TriggerTime"),
+	"aa.Acknowledged",
+	swql.As("aa.AcknowledgedBy", "AckBy"),
+	swql.As("aa.AcknowledgedDateTime", "AckTime"),
+	swql.As("aa.AcknowledgedNote", "AckNote"),
+}
+
+var alertDefinitionColumns = []interface{}{
+	"AlertID", "Uri", "Name", "Description", "Enabled", "Severity", "ObjectType",
+}
+
+// selectAlerts returns a query for active alerts with the columns of Alert
+func selectAlerts() *swql.Query {
+	return swql.Select(alertColumns...).
+		From("Orion.AlertActive", "aa").
+		Join("Orion.AlertObjects", "ao", swql.ColEq("aa.AlertObjectID", "ao.AlertObjectID")).
+		Join("Orion.AlertConfigurations", "ac", swql.ColEq("ao.AlertID", "ac.AlertID"))
+}
+
+func (f AlertFilter) query() *swql.Query {
+	q := selectAlerts()
+
+	if len(f.AlertIDs) > 0 {
+		q.In("ao.AlertID", intValues(f.AlertIDs)...)
+	}
+	if len(f.NodeIDs) > 0 {
+		q.In("ao.RelatedNodeId", intValues(f.NodeIDs)...)
+	}
+	if f.MinSeverity > 0 {
+		q.Where(swql.Ge("ac.Severity", f.MinSeverity))
+	}
+	if f.Acknowledged != nil {
+		q.Where(swql.Eq("aa.Acknowledged", *f.Acknowledged))
+	}
+	q.Where(f.Where...)
+	if f.Limit > 0 {
+		q.Limit(f.Limit)
+	}
+	return q.OrderByDesc("aa.TriggeredDateTime")
+}
+
+func (f AlertDefinitionFilter) query() *swql.Query {
+	q := swql.Select(alertDefinitionColumns...).From("Orion.AlertConfigurations")
+	if f.Name != "" {
+		q.Where(swql.Like("Name", f.Name))
+	}
+	if f.Enabled != nil {
+		q.Where(swql.Eq("Enabled", *f.Enabled))
+	}
+	if f.ObjectType != "" {
+		q.Where(swql.Eq("ObjectType", f.ObjectType))
+	}
+	return q.OrderBy("Name")
+}
+
+// Active returns the active alerts matching filter, most recent first
+func (s *AlertService) Active(ctx context.Context, filter AlertFilter) ([]Alert, error) {
+	alerts := []Alert{}
+	if err := s.query(ctx, "list_alerts", filter.query(), &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+// Acknowledge acknowledges active alerts by AlertObjectID with notes
+func (s *AlertService) Acknowledge(ctx context.Context, notes string, alertObjectIDs ...int) error {
+	return s.invoke(ctx, "acknowledge_alerts", "Acknowledge", alertObjectIDs, Args{"notes": notes}, acknowledgeArgs)
+}
+
+// Unacknowledge removes the acknowledgement of active alerts
+func (s *AlertService) Unacknowledge(ctx context.Context, alertObjectIDs ...int) error {
+	return s.invoke(ctx, "unacknowledge_alerts", "Unacknowledge", alertObjectIDs, Args{}, alertObjectArgs)
+}
+
+// Clear clears active alerts, resetting them until they trigger again
+func (s *AlertService) Clear(ctx context.Context, alertObjectIDs ...int) error {
+	return s.invoke(ctx, "clear_alerts", "ClearAlert", alertObjectIDs, Args{}, alertObjectArgs)
+}
+
+// AppendNote adds a note to active alerts
+func (s *AlertService) AppendNote(ctx context.Context, note string, alertObjectIDs ...int) error {
+	if note == "" {
+		return NewError(ErrorTypeValidation, "append_alert_note", "note cannot be empty")
+	}
+	return s.invoke(ctx, "append_alert_note", "AppendNote", alertObjectIDs, Args{"note": note}, appendNoteArgs)
+}
+
+func (s *AlertService) invoke(ctx context.Context, operation, verb string, ids []int, args Args, signature []VerbArgument) error {
+	if len(ids) == 0 {
+		return NewError(ErrorTypeValidation, operation, "no alert object IDs provided")
+	}
+	args["alertObjectIds"] = ids
+
+	if _, err := s.client.callVerb(ctx, operation, "Orion.AlertActive", verb, args, signature); err != nil {
+		return wrapCallError(err, ErrorTypeInternal, operation, fmt.Sprintf("failed to invoke Orion.AlertActive.%s", verb))
+	}
+	return nil
+}
+
+// Definitions returns the alert definitions matching filter ordered by name
+func (s *AlertService) Definitions(ctx context.Context, filter AlertDefinitionFilter) ([]AlertDefinition, error) {
+	definitions := []AlertDefinition{}
+	if err := s.query(ctx, "list_alert_definitions", filter.query(), &definitions); err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// Definition returns an alert definition by ID. It returns an
+// ErrorTypeNotFound error if there's no such definition.
+func (s *AlertService) Definition(ctx context.Context, alertID int) (*AlertDefinition, error) {
+	q := swql.Select(alertDefinitionColumns...).
+		From("Orion.AlertConfigurations").
+		Where(swql.Eq("AlertID", alertID))
+
+	var definitions []AlertDefinition
+	if err := s.query(ctx, "get_alert_definition", q, &definitions); err != nil {
+		return nil, err
+	}
+	if len(definitions) == 0 {
+		return nil, NewError(ErrorTypeNotFound, "get_alert_definition", fmt.Sprintf("alert definition %d not found", alertID))
+	}
+	return &definitions[0], nil
+}
+
+// EnableDefinition turns an alert definition on
+func (s *AlertService) EnableDefinition(ctx context.Context, alertID int) error {
+	return s.setEnabled(ctx, "enable_alert_definition", alertID, true)
+}
+
+// DisableDefinition turns an alert definition off
+func (s *AlertService) DisableDefinition(ctx context.Context, alertID int) error {
+	return s.setEnabled(ctx, "disable_alert_definition", alertID, false)
+}
+
+func (s *AlertService) setEnabled(ctx context.Context, operation string, alertID int, enabled bool) error {
+	definition, err := s.Definition(ctx, alertID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.client.UpdateURIContext(ctx, definition.URI, map[string]interface{}{"Enabled": enabled}); err != nil {
+		return wrapCallError(err, ErrorTypeInternal, operation, fmt.Sprintf("failed to update alert definition %d", alertID))
+	}
+	return nil
+}
+
+func (s *AlertService) query(ctx context.Context, operation string, q *swql.Query, dest interface{}) error {
+	query, params, err := q.Build()
+	if err != nil {
+		return WrapError(err, ErrorTypeValidation, operation, "invalid alert filter")
+	}
+	return s.client.queryScan(ctx, operation, query, params, dest)
+}
+
+// alertObjectArgs is the signature of the Orion.AlertActive verbs taking only
+// alert object IDs
+var alertObjectArgs = []VerbArgument{
+	{Position: 1, Name: "alertObjectIds", Type: "System.Int32[]"},
+}
+
+// acknowledgeArgs is the signature of Orion.AlertActive.Acknowledge
+var acknowledgeArgs = []VerbArgument{
+	{Position: 1, Name: "alertObjectIds", Type: "System.Int32[]"},
+	{Position: 2, Name: "notes", Type: "System.String", IsOptional: true},
+}
+
+// appendNoteArgs is the signature of Orion.AlertActive.AppendNote
+var appendNoteArgs = []VerbArgument{
+	{Position: 1, Name: "alertObjectIds", Type: "System.Int32[]"},
+	{Position: 2, Name: "note", Type: "System.String"},
+}
+
+func intValues(ints []int) []interface{} {
+	values := make([]interface{}, len(ints))
+	for i, v := range ints {
+		values[i] = v
+	}
+	return values
+}
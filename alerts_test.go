@@ -0,0 +1,113 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlertService_Active(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"SELECT aa.AlertActiveID": `[{"AlertActiveID":10,"AlertObjectID":20,"AlertID":3,"AlertName":"Node down",
+			"Message":"core-sw1 is down","Severity":2,"NodeID":42,"ObjectName":"core-sw1",
+			"EntityUri":"swis://orion/Orion/Orion.Nodes/NodeID=42","EntityType":"Orion.Nodes",
+			"TriggerTime":"2024-03-01T22:00:00","Acknowledged":true,"AckBy":"admin",
+			"AckTime":"2024-03-01T22:05:00","AckNote":"on it"}]`,
+	})
+	client := newTestClient(t, server.Server)
+
+	acknowledged := false
+	alerts, err := client.Alerts().Active(context.Background(), AlertFilter{
+		NodeIDs:      []int{42},
+		MinSeverity:  AlertSeverityWarning,
+		Acknowledged: &acknowledged,
+	})
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+
+	alert := alerts[0]
+	assert.Equal(t, 20, alert.AlertObjectID)
+	assert.Equal(t, "Node down", alert.AlertName)
+	assert.Equal(t, 42, alert.NodeID)
+	assert.True(t, alert.Acknowledged)
+	assert.Equal(t, "on it", alert.AckNote)
+	assert.Equal(t, 5, alert.AckTime.Minute())
+
+	var req struct {
+		Query      string                 `json:"query"`
+		Parameters map[string]interface{} `json:"parameters"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(server.bodies("Query")[0]), &req))
+	assert.Contains(t, req.Query, "FROM Orion.AlertActive aa INNER JOIN Orion.AlertObjects ao ON aa.AlertObjectID = ao.AlertObjectID")
+	assert.Contains(t, req.Query, "WHERE (ao.RelatedNodeId IN (@p0) AND ac.Severity >= @p1 AND aa.Acknowledged = @p2)")
+	assert.Contains(t, req.Query, "ORDER BY aa.TriggeredDateTime DESC")
+	assert.Equal(t, map[string]interface{}{"p0": float64(42), "p1": float64(2), "p2": false}, req.Parameters)
+}
+
+func TestAlertService_Actions(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	alerts := client.Alerts()
+
+	require.NoError(t, alerts.Acknowledge(ctx, "on it", 20, 21))
+	require.NoError(t, alerts.Unacknowledge(ctx, 20))
+	require.NoError(t, alerts.Clear(ctx, 21))
+	require.NoError(t, alerts.AppendNote(ctx, "rebooted", 20))
+
+	assert.Equal(t, []string{`[[20,21],"on it"]`}, server.bodies("Invoke/Orion.AlertActive/Acknowledge"))
+	assert.Equal(t, []string{`[[20]]`}, server.bodies("Invoke/Orion.AlertActive/Unacknowledge"))
+	assert.Equal(t, []string{`[[21]]`}, server.bodies("Invoke/Orion.AlertActive/ClearAlert"))
+	assert.Equal(t, []string{`[[20],"rebooted"]`}, server.bodies("Invoke/Orion.AlertActive/AppendNote"))
+
+	assert.ErrorIs(t, alerts.Clear(ctx), &Error{Type: ErrorTypeValidation})
+	assert.ErrorIs(t, alerts.AppendNote(ctx, "", 20), &Error{Type: ErrorTypeValidation})
+}
+
+func TestAlertService_Definitions(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"SELECT AlertID, Uri, Name": `[{"AlertID":3,"Uri":"swis://orion/Orion/Orion.AlertConfigurations/AlertID=3",
+			"Name":"Node down","Enabled":true,"Severity":2,"ObjectType":"Node"}]`,
+	})
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	alerts := client.Alerts()
+
+	definitions, err := alerts.Definitions(ctx, AlertDefinitionFilter{Name: "Node%"})
+	require.NoError(t, err)
+	require.Len(t, definitions, 1)
+	assert.True(t, definitions[0].Enabled)
+
+	require.NoError(t, alerts.DisableDefinition(ctx, 3))
+	require.NoError(t, alerts.EnableDefinition(ctx, 3))
+	assert.Equal(t, []string{`{"Enabled":false}`, `{"Enabled":true}`},
+		server.bodies("swis://orion/Orion/Orion.AlertConfigurations/AlertID=3"))
+}
+
+func TestAlertService_DefinitionNotFound(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+
+	err := client.Alerts().EnableDefinition(context.Background(), 99)
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeNotFound})
+	assert.Len(t, server.bodies("Query"), 1)
+}
+
+func TestAlertService_ErrorType(t *testing.T) {
+	server := newFakeSWIS(t, nil, func(w http.ResponseWriter, r *http.Request, req fakeRequest) bool {
+		w.WriteHeader(http.StatusUnauthorized)
+		return true
+	})
+	client := newTestClient(t, server.Server)
+
+	err := client.Alerts().Acknowledge(context.Background(), "on it", 20)
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeAuthentication, swErr.Type)
+	assert.Equal(t, http.StatusUnauthorized, swErr.StatusCode)
+	assert.Equal(t, "acknowledge_alerts", swErr.Operation)
+}
@@ -3,8 +3,12 @@ package gosolar
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -12,6 +16,146 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// newTestClient returns a client pointed at a local test server
+func newTestClient(t *testing.T, server *httptest.Server) *Client {
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+// fakeSWIS is a stand-in SWIS that records every request it receives.
+// Queries are answered with the results whose key appears in the query text,
+// the longest key winning and the empty key matching any query, and other
+// requests with the results keyed by their endpoint, such as
+// Invoke/Orion.Nodes/PollNow. Anything else gets no results.
+type fakeSWIS struct {
+	*httptest.Server
+
+	results  map[string]string
+	handlers []fakeHandler
+
+	mu       sync.Mutex
+	requests []fakeRequest
+}
+
+// fakeHandler sees each request to fakeSWIS before it is answered from the
+// results and answers it instead by returning true
+type fakeHandler func(w http.ResponseWriter, r *http.Request, req fakeRequest) bool
+
+// fakeRequest is a request received by fakeSWIS
+type fakeRequest struct {
+	Method string
+	Path   string // endpoint below the JSON API path
+	Body   json.RawMessage
+
+	// Query and Params are set for queries
+	Query  string
+	Params map[string]interface{}
+}
+
+// newFakeSWIS starts a fakeSWIS answering with results and handlers, which
+// is closed when the test ends
+func newFakeSWIS(t *testing.T, results map[string]string, handlers ...fakeHandler) *fakeSWIS {
+	f := &fakeSWIS{results: results, handlers: handlers}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := fakeRequest{
+			Method: r.Method,
+			Path:   r.URL.Path[strings.Index(r.URL.Path, "/Json/")+len("/Json/"):],
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req.Body)
+		if req.Path == "Query" {
+			var query struct {
+				Query      string                 `json:"query"`
+				Parameters map[string]interface{} `json:"parameters"`
+			}
+			require.NoError(t, json.Unmarshal(req.Body, &query))
+			req.Query, req.Params = query.Query, query.Parameters
+		}
+
+		f.mu.Lock()
+		f.requests = append(f.requests, req)
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		for _, handle := range f.handlers {
+			if handle(w, r, req) {
+				return
+			}
+		}
+		_, _ = w.Write([]byte(f.answer(req)))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// answer returns the canned response to req
+func (f *fakeSWIS) answer(req fakeRequest) string {
+	if req.Path != "Query" {
+		if res, ok := f.results[req.Path]; ok {
+			return res
+		}
+		return "null"
+	}
+
+	res, best := "[]", -1
+	for key, rows := range f.results {
+		if len(key) > best && strings.Contains(req.Query, key) {
+			res, best = rows, len(key)
+		}
+	}
+	return `{"results":` + res + `}`
+}
+
+// count returns the number of requests received
+func (f *fakeSWIS) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+// calls returns the method and endpoint of every request, in order
+func (f *fakeSWIS) calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []string
+	for _, req := range f.requests {
+		calls = append(calls, req.Method+" "+req.Path)
+	}
+	return calls
+}
+
+// bodies returns the bodies of the requests to an endpoint, in order
+func (f *fakeSWIS) bodies(path string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var bodies []string
+	for _, req := range f.requests {
+		if req.Path == path {
+			bodies = append(bodies, string(req.Body))
+		}
+	}
+	return bodies
+}
+
+// queries returns the queries received, in order
+func (f *fakeSWIS) queries() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var queries []fakeRequest
+	for _, req := range f.requests {
+		if req.Path == "Query" {
+			queries = append(queries, req)
+		}
+	}
+	return queries
+}
+
 func TestNewClient(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -109,6 +253,7 @@ func TestClient_QueryContext(t *testing.T) {
 
 	config := DefaultConfig()
 	config.Host = server.URL[7:] // Remove "http://" prefix
+	config.PlainHTTP = true
 	config.Username = "admin"
 	config.Password = "password"
 	config.InsecureSkipVerify = true
@@ -116,10 +261,6 @@ func TestClient_QueryContext(t *testing.T) {
 	client, err := NewClient(config)
 	require.NoError(t, err)
 
-	// Override the base URL to point to our test server
-	client.baseURL.Scheme = "http"
-	client.baseURL.Host = server.URL[7:]
-
 	ctx := context.Background()
 	result, err := client.QueryContext(ctx, "SELECT NodeID, Caption FROM Orion.Nodes", nil)
 
@@ -134,6 +275,58 @@ func TestClient_QueryContext(t *testing.T) {
 	assert.Equal(t, "Node1", nodes[0]["Caption"])
 }
 
+func TestClient_QueryHelpersColumnOrder(t *testing.T) {
+	// Column names sort in the opposite order to the SELECT list, so any
+	// map-based implementation would be caught picking the wrong column
+	server := newFakeSWIS(t, map[string]string{"": `[{"Zulu":"z1","Alpha":"a1"},{"Zulu":"z2","Alpha":"a2"}]`})
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	for i := 0; i < 20; i++ {
+		v, err := client.QueryOneContext(ctx, "SELECT Zulu, Alpha FROM Test.Entity", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "z1", v)
+
+		column, err := client.QueryColumnContext(ctx, "SELECT Zulu, Alpha FROM Test.Entity", nil)
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{"z1", "z2"}, column)
+	}
+
+	row, err := client.QueryRowContext(ctx, "SELECT Zulu, Alpha FROM Test.Entity", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Zulu":"z1","Alpha":"a1"}`, string(row))
+
+	_, err = client.QueryRowStrictContext(ctx, "SELECT Zulu, Alpha FROM Test.Entity", nil)
+	require.Error(t, err)
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeValidation, swErr.Type)
+}
+
+func TestClient_QueryHelpersNoRows(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	v, err := client.QueryOneContext(ctx, "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Nil(t, v)
+
+	row, err := client.QueryRowContext(ctx, "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(row))
+
+	column, err := client.QueryColumnContext(ctx, "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Empty(t, column)
+
+	_, err = client.QueryRowStrictContext(ctx, "SELECT NodeID FROM Orion.Nodes", nil)
+	require.Error(t, err)
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeNotFound, swErr.Type)
+}
+
 func TestClient_HTTPError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusUnauthorized)
@@ -143,6 +336,7 @@ func TestClient_HTTPError(t *testing.T) {
 
 	config := DefaultConfig()
 	config.Host = server.URL[7:]
+	config.PlainHTTP = true
 	config.Username = "wrong"
 	config.Password = "credentials"
 	config.InsecureSkipVerify = true
@@ -151,9 +345,6 @@ func TestClient_HTTPError(t *testing.T) {
 	client, err := NewClient(config)
 	require.NoError(t, err)
 
-	client.baseURL.Scheme = "http"
-	client.baseURL.Host = server.URL[7:]
-
 	ctx := context.Background()
 	_, err = client.QueryContext(ctx, "SELECT * FROM Orion.Nodes", nil)
 
@@ -173,6 +364,7 @@ func TestClient_ContextCancellation(t *testing.T) {
 
 	config := DefaultConfig()
 	config.Host = server.URL[7:]
+	config.PlainHTTP = true
 	config.Username = "admin"
 	config.Password = "password"
 	config.Timeout = 10 * time.Second // Long client timeout
@@ -181,9 +373,6 @@ func TestClient_ContextCancellation(t *testing.T) {
 	client, err := NewClient(config)
 	require.NoError(t, err)
 
-	client.baseURL.Scheme = "http"
-	client.baseURL.Host = server.URL[7:]
-
 	// Use a short context timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
 	defer cancel()
@@ -222,6 +411,194 @@ func TestClient_Retries(t *testing.T) {
 	assert.Contains(t, err.Error(), "request failed after retries")
 }
 
+func TestClient_RetryReplaysBody(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+
+		// Drop the connection on the first attempt to force a transport error
+		if len(bodies) == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			_ = conn.Close()
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[{"NodeID":1}]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 2
+	config.RetryDelay = time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	result, err := client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"NodeID":1}]`, string(result))
+
+	require.Len(t, bodies, 2)
+	assert.NotEmpty(t, bodies[0])
+	assert.Equal(t, bodies[0], bodies[1])
+}
+
+func TestClient_RetryAttempts(t *testing.T) {
+	config := DefaultConfig()
+	config.Host = "nonexistent.invalid"
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 2
+	config.RetryDelay = time.Millisecond
+	config.Timeout = 100 * time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT * FROM Orion.Nodes", nil)
+	require.Error(t, err)
+
+	var swErr *Error
+	require.True(t, errors.As(err, &swErr))
+	assert.Equal(t, 3, swErr.Attempts)
+}
+
+func TestClient_RetryHonorsContext(t *testing.T) {
+	config := DefaultConfig()
+	config.Host = "nonexistent.invalid"
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 5
+	config.RetryDelay = time.Minute
+	config.Timeout = 100 * time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = client.QueryContext(ctx, "SELECT * FROM Orion.Nodes", nil)
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var swErr *Error
+	require.True(t, errors.As(err, &swErr))
+	assert.Equal(t, ErrorTypeNetwork, swErr.Type)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_RetryTransientStatus(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.RetryDelay = time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestClient_RetryNonIdempotent(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 2
+	config.RetryDelay = time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.CreateContext(context.Background(), "Orion.Nodes", map[string]interface{}{"Caption": "n1"})
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	_, err = client.CreateContext(WithIdempotent(context.Background()), "Orion.Nodes", map[string]interface{}{"Caption": "n1"})
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	var swErr *Error
+	require.True(t, errors.As(err, &swErr))
+	assert.Equal(t, 3, swErr.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, swErr.StatusCode)
+}
+
+type recordingRetryPolicy struct {
+	infos []RetryInfo
+}
+
+func (p *recordingRetryPolicy) ShouldRetry(ctx context.Context, info RetryInfo) (bool, time.Duration) {
+	p.infos = append(p.infos, info)
+	return info.ErrorType == ErrorTypeAuthentication, 0
+}
+
+func TestClient_CustomRetryPolicy(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	policy := &recordingRetryPolicy{}
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 1
+	config.RetryPolicy = policy
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.Error(t, err)
+	assert.Equal(t, 2, calls)
+
+	require.Len(t, policy.infos, 1)
+	assert.Equal(t, 1, policy.infos[0].Attempt)
+	assert.Equal(t, "Query", policy.infos[0].Endpoint)
+	assert.Equal(t, ErrorTypeAuthentication, policy.infos[0].ErrorType)
+	assert.Equal(t, http.StatusUnauthorized, policy.infos[0].Response.StatusCode)
+}
+
 func TestDefaultConfig(t *testing.T) {
 	config := DefaultConfig()
 
@@ -229,6 +606,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, 10, config.MaxIdleConns)
 	assert.Equal(t, 3, config.MaxRetries)
 	assert.Equal(t, time.Second, config.RetryDelay)
+	assert.Equal(t, 30*time.Second, config.MaxRetryDelay)
 	assert.False(t, config.InsecureSkipVerify)
 	assert.Equal(t, "gosolar/2.0", config.UserAgent)
 }
@@ -250,6 +628,27 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "base URL without host",
+			config: &Config{
+				BaseURL:  "https://proxy.example.com/swis/",
+				Username: "admin",
+				Password: "password",
+				Timeout:  30 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid port",
+			config: &Config{
+				Host:     "example.com",
+				Port:     70000,
+				Username: "admin",
+				Password: "password",
+				Timeout:  30 * time.Second,
+			},
+			wantErr: true,
+		},
 		{
 			name: "negative max retries",
 			config: &Config{
@@ -274,3 +673,105 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestConfig_EndpointURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "hostname",
+			config: Config{Host: "orion.example.com"},
+			want:   "https://orion.example.com:17778/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "host with port",
+			config: Config{Host: "orion.example.com:8443"},
+			want:   "https://orion.example.com:8443/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "custom port",
+			config: Config{Host: "10.0.0.5", Port: 443},
+			want:   "https://10.0.0.5:443/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "bare IPv6 literal",
+			config: Config{Host: "fe80::1"},
+			want:   "https://[fe80::1]:17778/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "bracketed IPv6 literal",
+			config: Config{Host: "[::1]"},
+			want:   "https://[::1]:17778/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "bracketed IPv6 literal with port",
+			config: Config{Host: "[::1]:8080"},
+			want:   "https://[::1]:8080/SolarWinds/InformationService/v3/Json/",
+		},
+		{
+			name:   "plain http with custom path",
+			config: Config{Host: "localhost:8080", PlainHTTP: true, APIPath: "swis/v3/Json"},
+			want:   "http://localhost:8080/swis/v3/Json/",
+		},
+		{
+			name:   "base URL behind a proxy",
+			config: Config{BaseURL: "https://proxy.example.com/orion/swis"},
+			want:   "https://proxy.example.com/orion/swis/",
+		},
+		{
+			name:    "base URL without scheme",
+			config:  Config{BaseURL: "proxy.example.com/orion"},
+			wantErr: true,
+		},
+		{
+			name:    "base URL with unsupported scheme",
+			config:  Config{BaseURL: "ftp://proxy.example.com/"},
+			wantErr: true,
+		},
+		{
+			name:    "host given as URL",
+			config:  Config{Host: "https://orion.example.com"},
+			wantErr: true,
+		},
+		{
+			name:    "host with invalid port",
+			config:  Config{Host: "orion.example.com:99999"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := tt.config.endpointURL()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, u.String())
+		})
+	}
+}
+
+func TestClient_BaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orion/swis/Query", r.URL.Path)
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.BaseURL = server.URL + "/orion/swis"
+	config.Username = "admin"
+	config.Password = "password"
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	result, err := client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(result))
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config lists the metrics to export
+type Config struct {
+	// Listen is the address metrics are served on (default: :9810)
+	Listen string `yaml:"listen"`
+
+	// Interval is the time between runs of the queries (default: 60s)
+	Interval time.Duration `yaml:"interval"`
+
+	// Timeout bounds each run of the queries (default: the interval)
+	Timeout time.Duration `yaml:"timeout"`
+
+	// DefaultMetrics exports the node, interface and volume metrics of
+	// defaultMetrics before Metrics (default: true)
+	DefaultMetrics *bool `yaml:"default_metrics"`
+
+	// Metrics are the metrics to export in addition to the defaults
+	Metrics []MetricConfig `yaml:"metrics"`
+}
+
+// MetricConfig is a metric read from the rows of a SWQL query. Metrics with
+// the same query share a single run of it.
+type MetricConfig struct {
+	// Name is the Prometheus metric name
+	Name string `yaml:"name"`
+	Help string `yaml:"help"`
+
+	// Type is gauge or counter (default: gauge)
+	Type string `yaml:"type"`
+
+	Query string `yaml:"query"`
+
+	// Value is the column holding the sample value. Booleans are 1 or 0;
+	// rows where it's null are skipped.
+	Value string `yaml:"value"`
+
+	// Labels are the columns copied to labels, named in snake_case:
+	// NodeID becomes node_id. Write "label=Column" to name one yourself.
+	Labels []string `yaml:"labels"`
+}
+
+const (
+	metricGauge   = "gauge"
+	metricCounter = "counter"
+)
+
+// defaultMetrics are exported unless default_metrics is false
+var defaultMetrics = []MetricConfig{
+	{
+		Name:   "solarwinds_node_status",
+		Help:   "Status of the node (1 up, 2 down, 3 warning, 12 unreachable, 14 critical).",
+		Query:  nodeQuery,
+		Value:  "Status",
+		Labels: []string{"NodeID", "Caption", "IPAddress"},
+	},
+	{
+		Name:   "solarwinds_node_response_time_milliseconds",
+		Help:   "ICMP response time of the node.",
+		Query:  nodeQuery,
+		Value:  "ResponseTime",
+		Labels: []string{"NodeID", "Caption", "IPAddress"},
+	},
+	{
+		Name:   "solarwinds_interface_in_utilization_percent",
+		Help:   "Receive utilization of the interface.",
+		Query:  interfaceQuery,
+		Value:  "InPercentUtil",
+		Labels: []string{"InterfaceID", "NodeID", "node=NodeCaption", "Caption"},
+	},
+	{
+		Name:   "solarwinds_interface_out_utilization_percent",
+		Help:   "Transmit utilization of the interface.",
+		Query:  interfaceQuery,
+		Value:  "OutPercentUtil",
+		Labels: []string{"InterfaceID", "NodeID", "node=NodeCaption", "Caption"},
+	},
+	{
+		Name:   "solarwinds_volume_used_percent",
+		Help:   "Used space of the volume.",
+		Query:  volumeQuery,
+		Value:  "VolumePercentUsed",
+		Labels: []string{"VolumeID", "NodeID", "node=NodeCaption", "Caption"},
+	},
+}
+
+const (
+	nodeQuery      = "SELECT NodeID, Caption, IPAddress, Status, ResponseTime FROM Orion.Nodes"
+	interfaceQuery = "SELECT i.InterfaceID, i.NodeID, i.Node.Caption AS NodeCaption, i.Caption, i.InPercentUtil, i.OutPercentUtil FROM Orion.NPM.Interfaces i"
+	volumeQuery    = "SELECT v.VolumeID, v.NodeID, v.Node.Caption AS NodeCaption, v.Caption, v.VolumePercentUsed FROM Orion.Volumes v"
+)
+
+var (
+	metricNamePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNamePattern  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// loadConfig reads and checks a config file, filling in defaults. An empty
+// path exports the default metrics only.
+func loadConfig(path string) (*Config, error) {
+	var cfg Config
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if err := cfg.applyDefaults(); err != nil {
+		if path == "" {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (cfg *Config) applyDefaults() error {
+	if cfg.Listen == "" {
+		cfg.Listen = ":9810"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 60 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = cfg.Interval
+	}
+	if cfg.DefaultMetrics == nil || *cfg.DefaultMetrics {
+		cfg.Metrics = append(append([]MetricConfig(nil), defaultMetrics...), cfg.Metrics...)
+	}
+	if len(cfg.Metrics) == 0 {
+		return fmt.Errorf("no metrics listed")
+	}
+
+	types := map[string]string{}
+	for i := range cfg.Metrics {
+		m := &cfg.Metrics[i]
+		if !metricNamePattern.MatchString(m.Name) {
+			return fmt.Errorf("invalid metric name %q", m.Name)
+		}
+		if m.Type == "" {
+			m.Type = metricGauge
+		}
+		if m.Type != metricGauge && m.Type != metricCounter {
+			return fmt.Errorf("%s: unknown type %q", m.Name, m.Type)
+		}
+		if t, ok := types[m.Name]; ok && t != m.Type {
+			return fmt.Errorf("%s is listed as both a %s and a %s", m.Name, t, m.Type)
+		}
+		types[m.Name] = m.Type
+		if strings.TrimSpace(m.Query) == "" {
+			return fmt.Errorf("%s has no query", m.Name)
+		}
+		if m.Value == "" {
+			return fmt.Errorf("%s has no value column", m.Name)
+		}
+		if m.Help == "" {
+			m.Help = fmt.Sprintf("%s of %s.", m.Value, m.Name)
+		}
+		for _, l := range m.Labels {
+			name, _ := labelColumn(l)
+			if !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__") {
+				return fmt.Errorf("%s: invalid label name %q", m.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// labelColumn splits a Labels entry into the label name and the column
+func labelColumn(s string) (label, column string) {
+	if label, column, ok := strings.Cut(s, "="); ok {
+		return strings.TrimSpace(label), strings.TrimSpace(column)
+	}
+	return snakeCase(s), s
+}
+
+// snakeCase converts a column name such as IPAddress to ip_address
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			b.WriteByte('_')
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+)
+
+// Exporter runs the configured queries on an interval and serves the last
+// results in the Prometheus text format
+type Exporter struct {
+	client *gosolar.Client
+	cfg    *Config
+	logger *slog.Logger
+
+	// now is replaced in tests
+	now func() time.Time
+
+	mu        sync.Mutex
+	families  []family
+	durations map[string]float64
+	errors    map[string]float64
+	scrape    float64
+	scrapes   float64
+	last      time.Time
+}
+
+// family is a metric and its samples
+type family struct {
+	name, help, typ string
+	samples         []sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+// NewExporter returns an exporter for the metrics of cfg
+func NewExporter(client *gosolar.Client, cfg *Config, logger *slog.Logger) *Exporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	e := &Exporter{
+		client:    client,
+		cfg:       cfg,
+		logger:    logger,
+		now:       time.Now,
+		durations: map[string]float64{},
+		errors:    map[string]float64{},
+	}
+	for _, m := range cfg.Metrics {
+		e.errors[m.Name] = 0
+	}
+	return e
+}
+
+// Run refreshes the metrics every interval until ctx is done
+func (e *Exporter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.Refresh(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh runs every query once and replaces the exported samples. The
+// samples of a failed query are dropped and its error count raised.
+func (e *Exporter) Refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	start := e.now()
+
+	// Metrics sharing a query run it once
+	var queries []string
+	byQuery := map[string][]MetricConfig{}
+	for _, m := range e.cfg.Metrics {
+		if _, ok := byQuery[m.Query]; !ok {
+			queries = append(queries, m.Query)
+		}
+		byQuery[m.Query] = append(byQuery[m.Query], m)
+	}
+
+	samples := map[string][]sample{}
+	durations := map[string]float64{}
+	var failed []string
+	for _, query := range queries {
+		metrics := byQuery[query]
+		queryStart := e.now()
+		rows, err := e.query(ctx, query)
+		elapsed := e.now().Sub(queryStart).Seconds()
+
+		for _, m := range metrics {
+			durations[m.Name] = elapsed
+			if err != nil {
+				failed = append(failed, m.Name)
+				continue
+			}
+			samples[m.Name] = append(samples[m.Name], collect(m, rows)...)
+		}
+		if err != nil {
+			e.logger.Error("gosolar-exporter: query failed", "metric", metrics[0].Name, "error", err)
+		}
+	}
+
+	var families []family
+	seen := map[string]bool{}
+	for _, m := range e.cfg.Metrics {
+		if seen[m.Name] {
+			continue
+		}
+		seen[m.Name] = true
+		families = append(families, family{name: m.Name, help: m.Help, typ: m.Type, samples: dedupe(samples[m.Name])})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.families = families
+	e.durations = durations
+	for _, name := range failed {
+		e.errors[name]++
+	}
+	e.scrape = e.now().Sub(start).Seconds()
+	e.scrapes++
+	e.last = e.now()
+}
+
+func (e *Exporter) query(ctx context.Context, query string) ([]map[string]interface{}, error) {
+	res, err := e.client.QueryContext(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(res))
+	dec.UseNumber()
+	if err := dec.Decode(&rows); err != nil {
+		return nil, fmt.Errorf("parse results: %w", err)
+	}
+	return rows, nil
+}
+
+// collect reads the samples of m from rows
+func collect(m MetricConfig, rows []map[string]interface{}) []sample {
+	samples := make([]sample, 0, len(rows))
+	for _, row := range rows {
+		value, ok := sampleValue(row[m.Value])
+		if !ok {
+			continue
+		}
+
+		pairs := make([]string, 0, len(m.Labels))
+		for _, l := range m.Labels {
+			label, column := labelColumn(l)
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, label, escapeLabel(labelValue(row[column]))))
+		}
+		samples = append(samples, sample{labels: strings.Join(pairs, ","), value: value})
+	}
+	return samples
+}
+
+// dedupe drops samples repeating the labels of an earlier one, which
+// Prometheus would reject
+func dedupe(samples []sample) []sample {
+	seen := map[string]bool{}
+	out := samples[:0]
+	for _, s := range samples {
+		if !seen[s.labels] {
+			seen[s.labels] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sampleValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func labelValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(e.exposition())
+}
+
+func (e *Exporter) exposition() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var b bytes.Buffer
+	writeFamily := func(f family) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, helpEscaper.Replace(f.help), f.name, f.typ)
+		for _, s := range f.samples {
+			if s.labels == "" {
+				fmt.Fprintf(&b, "%s %s\n", f.name, formatValue(s.value))
+			} else {
+				fmt.Fprintf(&b, "%s{%s} %s\n", f.name, s.labels, formatValue(s.value))
+			}
+		}
+	}
+
+	for _, f := range e.families {
+		writeFamily(f)
+	}
+
+	perMetric := func(values map[string]float64) []sample {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		samples := make([]sample, len(names))
+		for i, name := range names {
+			samples[i] = sample{labels: fmt.Sprintf(`metric="%s"`, name), value: values[name]}
+		}
+		return samples
+	}
+
+	writeFamily(family{name: "gosolar_exporter_scrape_duration_seconds", typ: metricGauge,
+		help: "Time the last run of the queries took.", samples: []sample{{value: e.scrape}}})
+	writeFamily(family{name: "gosolar_exporter_query_duration_seconds", typ: metricGauge,
+		help: "Time the last run of the query of each metric took.", samples: perMetric(e.durations)})
+	writeFamily(family{name: "gosolar_exporter_scrape_errors_total", typ: metricCounter,
+		help: "Failed runs of the query of each metric.", samples: perMetric(e.errors)})
+	writeFamily(family{name: "gosolar_exporter_scrapes_total", typ: metricCounter,
+		help: "Runs of the queries.", samples: []sample{{value: e.scrapes}}})
+
+	var last float64
+	if !e.last.IsZero() {
+		last = float64(e.last.UnixNano()) / 1e9
+	}
+	writeFamily(family{name: "gosolar_exporter_last_scrape_timestamp_seconds", typ: metricGauge,
+		help: "Unix time of the last run of the queries.", samples: []sample{{value: last}}})
+
+	return b.Bytes()
+}
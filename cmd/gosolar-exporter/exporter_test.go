@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeSWIS answers queries starting with a key of results with its rows,
+// and fails queries starting with a key of failures
+func newFakeSWIS(t *testing.T, results map[string]string, failures map[string]bool) (*gosolar.Client, func() int) {
+	var mu sync.Mutex
+	var queries int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		mu.Lock()
+		queries++
+		mu.Unlock()
+
+		for prefix := range failures {
+			if strings.HasPrefix(req.Query, prefix) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		for prefix, rows := range results {
+			if strings.HasPrefix(req.Query, prefix) {
+				_, _ = w.Write([]byte(`{"results":` + rows + `}`))
+				return
+			}
+		}
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := gosolar.DefaultConfig()
+	config.Host = server.URL[len("http://"):]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.MaxRetries = 0
+	client, err := gosolar.NewClient(config)
+	require.NoError(t, err)
+
+	return client, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return queries
+	}
+}
+
+func scrape(t *testing.T, e *Exporter) string {
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "version=0.0.4")
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func newTestExporter(t *testing.T, client *gosolar.Client, cfg *Config) *Exporter {
+	require.NoError(t, cfg.applyDefaults())
+	e := NewExporter(client, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	e.now = func() time.Time { return time.Unix(1709330400, 0) }
+	return e
+}
+
+func TestExporter_Defaults(t *testing.T) {
+	client, queries := newFakeSWIS(t, map[string]string{
+		"SELECT NodeID": `[
+			{"NodeID":1,"Caption":"core-sw-01","IPAddress":"10.0.0.1","Status":1,"ResponseTime":3},
+			{"NodeID":2,"Caption":"edge \"rtr\"","IPAddress":"10.0.0.2","Status":2,"ResponseTime":null}]`,
+		"SELECT i.InterfaceID": `[{"InterfaceID":5,"NodeID":1,"NodeCaption":"core-sw-01","Caption":"Gi0/1","InPercentUtil":12.5,"OutPercentUtil":0.25}]`,
+		"SELECT v.VolumeID":    `[{"VolumeID":3,"NodeID":1,"NodeCaption":"core-sw-01","Caption":"C:\\","VolumePercentUsed":81.3}]`,
+	}, nil)
+	e := newTestExporter(t, client, &Config{})
+
+	e.Refresh(context.Background())
+	assert.Equal(t, 3, queries(), "metrics sharing a query run it once")
+
+	out := scrape(t, e)
+	for _, want := range []string{
+		"# TYPE solarwinds_node_status gauge\n",
+		`solarwinds_node_status{node_id="1",caption="core-sw-01",ip_address="10.0.0.1"} 1` + "\n",
+		`solarwinds_node_status{node_id="2",caption="edge \"rtr\"",ip_address="10.0.0.2"} 2` + "\n",
+		`solarwinds_node_response_time_milliseconds{node_id="1",caption="core-sw-01",ip_address="10.0.0.1"} 3` + "\n",
+		`solarwinds_interface_in_utilization_percent{interface_id="5",node_id="1",node="core-sw-01",caption="Gi0/1"} 12.5` + "\n",
+		`solarwinds_interface_out_utilization_percent{interface_id="5",node_id="1",node="core-sw-01",caption="Gi0/1"} 0.25` + "\n",
+		`solarwinds_volume_used_percent{volume_id="3",node_id="1",node="core-sw-01",caption="C:\\"} 81.3` + "\n",
+		"gosolar_exporter_scrapes_total 1\n",
+		`gosolar_exporter_scrape_errors_total{metric="solarwinds_node_status"} 0` + "\n",
+		"gosolar_exporter_last_scrape_timestamp_seconds 1.7093304e+09\n",
+	} {
+		assert.Contains(t, out, want)
+	}
+	assert.NotContains(t, out, `solarwinds_node_response_time_milliseconds{node_id="2"`, "null values are skipped")
+}
+
+func TestExporter_CustomMetricsAndErrors(t *testing.T) {
+	client, _ := newFakeSWIS(t, map[string]string{
+		"SELECT Name": `[{"Name":"IIS","Up":true},{"Name":"SQL","Up":false},{"Name":"SQL","Up":true}]`,
+	}, map[string]bool{"SELECT Broken": true})
+
+	off := false
+	e := newTestExporter(t, client, &Config{
+		DefaultMetrics: &off,
+		Metrics: []MetricConfig{
+			{Name: "app_up", Query: "SELECT Name, Up FROM Orion.APM.Application", Value: "Up", Labels: []string{"app=Name"}},
+			{Name: "broken_total", Type: "counter", Query: "SELECT Broken FROM Orion.Nodes", Value: "Broken"},
+		},
+	})
+
+	e.Refresh(context.Background())
+	e.Refresh(context.Background())
+
+	out := scrape(t, e)
+	assert.Contains(t, out, `app_up{app="IIS"} 1`+"\n")
+	assert.Contains(t, out, `app_up{app="SQL"} 0`+"\n")
+	assert.Equal(t, 1, strings.Count(out, `app_up{app="SQL"}`), "duplicate label sets are dropped")
+	assert.Contains(t, out, "# TYPE broken_total counter\n")
+	assert.NotContains(t, out, "\nbroken_total ")
+	assert.Contains(t, out, `gosolar_exporter_scrape_errors_total{metric="broken_total"} 2`+"\n")
+	assert.Contains(t, out, `gosolar_exporter_scrape_errors_total{metric="app_up"} 0`+"\n")
+	assert.Contains(t, out, "gosolar_exporter_scrapes_total 2\n")
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exporter.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+interval: 2m
+metrics:
+  - name: solarwinds_alerts_active
+    query: SELECT COUNT(AlertActiveID) AS Active FROM Orion.AlertActive
+    value: Active
+`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2*time.Minute, cfg.Interval)
+	assert.Equal(t, 2*time.Minute, cfg.Timeout)
+	assert.Equal(t, ":9810", cfg.Listen)
+	require.Len(t, cfg.Metrics, len(defaultMetrics)+1)
+	last := cfg.Metrics[len(cfg.Metrics)-1]
+	assert.Equal(t, metricGauge, last.Type)
+	assert.NotEmpty(t, last.Help)
+
+	invalid := []MetricConfig{
+		{Name: "bad-name", Query: "SELECT 1", Value: "x"},
+		{Name: "m", Query: "SELECT 1", Value: "x", Type: "histogram"},
+		{Name: "m", Value: "x"},
+		{Name: "m", Query: "SELECT 1"},
+		{Name: "m", Query: "SELECT 1", Value: "x", Labels: []string{"bad label=Col"}},
+	}
+	for _, m := range invalid {
+		off := false
+		cfg := &Config{DefaultMetrics: &off, Metrics: []MetricConfig{m}}
+		assert.Error(t, cfg.applyDefaults(), m.Name)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"NodeID":        "node_id",
+		"IPAddress":     "ip_address",
+		"Caption":       "caption",
+		"InPercentUtil": "in_percent_util",
+		"CPULoad":       "cpu_load",
+		"Volume2Size":   "volume2_size",
+		"Node.Caption":  "node_caption",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, snakeCase(in), in)
+	}
+}
@@ -0,0 +1,100 @@
+// Command gosolar-exporter exports the results of SWQL queries as Prometheus
+// metrics.
+//
+// By default it exports node status and response time, interface in and out
+// utilization and volume used space. Further metrics are listed in a YAML
+// config file:
+//
+//	listen: ":9810"
+//	interval: 60s
+//	metrics:
+//	  - name: solarwinds_application_availability
+//	    help: Availability of the application.
+//	    query: SELECT ApplicationID, NodeID, Name, Availability FROM Orion.APM.Application
+//	    value: Availability
+//	    labels: [ApplicationID, NodeID, Name]
+//	  - name: solarwinds_alerts_active
+//	    query: SELECT COUNT(AlertActiveID) AS Active FROM Orion.AlertActive
+//	    value: Active
+//
+// Label columns are named in snake_case (NodeID becomes node_id) unless
+// written as "label=Column". Set default_metrics to false to export only the
+// listed metrics.
+//
+// The queries run every interval, and /metrics serves the last results
+// along with the exporter's own gosolar_exporter_* scrape duration and error
+// metrics. The server is named by SOLARWINDS_HOST, SOLARWINDS_USERNAME and
+// SOLARWINDS_PASSWORD.
+//
+//	gosolar-exporter -config gosolar-exporter.yaml
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+)
+
+func main() {
+	configPath := flag.String("config", "", "path of the YAML config file (default: the default metrics only)")
+	listen := flag.String("listen", "", "address to serve metrics on (overrides the config)")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *configPath, *listen, *insecure); err != nil {
+		log.Fatalf("gosolar-exporter: %v", err)
+	}
+}
+
+func run(ctx context.Context, configPath, listen string, insecure bool) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if listen != "" {
+		cfg.Listen = listen
+	}
+
+	config := gosolar.DefaultConfig()
+	config.Host = os.Getenv("SOLARWINDS_HOST")
+	config.Username = os.Getenv("SOLARWINDS_USERNAME")
+	config.Password = os.Getenv("SOLARWINDS_PASSWORD")
+	config.InsecureSkipVerify = insecure
+
+	client, err := gosolar.NewClient(config)
+	if err != nil {
+		return err
+	}
+
+	exporter := NewExporter(client, cfg, slog.Default())
+	go func() { _ = exporter.Run(ctx) }()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	server := &http.Server{Addr: cfg.Listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+
+	slog.Info("gosolar-exporter: serving metrics", "address", cfg.Listen)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mrxinu/gosolar"
+)
+
+// Config lists the entities to generate code for
+type Config struct {
+	// Package is the package name of the generated file
+	Package string `json:"package"`
+
+	// Output is the path of the generated file
+	Output string `json:"output"`
+
+	// Snapshot is the path of the metadata snapshot to read from, or to
+	// write to when fetching live metadata
+	Snapshot string `json:"snapshot"`
+
+	// Entities are the entities to generate types for
+	Entities []EntityConfig `json:"entities"`
+}
+
+// EntityConfig selects an entity and how its type is generated
+type EntityConfig struct {
+	// Entity is the full SWIS entity name, such as Orion.Nodes
+	Entity string `json:"entity"`
+
+	// Type is the Go type name (default: the last part of the entity name)
+	Type string `json:"type"`
+
+	// Plural names the select helpers (default: Type, with an s appended
+	// unless it already ends in one)
+	Plural string `json:"plural"`
+
+	// Properties limits the generated fields to these properties
+	// (default: every property that isn't a navigation property)
+	Properties []string `json:"properties"`
+
+	// Exclude leaves these properties out
+	Exclude []string `json:"exclude"`
+
+	// CustomProperties adds the entity's custom properties as a nested struct
+	CustomProperties bool `json:"custom_properties"`
+
+	// CustomPropertiesEntity overrides the entity custom properties are read
+	// from (default: the entity name followed by CustomProperties)
+	CustomPropertiesEntity string `json:"custom_properties_entity"`
+}
+
+// loadConfig reads and checks a config file, filling in defaults
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.applyDefaults(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (cfg *Config) applyDefaults() error {
+	if cfg.Package == "" {
+		return fmt.Errorf("package is required")
+	}
+	if cfg.Output == "" {
+		cfg.Output = cfg.Package + "_gen.go"
+	}
+	if len(cfg.Entities) == 0 {
+		return fmt.Errorf("no entities listed")
+	}
+
+	for i := range cfg.Entities {
+		e := &cfg.Entities[i]
+		if e.Entity == "" {
+			return fmt.Errorf("entity %d has no name", i+1)
+		}
+		if e.Type == "" {
+			e.Type = goName(e.Entity[strings.LastIndex(e.Entity, ".")+1:])
+		}
+		if e.Plural == "" {
+			e.Plural = e.Type
+			if !strings.HasSuffix(e.Plural, "s") {
+				e.Plural += "s"
+			}
+		}
+		if e.CustomProperties && e.CustomPropertiesEntity == "" {
+			e.CustomPropertiesEntity = e.Entity + "CustomProperties"
+		}
+	}
+	return nil
+}
+
+// Snapshot is saved entity metadata, so code can be generated offline
+type Snapshot struct {
+	Entities []*gosolar.EntityMetadata `json:"entities"`
+}
+
+// lookup returns the metadata of an entity, matched case-insensitively
+func (s *Snapshot) lookup(name string) *gosolar.EntityMetadata {
+	for _, e := range s.Entities {
+		if strings.EqualFold(e.FullName, name) {
+			return e
+		}
+	}
+	return nil
+}
+
+func loadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &snap, nil
+}
+
+func saveSnapshot(path string, snap *Snapshot) error {
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mrxinu/gosolar"
+)
+
+// goTypes maps SWIS property types to Go types
+var goTypes = map[string]string{
+	"System.Boolean":  "bool",
+	"System.Byte":     "uint8",
+	"System.SByte":    "int8",
+	"System.Int16":    "int16",
+	"System.UInt16":   "uint16",
+	"System.Int32":    "int32",
+	"System.UInt32":   "uint32",
+	"System.Int64":    "int64",
+	"System.UInt64":   "uint64",
+	"System.Single":   "float32",
+	"System.Double":   "float64",
+	"System.Decimal":  "float64",
+	"System.String":   "string",
+	"System.Char":     "string",
+	"System.Guid":     "string",
+	"System.Type":     "string",
+	"System.Uri":      "string",
+	"System.DateTime": "gosolar.Time",
+	"System.Byte[]":   "[]byte",
+}
+
+// field is a generated struct field
+type field struct {
+	Name     string
+	Column   string
+	Type     string
+	Nullable bool
+	Summary  string
+}
+
+// entityType is a generated struct with its helpers
+type entityType struct {
+	EntityConfig
+	Meta             *gosolar.EntityMetadata
+	Fields           []field
+	Keys             []field
+	CustomProperties []field
+}
+
+// Generate renders the Go source for the configured entities
+func Generate(cfg *Config, snap *Snapshot) ([]byte, error) {
+	var types []entityType
+	for _, ec := range cfg.Entities {
+		meta := snap.lookup(ec.Entity)
+		if meta == nil {
+			return nil, fmt.Errorf("no metadata for %s in snapshot", ec.Entity)
+		}
+
+		t := entityType{EntityConfig: ec, Meta: meta}
+		var err error
+		if t.Fields, err = fieldsOf(meta, ec.Properties, ec.Exclude, false); err != nil {
+			return nil, err
+		}
+		for _, f := range t.Fields {
+			for _, key := range meta.Keys {
+				if strings.EqualFold(f.Column, key) {
+					t.Keys = append(t.Keys, f)
+				}
+			}
+		}
+
+		if ec.CustomPropertiesEntity != "" {
+			cp := snap.lookup(ec.CustomPropertiesEntity)
+			if cp == nil {
+				return nil, fmt.Errorf("no metadata for %s in snapshot", ec.CustomPropertiesEntity)
+			}
+			if t.CustomProperties, err = fieldsOf(cp, nil, ec.Exclude, true); err != nil {
+				return nil, err
+			}
+		}
+		types = append(types, t)
+	}
+
+	var b bytes.Buffer
+	imports := map[string]bool{
+		"context":                        true,
+		"github.com/mrxinu/gosolar":      true,
+		"github.com/mrxinu/gosolar/swql": true,
+	}
+	for _, t := range types {
+		for _, f := range append(append([]field{}, t.Fields...), t.CustomProperties...) {
+			if f.Type == "json.RawMessage" {
+				imports["encoding/json"] = true
+			}
+		}
+	}
+
+	fmt.Fprintf(&b, "// Code generated by gosolar-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", cfg.Package)
+	writeImports(&b, imports)
+
+	for _, t := range types {
+		writeType(&b, t)
+	}
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// fieldsOf picks the properties of an entity to generate fields for
+func fieldsOf(meta *gosolar.EntityMetadata, include, exclude []string, customOnly bool) ([]field, error) {
+	contains := func(list []string, name string) bool {
+		for _, s := range list {
+			if strings.EqualFold(s, name) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, name := range include {
+		if _, ok := meta.Property(name); !ok {
+			return nil, fmt.Errorf("%s has no property %s", meta.FullName, name)
+		}
+	}
+
+	var fields []field
+	used := map[string]bool{"CustomProperties": true}
+	for _, p := range meta.Properties {
+		if p.IsNavigable || contains(exclude, p.Name) {
+			continue
+		}
+		if len(include) > 0 && !contains(include, p.Name) {
+			continue
+		}
+		if customOnly && (p.IsKey || p.IsInherited) {
+			continue
+		}
+
+		name := goName(p.Name)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", goName(p.Name), i)
+		}
+		used[name] = true
+
+		typ, ok := goTypes[p.Type]
+		if !ok {
+			typ = "json.RawMessage"
+		}
+		nullable := p.IsNullable && !p.IsKey
+		if nullable && typ != "gosolar.Time" && typ != "json.RawMessage" && typ != "[]byte" {
+			typ = "*" + typ
+		}
+
+		fields = append(fields, field{
+			Name:     name,
+			Column:   p.Name,
+			Type:     typ,
+			Nullable: nullable,
+			Summary:  oneLine(p.Summary),
+		})
+	}
+	return fields, nil
+}
+
+func writeImports(b *bytes.Buffer, imports map[string]bool) {
+	var std, other []string
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	b.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(b, "\t%q\n", path)
+	}
+	b.WriteString("\n")
+	for _, path := range other {
+		fmt.Fprintf(b, "\t%q\n", path)
+	}
+	b.WriteString(")\n\n")
+}
+
+func writeType(b *bytes.Buffer, t entityType) {
+	name := t.Type
+	entity := t.Meta.FullName
+
+	fmt.Fprintf(b, "// %sEntity is the SWIS entity %s is generated from\n", name, name)
+	fmt.Fprintf(b, "const %sEntity = %q\n\n", name, entity)
+
+	if summary := oneLine(t.Meta.Summary); summary != "" {
+		fmt.Fprintf(b, "// %s is a row of %s: %s\n", name, entity, summary)
+	} else {
+		fmt.Fprintf(b, "// %s is a row of %s\n", name, entity)
+	}
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	writeFields(b, t.Fields)
+	if len(t.CustomProperties) > 0 {
+		fmt.Fprintf(b, "\tCustomProperties %sCustomProperties `json:\"CustomProperties\" swis:\"CustomProperties\"`\n", name)
+	}
+	b.WriteString("}\n\n")
+
+	if len(t.CustomProperties) > 0 {
+		fmt.Fprintf(b, "// %sCustomProperties holds the custom properties of %s\n", name, entity)
+		fmt.Fprintf(b, "type %sCustomProperties struct {\n", name)
+		writeFields(b, t.CustomProperties)
+		b.WriteString("}\n\n")
+	}
+
+	if len(t.Keys) > 0 {
+		writeURI(b, t)
+	}
+
+	fmt.Fprintf(b, "// Select%s returns a query for every generated column of %s. Add\n", t.Plural, entity)
+	fmt.Fprintf(b, "// Where, OrderBy and Limit as needed and run it with Query%s.\n", t.Plural)
+	fmt.Fprintf(b, "func Select%s() *swql.Query {\n", t.Plural)
+	b.WriteString("\treturn swql.Select(\n")
+	for _, f := range t.Fields {
+		fmt.Fprintf(b, "\t\t%q,\n", f.Column)
+	}
+	for _, f := range t.CustomProperties {
+		column := "CustomProperties." + f.Column
+		fmt.Fprintf(b, "\t\tswql.As(%q, %q),\n", column, column)
+	}
+	fmt.Fprintf(b, "\t).From(%sEntity)\n}\n\n", name)
+
+	fmt.Fprintf(b, "// Query%s runs a query built with Select%s and scans its rows\n", t.Plural, t.Plural)
+	fmt.Fprintf(b, "func Query%s(ctx context.Context, c *gosolar.Client, q *swql.Query) ([]%s, error) {\n", t.Plural, name)
+	b.WriteString(`	query, params, err := q.Build()
+	if err != nil {
+		return nil, err
+	}
+	data, err := c.QueryContext(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+`)
+	fmt.Fprintf(b, "\tvar rows []%s\n", name)
+	b.WriteString(`	if err := gosolar.Scan(data, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+`)
+}
+
+func writeFields(b *bytes.Buffer, fields []field) {
+	for _, f := range fields {
+		if f.Summary != "" {
+			fmt.Fprintf(b, "\t// %s\n", f.Summary)
+		}
+		fmt.Fprintf(b, "\t%s %s `json:\"%s\" swis:\"%s\"`\n", f.Name, f.Type, f.Column, f.Column)
+	}
+}
+
+// writeURI writes a function building the SWIS URI of an instance from its keys
+func writeURI(b *bytes.Buffer, t entityType) {
+	var params, keys []string
+	used := map[string]bool{"host": true}
+	for _, k := range t.Keys {
+		arg := paramName(k.Name)
+		for used[arg] || token.IsKeyword(arg) {
+			arg += "Key"
+		}
+		used[arg] = true
+
+		params = append(params, arg+" "+k.Type)
+		keys = append(keys, fmt.Sprintf("gosolar.Key(%q, %s)", k.Column, arg))
+	}
+
+	entity := t.Meta.FullName
+	fmt.Fprintf(b, "// %sURI returns the SWIS URI of the %s instance with the given keys\n", t.Type, entity)
+	fmt.Fprintf(b, "func %sURI(host string, %s) gosolar.URI {\n", t.Type, strings.Join(params, ", "))
+	fmt.Fprintf(b, "\treturn gosolar.NewURI(host, %q, %s)\n}\n\n", entity, strings.Join(keys, ", "))
+
+	fmt.Fprintf(b, "// URI returns the SWIS URI of the %s on host\n", t.Type)
+	receiver := strings.ToLower(t.Type[:1])
+	var fields []string
+	for _, k := range t.Keys {
+		fields = append(fields, receiver+"."+k.Name)
+	}
+	fmt.Fprintf(b, "func (%s %s) URI(host string) gosolar.URI {\n", receiver, t.Type)
+	fmt.Fprintf(b, "\treturn %sURI(host, %s)\n}\n\n", t.Type, strings.Join(fields, ", "))
+}
+
+// goName turns a SWIS name into an exported Go identifier
+func goName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// paramName turns a field name into a parameter name, lowering the leading
+// run of capitals: NodeID becomes nodeID and URI becomes uri
+func paramName(s string) string {
+	runes := []rune(s)
+	i := 0
+	for i < len(runes) && unicode.IsUpper(runes[i]) {
+		i++
+	}
+	switch {
+	case i == len(runes):
+		return strings.ToLower(s)
+	case i > 1:
+		i--
+	}
+	return strings.ToLower(string(runes[:i])) + string(runes[i:])
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
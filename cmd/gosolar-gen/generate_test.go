@@ -0,0 +1,188 @@
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{Entities: []*gosolar.EntityMetadata{
+		{
+			EntityInfo: gosolar.EntityInfo{FullName: "Orion.Nodes", Summary: "Managed nodes"},
+			Keys:       []string{"NodeID"},
+			Properties: []gosolar.PropertyInfo{
+				{Name: "NodeID", Type: "System.Int32", IsKey: true},
+				{Name: "Caption", Type: "System.String", IsNullable: true, Summary: "Display\nname"},
+				{Name: "IPAddress", Type: "System.String"},
+				{Name: "CPULoad", Type: "System.Int16", IsNullable: true},
+				{Name: "LastBoot", Type: "System.DateTime", IsNullable: true},
+				{Name: "Interfaces", Type: "Orion.NPM.Interfaces", IsNavigable: true},
+				{Name: "Icon", Type: "System.String"},
+			},
+		},
+		{
+			EntityInfo: gosolar.EntityInfo{FullName: "Orion.NodesCustomProperties"},
+			Keys:       []string{"NodeID"},
+			Properties: []gosolar.PropertyInfo{
+				{Name: "NodeID", Type: "System.Int32", IsKey: true},
+				{Name: "City", Type: "System.String", IsNullable: true},
+				{Name: "Rack Unit", Type: "System.Int32", IsNullable: true},
+				{Name: "Uri", Type: "System.String", IsInherited: true},
+			},
+		},
+		{
+			EntityInfo: gosolar.EntityInfo{FullName: "Orion.Pollers"},
+			Keys:       []string{"PollerID"},
+			Properties: []gosolar.PropertyInfo{
+				{Name: "PollerID", Type: "System.Int32", IsKey: true},
+				{Name: "PollerType", Type: "System.String"},
+				{Name: "Extra", Type: "System.Xml.XmlDocument", IsNullable: true},
+			},
+		},
+	}}
+}
+
+// typeCheck fails the test unless src compiles against this module's
+// gosolar and swql packages
+func typeCheck(t *testing.T, src []byte) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "orion_gen.go", src, parser.ParseComments)
+	require.NoError(t, err, "generated code must parse:\n%s", src)
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	_, err = conf.Check("orion", fset, []*ast.File{file}, nil)
+	require.NoError(t, err, "generated code must type-check:\n%s", src)
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := &Config{
+		Package: "orion",
+		Entities: []EntityConfig{
+			{Entity: "Orion.Nodes", Type: "Node", CustomProperties: true, Exclude: []string{"Icon"}},
+			{Entity: "orion.pollers"},
+		},
+	}
+	require.NoError(t, cfg.applyDefaults())
+
+	src, err := Generate(cfg, testSnapshot())
+	require.NoError(t, err)
+
+	typeCheck(t, src)
+
+	// Compare with gofmt's column alignment collapsed
+	code := strings.Join(strings.Fields(string(src)), " ")
+	for _, want := range []string{
+		"// Code generated by gosolar-gen. DO NOT EDIT.",
+		`const NodeEntity = "Orion.Nodes"`,
+		"// Node is a row of Orion.Nodes: Managed nodes",
+		"NodeID int32 `json:\"NodeID\" swis:\"NodeID\"`",
+		"// Display name",
+		"Caption *string `json:\"Caption\" swis:\"Caption\"`",
+		"IPAddress string `json:\"IPAddress\" swis:\"IPAddress\"`",
+		"CPULoad *int16 `json:\"CPULoad\" swis:\"CPULoad\"`",
+		"LastBoot gosolar.Time `json:\"LastBoot\" swis:\"LastBoot\"`",
+		"CustomProperties NodeCustomProperties `json:\"CustomProperties\" swis:\"CustomProperties\"`",
+		"RackUnit *int32 `json:\"Rack Unit\" swis:\"Rack Unit\"`",
+		"func NodeURI(host string, nodeID int32) gosolar.URI {",
+		`return gosolar.NewURI(host, "Orion.Nodes", gosolar.Key("NodeID", nodeID))`,
+		"func (n Node) URI(host string) gosolar.URI {",
+		"func SelectNodes() *swql.Query {",
+		`swql.As("CustomProperties.Rack Unit", "CustomProperties.Rack Unit"),`,
+		"func QueryNodes(ctx context.Context, c *gosolar.Client, q *swql.Query) ([]Node, error) {",
+		"type Pollers struct {",
+		"Extra json.RawMessage `json:\"Extra\" swis:\"Extra\"`",
+		"func SelectPollers() *swql.Query {",
+		`"encoding/json"`,
+	} {
+		assert.Contains(t, code, want)
+	}
+
+	for _, unwanted := range []string{"Icon", "Interfaces", "Uri string", "City2"} {
+		assert.NotContains(t, code, unwanted)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity EntityConfig
+		want   string
+	}{
+		{"unknown entity", EntityConfig{Entity: "Orion.Nope"}, "no metadata for Orion.Nope"},
+		{"unknown property", EntityConfig{Entity: "Orion.Nodes", Properties: []string{"Nope"}}, "Orion.Nodes has no property Nope"},
+		{"missing custom properties", EntityConfig{Entity: "Orion.Pollers", CustomProperties: true}, "no metadata for Orion.PollersCustomProperties"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Package: "orion", Entities: []EntityConfig{tt.entity}}
+			require.NoError(t, cfg.applyDefaults())
+
+			_, err := Generate(cfg, testSnapshot())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestRun_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snapshot.json")
+	require.NoError(t, saveSnapshot(snapPath, testSnapshot()))
+
+	configPath := filepath.Join(dir, "gosolar-gen.json")
+	require.NoError(t, os.WriteFile(configPath, []byte(`{
+		"package": "orion",
+		"snapshot": "`+filepath.ToSlash(snapPath)+`",
+		"entities": [{"entity": "Orion.Nodes", "type": "Node", "properties": ["NodeID", "Caption"]}]
+	}`), 0o644))
+
+	output := filepath.Join(dir, "nodes_gen.go")
+	require.NoError(t, run(configPath, "", output, false, false))
+
+	src, err := os.ReadFile(output)
+	require.NoError(t, err)
+	typeCheck(t, src)
+	assert.Contains(t, string(src), "Caption *string")
+	assert.NotContains(t, string(src), "IPAddress")
+}
+
+func TestLoadConfig_Defaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gosolar-gen.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"package": "orion", "entities": [{"entity": "Orion.NPM.Interfaces", "custom_properties": true}]}`), 0o644))
+
+	cfg, err := loadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, "orion_gen.go", cfg.Output)
+	assert.Equal(t, "Interfaces", cfg.Entities[0].Type)
+	assert.Equal(t, "Interfaces", cfg.Entities[0].Plural)
+	assert.Equal(t, "Orion.NPM.InterfacesCustomProperties", cfg.Entities[0].CustomPropertiesEntity)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"entities": []}`), 0o644))
+	_, err = loadConfig(path)
+	assert.ErrorContains(t, err, "package is required")
+}
+
+func TestParamName(t *testing.T) {
+	for in, want := range map[string]string{
+		"NodeID":    "nodeID",
+		"IPAddress": "ipAddress",
+		"URI":       "uri",
+		"Caption":   "caption",
+	} {
+		assert.Equal(t, want, paramName(in), in)
+	}
+}
@@ -0,0 +1,117 @@
+// Command gosolar-gen generates Go structs and query helpers from SWIS
+// entity metadata.
+//
+// The entities to generate are listed in a JSON config file:
+//
+//	{
+//	  "package": "orion",
+//	  "output": "orion_gen.go",
+//	  "snapshot": "swis-metadata.json",
+//	  "entities": [
+//	    {"entity": "Orion.Nodes", "type": "Node", "custom_properties": true},
+//	    {"entity": "Orion.Volumes", "type": "Volume", "exclude": ["Icon"]}
+//	  ]
+//	}
+//
+// With -live, metadata is fetched from the server named by SOLARWINDS_HOST,
+// SOLARWINDS_USERNAME and SOLARWINDS_PASSWORD and saved to the snapshot.
+// Without it, the snapshot is read, so generation works offline.
+//
+//	gosolar-gen -config gosolar-gen.json -live
+//	gosolar-gen -config gosolar-gen.json
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+)
+
+func main() {
+	configPath := flag.String("config", "gosolar-gen.json", "path of the config file")
+	live := flag.Bool("live", false, "fetch metadata from SWIS and save it to the snapshot")
+	snapshotPath := flag.String("snapshot", "", "path of the metadata snapshot (overrides the config)")
+	output := flag.String("o", "", "path of the generated file (overrides the config)")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification with -live")
+	flag.Parse()
+
+	if err := run(*configPath, *snapshotPath, *output, *live, *insecure); err != nil {
+		log.Fatalf("gosolar-gen: %v", err)
+	}
+}
+
+func run(configPath, snapshotPath, output string, live, insecure bool) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	if snapshotPath != "" {
+		cfg.Snapshot = snapshotPath
+	}
+	if output != "" {
+		cfg.Output = output
+	}
+
+	var snap *Snapshot
+	if live {
+		if snap, err = fetchSnapshot(cfg, insecure); err != nil {
+			return err
+		}
+		if cfg.Snapshot != "" {
+			if err := saveSnapshot(cfg.Snapshot, snap); err != nil {
+				return err
+			}
+		}
+	} else {
+		if cfg.Snapshot == "" {
+			return fmt.Errorf("no snapshot configured; use -live to fetch metadata")
+		}
+		if snap, err = loadSnapshot(cfg.Snapshot); err != nil {
+			return err
+		}
+	}
+
+	src, err := Generate(cfg, snap)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cfg.Output, src, 0o644)
+}
+
+// fetchSnapshot reads the metadata of every configured entity from SWIS
+func fetchSnapshot(cfg *Config, insecure bool) (*Snapshot, error) {
+	config := gosolar.DefaultConfig()
+	config.Host = os.Getenv("SOLARWINDS_HOST")
+	config.Username = os.Getenv("SOLARWINDS_USERNAME")
+	config.Password = os.Getenv("SOLARWINDS_PASSWORD")
+	config.InsecureSkipVerify = insecure
+
+	client, err := gosolar.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	snap := &Snapshot{}
+	for _, e := range cfg.Entities {
+		names := []string{e.Entity}
+		if e.CustomPropertiesEntity != "" {
+			names = append(names, e.CustomPropertiesEntity)
+		}
+		for _, name := range names {
+			meta, err := client.Entity(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			snap.Entities = append(snap.Entities, meta)
+		}
+	}
+	return snap, nil
+}
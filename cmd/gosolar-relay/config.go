@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config lists the webhooks alerts are relayed to
+type Config struct {
+	// Interval is the time between polls of Orion.AlertActive (default: 30s)
+	Interval Duration `json:"interval"`
+
+	// State is the path of the watch checkpoint remembering the alerts
+	// already relayed, so a restart doesn't send them again
+	State string `json:"state"`
+
+	// DeadLetter is the path of the JSON lines file deliveries that still
+	// fail after their retries are appended to
+	DeadLetter string `json:"dead_letter"`
+
+	// Routes are the webhooks to relay to
+	Routes []RouteConfig `json:"routes"`
+}
+
+// RouteConfig is a webhook and the alerts sent to it
+type RouteConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Preset is slack, teams, pagerduty or generic. It picks the payload
+	// template unless Template or TemplateFile is set.
+	Preset string `json:"preset"`
+
+	// Template is a Go text/template producing the request body
+	Template     string `json:"template"`
+	TemplateFile string `json:"template_file"`
+
+	// Events are the changes sent: new, acknowledged and reset (default: all)
+	Events []string `json:"events"`
+
+	// MinSeverity and Severities filter on AlertSeverity* values
+	MinSeverity *int  `json:"min_severity"`
+	Severities  []int `json:"severities"`
+
+	// NodeCustomProperties only sends alerts whose node has these custom
+	// property values
+	NodeCustomProperties map[string]string `json:"node_custom_properties"`
+
+	Headers map[string]string `json:"headers"`
+
+	// Secret, or the environment variable SecretEnv names, signs each body
+	// with HMAC-SHA256
+	Secret    string `json:"secret"`
+	SecretEnv string `json:"secret_env"`
+
+	// RoutingKey is passed to templates, for PagerDuty's routing_key
+	RoutingKey string `json:"routing_key"`
+
+	// Retries is the number of retries of a failed delivery (default: 3)
+	Retries *int `json:"retries"`
+
+	// RetryDelay is the delay before the first retry, doubling after each
+	// (default: 1s)
+	RetryDelay Duration `json:"retry_delay"`
+
+	// Timeout bounds each request (default: 10s)
+	Timeout Duration `json:"timeout"`
+}
+
+// Duration is a time.Duration written as a string such as "30s"
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"30s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON writes the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// loadConfig reads and checks a config file, filling in defaults
+func loadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.applyDefaults(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+func (cfg *Config) applyDefaults() error {
+	if cfg.Interval.Duration <= 0 {
+		cfg.Interval.Duration = 30 * time.Second
+	}
+	if cfg.State == "" {
+		cfg.State = "gosolar-relay-state.json"
+	}
+	if cfg.DeadLetter == "" {
+		cfg.DeadLetter = "gosolar-relay-dead-letter.jsonl"
+	}
+	if len(cfg.Routes) == 0 {
+		return fmt.Errorf("no routes listed")
+	}
+
+	for i := range cfg.Routes {
+		r := &cfg.Routes[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("route %d", i+1)
+		}
+		if r.URL == "" {
+			return fmt.Errorf("%s has no url", r.Name)
+		}
+		if r.Preset == "" {
+			r.Preset = "generic"
+		}
+		r.Preset = strings.ToLower(r.Preset)
+		if _, ok := presets[r.Preset]; !ok {
+			return fmt.Errorf("%s: unknown preset %q", r.Name, r.Preset)
+		}
+		if len(r.Events) == 0 {
+			r.Events = []string{kindNew, kindAcknowledged, kindReset}
+		}
+		for _, e := range r.Events {
+			switch e {
+			case kindNew, kindAcknowledged, kindReset:
+			default:
+				return fmt.Errorf("%s: unknown event %q", r.Name, e)
+			}
+		}
+		if r.SecretEnv != "" && r.Secret == "" {
+			r.Secret = os.Getenv(r.SecretEnv)
+		}
+		if r.Retries == nil {
+			retries := 3
+			r.Retries = &retries
+		}
+		if r.RetryDelay.Duration <= 0 {
+			r.RetryDelay.Duration = time.Second
+		}
+		if r.Timeout.Duration <= 0 {
+			r.Timeout.Duration = 10 * time.Second
+		}
+	}
+	return nil
+}
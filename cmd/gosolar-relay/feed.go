@@ -0,0 +1,56 @@
+package main
+
+import (
+	"time"
+
+	"github.com/mrxinu/gosolar"
+)
+
+// The changes an active alert goes through
+const (
+	kindNew          = "new"
+	kindAcknowledged = "acknowledged"
+	kindReset        = "reset"
+)
+
+// Notification is an alert change, the data payload templates are executed
+// with
+type Notification struct {
+	// Kind is new, acknowledged or reset
+	Kind  string        `json:"kind"`
+	Alert gosolar.Alert `json:"alert"`
+
+	// Time is when the relay saw the change
+	Time time.Time `json:"time"`
+
+	// Node holds the custom properties of the alert's node that routes
+	// filter on
+	Node map[string]interface{} `json:"node,omitempty"`
+
+	// Route is the route the notification is rendered for
+	Route RouteInfo `json:"route"`
+}
+
+// RouteInfo is the part of a route templates can see
+type RouteInfo struct {
+	Name       string `json:"name"`
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// notificationKinds maps the alert changes a watcher delivers to the kinds
+// routes subscribe to
+var notificationKinds = map[gosolar.ChangeKind]string{
+	gosolar.ChangeAlert:             kindNew,
+	gosolar.ChangeAlertAcknowledged: kindAcknowledged,
+	gosolar.ChangeAlertReset:        kindReset,
+}
+
+// newNotification returns the notification for an alert change, or false
+// if the change isn't about an alert
+func newNotification(c gosolar.Change, now time.Time) (Notification, bool) {
+	kind, ok := notificationKinds[c.Kind]
+	if !ok || c.Alert == nil {
+		return Notification{}, false
+	}
+	return Notification{Kind: kind, Alert: *c.Alert, Time: now}, true
+}
@@ -0,0 +1,90 @@
+// Command gosolar-relay forwards alert changes to webhooks. It watches
+// Orion.AlertActive with a gosolar.Watcher and sends each new, acknowledged
+// and reset alert to the routes whose filters it matches.
+//
+// The routes are listed in a JSON config file:
+//
+//	{
+//	  "interval": "30s",
+//	  "state": "/var/lib/gosolar-relay/state.json",
+//	  "dead_letter": "/var/lib/gosolar-relay/dead-letter.jsonl",
+//	  "routes": [
+//	    {"name": "ops", "preset": "slack", "url": "https://hooks.slack.com/services/...",
+//	     "min_severity": 4, "node_custom_properties": {"Environment": "production"}},
+//	    {"name": "oncall", "preset": "pagerduty", "url": "https://events.pagerduty.com/v2/enqueue",
+//	     "routing_key": "...", "severities": [5]},
+//	    {"name": "audit", "url": "https://audit.example.com/hook", "secret_env": "AUDIT_SECRET",
+//	     "events": ["new", "reset"], "template_file": "audit.tmpl"}
+//	  ]
+//	}
+//
+// Presets are slack, teams, pagerduty and generic, which sends the whole
+// notification as JSON. A template or template_file replaces the preset
+// with a Go text/template executed with the notification; the json,
+// severity, color, rfc3339, pagerdutySeverity, pagerdutyAction, upper and
+// lower functions are available.
+//
+// Failed deliveries are retried with a doubling delay and then appended to
+// the dead-letter file. Routes with a secret sign each body: the
+// X-Gosolar-Signature header is "sha256=" and the hex HMAC-SHA256 of the
+// X-Gosolar-Timestamp header, a dot and the body.
+//
+// The server is named by SOLARWINDS_HOST, SOLARWINDS_USERNAME and
+// SOLARWINDS_PASSWORD.
+//
+//	gosolar-relay -config gosolar-relay.json
+//	gosolar-relay -config gosolar-relay.json -once
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/mrxinu/gosolar"
+)
+
+func main() {
+	configPath := flag.String("config", "gosolar-relay.json", "path of the config file")
+	once := flag.Bool("once", false, "poll once and exit")
+	insecure := flag.Bool("insecure", false, "skip TLS certificate verification")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, *configPath, *once, *insecure); err != nil && ctx.Err() == nil {
+		log.Fatalf("gosolar-relay: %v", err)
+	}
+}
+
+func run(ctx context.Context, configPath string, once, insecure bool) error {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	config := gosolar.DefaultConfig()
+	config.Host = os.Getenv("SOLARWINDS_HOST")
+	config.Username = os.Getenv("SOLARWINDS_USERNAME")
+	config.Password = os.Getenv("SOLARWINDS_PASSWORD")
+	config.InsecureSkipVerify = insecure
+
+	client, err := gosolar.NewClient(config)
+	if err != nil {
+		return err
+	}
+
+	relay, err := NewRelay(client, cfg, slog.Default())
+	if err != nil {
+		return err
+	}
+	if once {
+		return relay.Poll(ctx)
+	}
+	return relay.Run(ctx)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/mrxinu/gosolar/swql"
+)
+
+// Signature headers sent when a route has a secret. The signature is the hex
+// HMAC-SHA256 of the timestamp, a dot and the body.
+const (
+	timestampHeader = "X-Gosolar-Timestamp"
+	signatureHeader = "X-Gosolar-Signature"
+)
+
+// Relay watches the active alerts and sends their changes to webhooks
+type Relay struct {
+	client     *gosolar.Client
+	watcher    *gosolar.Watcher
+	cfg        *Config
+	routes     []*route
+	properties []string
+	httpClient *http.Client
+	logger     *slog.Logger
+
+	// now and sleep are replaced in tests
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+
+	deadMu sync.Mutex
+}
+
+// NewRelay builds the routes of cfg
+func NewRelay(client *gosolar.Client, cfg *Config, logger *slog.Logger) (*Relay, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	r := &Relay{
+		client:     client,
+		cfg:        cfg,
+		httpClient: &http.Client{},
+		logger:     logger,
+		now:        time.Now,
+		sleep:      sleepContext,
+	}
+	r.watcher = client.NewWatcher(gosolar.WatchOptions{
+		Interval:     cfg.Interval.Duration,
+		Alerts:       true,
+		AlertUpdates: true,
+		Checkpoints:  gosolar.NewFileCheckpointStore(cfg.State),
+		OnError: func(err error) {
+			logger.Error("gosolar-relay: poll failed", "error", err)
+		},
+	})
+
+	seen := map[string]bool{}
+	for _, rc := range cfg.Routes {
+		rt, err := newRoute(rc)
+		if err != nil {
+			return nil, err
+		}
+		r.routes = append(r.routes, rt)
+		for name := range rc.NodeCustomProperties {
+			if !seen[name] {
+				seen[name] = true
+				r.properties = append(r.properties, name)
+			}
+		}
+	}
+	sort.Strings(r.properties)
+	return r, nil
+}
+
+// Run relays alert changes until ctx is done. A batch of changes is
+// checkpointed once every route each change matches has had it, so a restart
+// misses nothing but may send the changes of an interrupted batch again; if
+// handling a change fails, it is retried on the next poll.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		err := r.watcher.Run(ctx, r.handle)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.logger.Error("gosolar-relay: poll failed", "error", err)
+		if err := r.sleep(ctx, r.cfg.Interval.Duration); err != nil {
+			return err
+		}
+	}
+}
+
+// Poll relays the alert changes since the last poll once. The first poll
+// without a state file only records the alerts already active.
+func (r *Relay) Poll(ctx context.Context) error {
+	return r.watcher.Poll(ctx, r.handle)
+}
+
+// handle sends an alert change to the routes it matches
+func (r *Relay) handle(ctx context.Context, c gosolar.Change) error {
+	n, ok := newNotification(c, r.now())
+	if !ok {
+		return nil
+	}
+	if err := r.addNodeProperties(ctx, &n); err != nil {
+		return err
+	}
+
+	for _, rt := range r.routes {
+		if rt.matches(n) {
+			r.deliver(ctx, rt, n)
+		}
+	}
+	return ctx.Err()
+}
+
+// addNodeProperties fills in the node custom properties routes filter on
+func (r *Relay) addNodeProperties(ctx context.Context, n *Notification) error {
+	if len(r.properties) == 0 || n.Alert.NodeID <= 0 {
+		return nil
+	}
+
+	columns := []interface{}{"NodeID"}
+	for _, name := range r.properties {
+		columns = append(columns, name)
+	}
+	query, params, err := swql.Select(columns...).
+		From("Orion.NodesCustomProperties").
+		Where(swql.Eq("NodeID", n.Alert.NodeID)).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	res, err := r.client.QueryContext(ctx, query, params)
+	if err != nil {
+		return err
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(res, &rows); err != nil {
+		return fmt.Errorf("parse node custom properties: %w", err)
+	}
+
+	for _, row := range rows {
+		if id, ok := row["NodeID"].(float64); ok && int(id) == n.Alert.NodeID {
+			n.Node = row
+		}
+	}
+	return nil
+}
+
+// deliver sends n to rt, retrying with a doubling delay, and writes it to
+// the dead-letter file if every attempt fails
+func (r *Relay) deliver(ctx context.Context, rt *route, n Notification) {
+	body, err := rt.render(n)
+	if err != nil {
+		r.deadLetter(rt, n, nil, err)
+		return
+	}
+
+	delay := rt.RetryDelay.Duration
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		retry, err = r.post(ctx, rt, body)
+		if err == nil {
+			r.logger.Info("gosolar-relay: delivered", "route", rt.Name, "kind", n.Kind, "alert", n.Alert.AlertActiveID)
+			return
+		}
+		if !retry || attempt >= *rt.Retries || ctx.Err() != nil {
+			break
+		}
+
+		r.logger.Warn("gosolar-relay: delivery failed, retrying", "route", rt.Name, "attempt", attempt+1, "error", err)
+		if r.sleep(ctx, delay) != nil {
+			break
+		}
+		delay *= 2
+	}
+
+	r.deadLetter(rt, n, body, err)
+}
+
+// post sends one request. It reports whether a failure is worth retrying.
+func (r *Relay) post(ctx context.Context, rt *route, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, rt.Timeout.Duration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rt.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gosolar-relay")
+	for name, value := range rt.Headers {
+		req.Header.Set(name, value)
+	}
+	if rt.Secret != "" {
+		timestamp := strconv.FormatInt(r.now().Unix(), 10)
+		req.Header.Set(timestampHeader, timestamp)
+		req.Header.Set(signatureHeader, "sha256="+sign(rt.Secret, timestamp, body))
+	}
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("%s returned %s", rt.Name, resp.Status)
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, err
+}
+
+// sign returns the hex HMAC-SHA256 of timestamp, a dot and body
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deadLetter is a line of the dead-letter file
+type deadLetter struct {
+	Time    time.Time       `json:"time"`
+	Route   string          `json:"route"`
+	URL     string          `json:"url"`
+	Kind    string          `json:"kind"`
+	AlertID int             `json:"alert_active_id"`
+	Error   string          `json:"error"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Body    string          `json:"body,omitempty"`
+}
+
+// deadLetter appends an undelivered notification to the dead-letter file
+func (r *Relay) deadLetter(rt *route, n Notification, body []byte, cause error) {
+	r.logger.Error("gosolar-relay: delivery abandoned", "route", rt.Name, "kind", n.Kind, "alert", n.Alert.AlertActiveID, "error", cause)
+
+	entry := deadLetter{
+		Time:    r.now().UTC(),
+		Route:   rt.Name,
+		URL:     rt.URL,
+		Kind:    n.Kind,
+		AlertID: n.Alert.AlertActiveID,
+		Error:   cause.Error(),
+	}
+	if json.Valid(body) {
+		entry.Payload = body
+	} else {
+		entry.Body = string(body)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		r.logger.Error("gosolar-relay: encode dead letter", "error", err)
+		return
+	}
+
+	r.deadMu.Lock()
+	defer r.deadMu.Unlock()
+
+	f, err := os.OpenFile(r.cfg.DeadLetter, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		r.logger.Error("gosolar-relay: open dead-letter file", "error", err)
+		return
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		r.logger.Error("gosolar-relay: write dead letter", "error", err)
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
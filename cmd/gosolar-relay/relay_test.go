@@ -0,0 +1,341 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrion serves the active alerts and node custom properties
+type fakeOrion struct {
+	mu     sync.Mutex
+	alerts []gosolar.Alert
+	nodes  []map[string]interface{}
+}
+
+func (f *fakeOrion) set(alerts ...gosolar.Alert) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts = alerts
+}
+
+func (f *fakeOrion) client(t *testing.T) *gosolar.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var rows interface{}
+		switch {
+		case strings.Contains(req.Query, "FROM Orion.AlertActive"):
+			rows = f.alerts
+		case strings.Contains(req.Query, "FROM Orion.NodesCustomProperties"):
+			rows = f.nodes
+		default:
+			t.Errorf("unexpected query %s", req.Query)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": rows})
+	}))
+	t.Cleanup(server.Close)
+
+	config := gosolar.DefaultConfig()
+	config.Host = server.URL[len("http://"):]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	client, err := gosolar.NewClient(config)
+	require.NoError(t, err)
+	return client
+}
+
+// webhook records the requests it receives, answering with status
+type webhook struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (h *webhook) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.requests = append(h.requests, r)
+		h.bodies = append(h.bodies, string(body))
+		if h.status != 0 {
+			w.WriteHeader(h.status)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (h *webhook) received() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.bodies...)
+}
+
+var triggered = time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+
+func testAlert(id, severity, nodeID int) gosolar.Alert {
+	return gosolar.Alert{
+		AlertActiveID: id,
+		AlertObjectID: id * 10,
+		AlertID:       7,
+		AlertName:     "Node down",
+		Message:       "core-sw-01 is down",
+		Severity:      severity,
+		NodeID:        nodeID,
+		ObjectName:    "core-sw-01",
+		TriggerTime:   gosolar.NewTime(triggered),
+	}
+}
+
+func newTestRelay(t *testing.T, client *gosolar.Client, dir string, routes ...RouteConfig) *Relay {
+	cfg := &Config{
+		State:      filepath.Join(dir, "state.json"),
+		DeadLetter: filepath.Join(dir, "dead-letter.jsonl"),
+		Routes:     routes,
+	}
+	require.NoError(t, cfg.applyDefaults())
+
+	relay, err := NewRelay(client, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	relay.now = func() time.Time { return triggered.Add(time.Minute) }
+	relay.sleep = func(context.Context, time.Duration) error { return nil }
+	return relay
+}
+
+func TestRelay_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	orion := &fakeOrion{}
+	hook := &webhook{}
+	server := hook.server(t)
+
+	orion.set(testAlert(1, gosolar.AlertSeverityCritical, 5))
+	relay := newTestRelay(t, orion.client(t), dir, RouteConfig{URL: server.URL})
+
+	// The first poll only records the alerts already active
+	require.NoError(t, relay.Poll(ctx))
+	assert.Empty(t, hook.received())
+
+	orion.set(testAlert(1, gosolar.AlertSeverityCritical, 5), testAlert(2, gosolar.AlertSeverityMajor, 5))
+	require.NoError(t, relay.Poll(ctx))
+
+	acked := testAlert(2, gosolar.AlertSeverityMajor, 5)
+	acked.Acknowledged = true
+	orion.set(acked)
+	require.NoError(t, relay.Poll(ctx))
+
+	kinds := func() []string {
+		var kinds []string
+		for _, body := range hook.received() {
+			var n Notification
+			require.NoError(t, json.Unmarshal([]byte(body), &n))
+			kinds = append(kinds, fmt.Sprintf("%s %s %d", n.Kind, n.Alert.AlertName, n.Alert.AlertActiveID))
+		}
+		return kinds
+	}
+	assert.Equal(t, []string{"new Node down 2", "reset Node down 1", "acknowledged Node down 2"}, kinds())
+
+	// A restarted relay picks up from the state file without replaying
+	restarted := newTestRelay(t, orion.client(t), dir, RouteConfig{URL: server.URL})
+	orion.set()
+	require.NoError(t, restarted.Poll(ctx))
+	assert.Equal(t, []string{"new Node down 2", "reset Node down 1", "acknowledged Node down 2", "reset Node down 2"}, kinds())
+}
+
+func TestRelay_Reacknowledged(t *testing.T) {
+	ctx := context.Background()
+	orion := &fakeOrion{}
+	hook := &webhook{}
+	relay := newTestRelay(t, orion.client(t), t.TempDir(), RouteConfig{URL: hook.server(t).URL, Events: []string{kindAcknowledged}})
+
+	alert := testAlert(1, gosolar.AlertSeverityCritical, 5)
+	orion.set(alert)
+	require.NoError(t, relay.Poll(ctx))
+
+	// Acknowledged, unacknowledged and acknowledged again
+	for _, acked := range []bool{true, false, true} {
+		alert.Acknowledged = acked
+		orion.set(alert)
+		require.NoError(t, relay.Poll(ctx))
+	}
+	assert.Len(t, hook.received(), 2)
+}
+
+func TestRelay_Filters(t *testing.T) {
+	ctx := context.Background()
+	orion := &fakeOrion{nodes: []map[string]interface{}{
+		{"NodeID": 5, "Environment": "Production"},
+		{"NodeID": 6, "Environment": "Lab"},
+	}}
+	major := gosolar.AlertSeverityMajor
+
+	severe, critical, production := &webhook{}, &webhook{}, &webhook{}
+	relay := newTestRelay(t, orion.client(t), t.TempDir(),
+		RouteConfig{Name: "severe", URL: severe.server(t).URL, MinSeverity: &major},
+		RouteConfig{Name: "critical", URL: critical.server(t).URL, Severities: []int{gosolar.AlertSeverityCritical}, Events: []string{"new"}},
+		RouteConfig{Name: "production", URL: production.server(t).URL, NodeCustomProperties: map[string]string{"Environment": "production"}},
+	)
+	require.NoError(t, relay.Poll(ctx))
+
+	orion.set(
+		testAlert(1, gosolar.AlertSeverityWarning, 5),
+		testAlert(2, gosolar.AlertSeverityMajor, 6),
+		testAlert(3, gosolar.AlertSeverityCritical, 6),
+	)
+	require.NoError(t, relay.Poll(ctx))
+	orion.set()
+	require.NoError(t, relay.Poll(ctx))
+
+	assert.Len(t, severe.received(), 4)
+	assert.Len(t, critical.received(), 1)
+	assert.Len(t, production.received(), 2)
+}
+
+func TestRelay_Signature(t *testing.T) {
+	orion := &fakeOrion{}
+	hook := &webhook{}
+	relay := newTestRelay(t, orion.client(t), t.TempDir(), RouteConfig{URL: hook.server(t).URL, Secret: "s3cret", Headers: map[string]string{"X-Team": "noc"}})
+	require.NoError(t, relay.Poll(context.Background()))
+
+	orion.set(testAlert(1, gosolar.AlertSeverityCritical, 5))
+	require.NoError(t, relay.Poll(context.Background()))
+
+	require.Len(t, hook.requests, 1)
+	req := hook.requests[0]
+	timestamp := req.Header.Get(timestampHeader)
+	assert.Equal(t, "1709330460", timestamp)
+	assert.Equal(t, "sha256="+sign("s3cret", timestamp, []byte(hook.bodies[0])), req.Header.Get(signatureHeader))
+	assert.Equal(t, "noc", req.Header.Get("X-Team"))
+	assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+}
+
+func TestRelay_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+	}{
+		{"retried", http.StatusServiceUnavailable, 3},
+		{"rejected", http.StatusBadRequest, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			orion := &fakeOrion{}
+			hook := &webhook{status: tt.status}
+			retries := 2
+			relay := newTestRelay(t, orion.client(t), dir, RouteConfig{Name: "down", URL: hook.server(t).URL, Preset: "slack", Retries: &retries})
+			require.NoError(t, relay.Poll(context.Background()))
+
+			orion.set(testAlert(1, gosolar.AlertSeverityCritical, 5))
+			require.NoError(t, relay.Poll(context.Background()))
+			assert.Len(t, hook.received(), tt.attempts)
+
+			data, err := os.ReadFile(filepath.Join(dir, "dead-letter.jsonl"))
+			require.NoError(t, err)
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			require.Len(t, lines, 1)
+
+			var entry deadLetter
+			require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+			assert.Equal(t, "down", entry.Route)
+			assert.Equal(t, "new", entry.Kind)
+			assert.Equal(t, 1, entry.AlertID)
+			assert.Contains(t, entry.Error, http.StatusText(tt.status))
+			assert.JSONEq(t, hook.received()[0], string(entry.Payload))
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	alert := testAlert(1, gosolar.AlertSeverityCritical, 5)
+	n := Notification{Kind: kindReset, Alert: alert, Time: triggered}
+
+	tests := []struct {
+		preset string
+		want   string
+	}{
+		{"slack", `{"text":"*Node down* reset on core-sw-01 (critical): core-sw-01 is down"}`},
+		{"pagerduty", `{
+			"routing_key": "R0UT1NG",
+			"event_action": "resolve",
+			"dedup_key": "gosolar-10",
+			"payload": {
+				"summary": "Node down on core-sw-01",
+				"source": "core-sw-01",
+				"severity": "critical",
+				"timestamp": "2024-03-01T22:00:00Z",
+				"custom_details": {"message": "core-sw-01 is down", "alert_id": 7, "node_id": 5, "entity_uri": ""}
+			}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.preset, func(t *testing.T) {
+			rt, err := newRoute(RouteConfig{Name: tt.preset, Preset: tt.preset, RoutingKey: "R0UT1NG"})
+			require.NoError(t, err)
+			body, err := rt.render(n)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+
+	rt, err := newRoute(RouteConfig{Name: "teams", Preset: "teams"})
+	require.NoError(t, err)
+	body, err := rt.render(n)
+	require.NoError(t, err)
+	var card map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &card))
+	assert.Equal(t, "MessageCard", card["@type"])
+	assert.Equal(t, "D32F2F", card["themeColor"])
+}
+
+func TestConfig_ApplyDefaults(t *testing.T) {
+	t.Setenv("RELAY_SECRET", "from-env")
+
+	cfg := &Config{Routes: []RouteConfig{{URL: "http://hooks.example.com", SecretEnv: "RELAY_SECRET"}}}
+	require.NoError(t, cfg.applyDefaults())
+	r := cfg.Routes[0]
+	assert.Equal(t, "route 1", r.Name)
+	assert.Equal(t, "generic", r.Preset)
+	assert.Equal(t, []string{kindNew, kindAcknowledged, kindReset}, r.Events)
+	assert.Equal(t, "from-env", r.Secret)
+	assert.Equal(t, 3, *r.Retries)
+	assert.Equal(t, 30*time.Second, cfg.Interval.Duration)
+
+	invalid := []Config{
+		{},
+		{Routes: []RouteConfig{{Name: "no url"}}},
+		{Routes: []RouteConfig{{URL: "http://x", Preset: "discord"}}},
+		{Routes: []RouteConfig{{URL: "http://x", Events: []string{"flapping"}}}},
+	}
+	for _, cfg := range invalid {
+		assert.Error(t, cfg.applyDefaults())
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+)
+
+// presets are the payload templates selected by a route's preset
+var presets = map[string]string{
+	"generic": `{{ json . }}`,
+
+	"slack": `{"text": {{ printf "*%s* %s on %s (%s): %s" .Alert.AlertName .Kind .Alert.ObjectName (severity .Alert.Severity) .Alert.Message | json }}}`,
+
+	"teams": `{
+  "@type": "MessageCard",
+  "@context": "https://schema.org/extensions",
+  "themeColor": {{ color .Alert.Severity | json }},
+  "summary": {{ .Alert.AlertName | json }},
+  "title": {{ printf "%s: %s" .Alert.AlertName .Kind | json }},
+  "text": {{ .Alert.Message | json }},
+  "sections": [{"facts": [
+    {"name": "Object", "value": {{ .Alert.ObjectName | json }}},
+    {"name": "Severity", "value": {{ severity .Alert.Severity | json }}},
+    {"name": "Triggered", "value": {{ rfc3339 .Alert.TriggerTime | json }}}
+  ]}]
+}`,
+
+	"pagerduty": `{
+  "routing_key": {{ .Route.RoutingKey | json }},
+  "event_action": {{ pagerdutyAction .Kind | json }},
+  "dedup_key": {{ printf "gosolar-%d" .Alert.AlertObjectID | json }},
+  "payload": {
+    "summary": {{ printf "%s on %s" .Alert.AlertName .Alert.ObjectName | json }},
+    "source": {{ .Alert.ObjectName | json }},
+    "severity": {{ pagerdutySeverity .Alert.Severity | json }},
+    "timestamp": {{ rfc3339 .Alert.TriggerTime | json }},
+    "custom_details": {
+      "message": {{ .Alert.Message | json }},
+      "alert_id": {{ .Alert.AlertID }},
+      "node_id": {{ .Alert.NodeID }},
+      "entity_uri": {{ .Alert.EntityURI | json }}
+    }
+  }
+}`,
+}
+
+var severityNames = map[int]string{
+	gosolar.AlertSeverityInformational: "informational",
+	gosolar.AlertSeverityNotice:        "notice",
+	gosolar.AlertSeverityWarning:       "warning",
+	gosolar.AlertSeverityMinor:         "minor",
+	gosolar.AlertSeverityMajor:         "major",
+	gosolar.AlertSeverityCritical:      "critical",
+}
+
+// templateFuncs are the functions payload templates can call
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"severity": func(severity int) string {
+		if name, ok := severityNames[severity]; ok {
+			return name
+		}
+		return fmt.Sprintf("severity %d", severity)
+	},
+	"color": func(severity int) string {
+		switch {
+		case severity >= gosolar.AlertSeverityMajor:
+			return "D32F2F"
+		case severity >= gosolar.AlertSeverityWarning:
+			return "F9A825"
+		default:
+			return "1976D2"
+		}
+	},
+	"rfc3339": func(t gosolar.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	},
+	"pagerdutySeverity": func(severity int) string {
+		switch {
+		case severity >= gosolar.AlertSeverityCritical:
+			return "critical"
+		case severity == gosolar.AlertSeverityMajor:
+			return "error"
+		case severity >= gosolar.AlertSeverityWarning:
+			return "warning"
+		default:
+			return "info"
+		}
+	},
+	"pagerdutyAction": func(kind string) string {
+		switch kind {
+		case kindAcknowledged:
+			return "acknowledge"
+		case kindReset:
+			return "resolve"
+		default:
+			return "trigger"
+		}
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// route is a RouteConfig ready to match and render notifications
+type route struct {
+	RouteConfig
+	events     map[string]bool
+	severities map[int]bool
+	tmpl       *template.Template
+}
+
+func newRoute(cfg RouteConfig) (*route, error) {
+	text := presets[cfg.Preset]
+	switch {
+	case cfg.Template != "":
+		text = cfg.Template
+	case cfg.TemplateFile != "":
+		data, err := os.ReadFile(cfg.TemplateFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.Name, err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New(cfg.Name).Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Name, err)
+	}
+
+	r := &route{RouteConfig: cfg, events: map[string]bool{}, tmpl: tmpl}
+	for _, e := range cfg.Events {
+		r.events[e] = true
+	}
+	if len(cfg.Severities) > 0 {
+		r.severities = map[int]bool{}
+		for _, s := range cfg.Severities {
+			r.severities[s] = true
+		}
+	}
+	return r, nil
+}
+
+// matches reports whether n is sent to the route. n.Node must hold the node
+// custom properties the route filters on.
+func (r *route) matches(n Notification) bool {
+	if !r.events[n.Kind] {
+		return false
+	}
+	if r.MinSeverity != nil && n.Alert.Severity < *r.MinSeverity {
+		return false
+	}
+	if r.severities != nil && !r.severities[n.Alert.Severity] {
+		return false
+	}
+	for name, want := range r.NodeCustomProperties {
+		value, ok := n.Node[name]
+		if !ok || value == nil || !strings.EqualFold(fmt.Sprint(value), want) {
+			return false
+		}
+	}
+	return true
+}
+
+// render executes the route's template for n
+func (r *route) render(n Notification) ([]byte, error) {
+	n.Route = RouteInfo{Name: r.Name, RoutingKey: r.RoutingKey}
+
+	var buf bytes.Buffer
+	if err := r.tmpl.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("render %s: %w", r.Name, err)
+	}
+	return buf.Bytes(), nil
+}
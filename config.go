@@ -1,15 +1,43 @@
 package gosolar
 
 import (
+	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// DefaultPort is the port SWIS listens on for its REST API
+const DefaultPort = 17778
+
+// DefaultAPIPath is the path of the SWIS v3 JSON endpoint
+const DefaultAPIPath = "/SolarWinds/InformationService/v3/Json/"
+
 // Config holds configuration options for the SolarWinds client
 type Config struct {
-	// Host is the SolarWinds server hostname or IP
+	// Host is the SolarWinds server hostname or IP. It may include a port
+	// ("orion:8443") and IPv6 literals may be given with or without brackets.
 	Host string
 
+	// Port overrides the SWIS port when Host doesn't include one (default: 17778)
+	Port int
+
+	// APIPath overrides the path of the SWIS JSON endpoint
+	// (default: /SolarWinds/InformationService/v3/Json/)
+	APIPath string
+
+	// PlainHTTP connects over http instead of https, for local stand-ins
+	PlainHTTP bool
+
+	// BaseURL is the full URL of the SWIS JSON endpoint, for example when SWIS
+	// is published behind a reverse proxy. When set, it takes precedence over
+	// Host, Port, APIPath and PlainHTTP.
+	BaseURL string
+
 	// Username for authentication
 	Username string
 
@@ -19,7 +47,25 @@ type Config struct {
 	// InsecureSkipVerify controls whether SSL certificate verification is skipped
 	InsecureSkipVerify bool
 
-	// Timeout for HTTP requests (default: 30s)
+	// HTTPClient is the client requests are sent with. Its Transport is
+	// wrapped by the middleware chain, and its Timeout, if set, bounds a
+	// whole call, retries included. InsecureSkipVerify and MaxIdleConns only
+	// apply if its Transport is nil, in which case the client builds one as
+	// it would without an HTTPClient.
+	HTTPClient *http.Client
+
+	// Transport is the RoundTripper at the end of the middleware chain,
+	// taking precedence over the Transport of HTTPClient. Without either, the
+	// client builds an http.Transport from InsecureSkipVerify and MaxIdleConns.
+	Transport http.RoundTripper
+
+	// Middleware wraps every attempt, after retries and authentication have
+	// been applied; the first is outermost
+	Middleware []Middleware
+
+	// Timeout bounds each attempt of a request, reading the response
+	// included (default: 30s). QueryIter only waits this long for the
+	// response headers, leaving the rows to the caller's context.
 	Timeout time.Duration
 
 	// MaxIdleConns controls the maximum number of idle connections per host
@@ -28,9 +74,35 @@ type Config struct {
 	// MaxRetries for failed requests (default: 3)
 	MaxRetries int
 
-	// RetryDelay between retry attempts (default: 1s)
+	// RetryDelay is the base delay before the first retry; later retries
+	// back off exponentially with jitter (default: 1s)
 	RetryDelay time.Duration
 
+	// MaxRetryDelay caps the backoff between retry attempts (default: 30s)
+	MaxRetryDelay time.Duration
+
+	// RetryPolicy decides which failures are retried and how long to wait
+	// (optional, defaults to DefaultRetryPolicy using RetryDelay and MaxRetryDelay)
+	RetryPolicy RetryPolicy
+
+	// ServerLocation is the time zone SWIS DateTime values without an offset
+	// are read in when decoding Time and time.Time fields and values, and
+	// Time parameters and Update values are written in (default: UTC)
+	ServerLocation *time.Location
+
+	// ValidateQueries parses queries client-side before sending them and
+	// checks that every @parameter has a value, so mistakes fail fast with a
+	// line and column instead of a round trip and an HTTP 400
+	ValidateQueries bool
+
+	// Metrics receives the operation, entity, latency, response size,
+	// attempts and error type of every call (optional)
+	Metrics MetricsRecorder
+
+	// Tracer starts a span around every call and each of its attempts
+	// (optional)
+	Tracer Tracer
+
 	// Logger for structured logging (optional)
 	Logger *slog.Logger
 
@@ -45,6 +117,7 @@ func DefaultConfig() *Config {
 		MaxIdleConns:       10,
 		MaxRetries:         3,
 		RetryDelay:         time.Second,
+		MaxRetryDelay:      30 * time.Second,
 		InsecureSkipVerify: false,
 		UserAgent:          "gosolar/2.0",
 	}
@@ -52,7 +125,7 @@ func DefaultConfig() *Config {
 
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
-	if c.Host == "" {
+	if c.Host == "" && c.BaseURL == "" {
 		return NewError(ErrorTypeValidation, "config", "host is required")
 	}
 	if c.Username == "" {
@@ -67,5 +140,96 @@ func (c *Config) Validate() error {
 	if c.MaxRetries < 0 {
 		return NewError(ErrorTypeValidation, "config", "max retries cannot be negative")
 	}
+	if c.RetryDelay < 0 {
+		return NewError(ErrorTypeValidation, "config", "retry delay cannot be negative")
+	}
+	if c.MaxRetryDelay < 0 {
+		return NewError(ErrorTypeValidation, "config", "max retry delay cannot be negative")
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return NewError(ErrorTypeValidation, "config", fmt.Sprintf("invalid port: %d", c.Port))
+	}
+	if _, err := c.endpointURL(); err != nil {
+		return err
+	}
 	return nil
 }
+
+// endpointURL builds the URL of the SWIS JSON endpoint from the configuration.
+// The returned URL always ends in a slash so endpoints resolve beneath it.
+func (c *Config) endpointURL() (*url.URL, error) {
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeValidation, "config", "invalid base URL")
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, NewError(ErrorTypeValidation, "config", fmt.Sprintf("base URL scheme must be http or https, got %q", u.Scheme))
+		}
+		if u.Host == "" {
+			return nil, NewError(ErrorTypeValidation, "config", "base URL must include a host")
+		}
+		if !strings.HasSuffix(u.Path, "/") {
+			u.Path += "/"
+		}
+		return u, nil
+	}
+
+	host, err := c.hostPort()
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := "https"
+	if c.PlainHTTP {
+		scheme = "http"
+	}
+
+	path := c.APIPath
+	if path == "" {
+		path = DefaultAPIPath
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+
+	return &url.URL{Scheme: scheme, Host: host, Path: path}, nil
+}
+
+// hostPort combines Host and Port into a URL host, keeping a port already
+// present in Host and bracketing IPv6 literals
+func (c *Config) hostPort() (string, error) {
+	host := strings.TrimSpace(c.Host)
+	if strings.Contains(host, "://") || strings.ContainsAny(host, "/?#@ ") {
+		return "", NewError(ErrorTypeValidation, "config", fmt.Sprintf("invalid host %q: use BaseURL for full URLs", c.Host))
+	}
+
+	port := c.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	// A bare IPv6 literal such as ::1 contains colons but no port
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return net.JoinHostPort(ip.String(), strconv.Itoa(port)), nil
+	}
+
+	if strings.Contains(host, ":") {
+		h, p, err := net.SplitHostPort(host)
+		if err != nil {
+			return "", WrapError(err, ErrorTypeValidation, "config", fmt.Sprintf("invalid host %q", c.Host))
+		}
+		if h == "" {
+			return "", NewError(ErrorTypeValidation, "config", fmt.Sprintf("invalid host %q", c.Host))
+		}
+		if n, err := strconv.Atoi(p); err != nil || n <= 0 || n > 65535 {
+			return "", NewError(ErrorTypeValidation, "config", fmt.Sprintf("invalid port in host %q", c.Host))
+		}
+		return net.JoinHostPort(h, p), nil
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port)), nil
+}
@@ -0,0 +1,74 @@
+package gosolarotel
+
+import (
+	"context"
+
+	"github.com/mrxinu/gosolar"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsRecorder is a gosolar.MetricsRecorder recording to OpenTelemetry
+// instruments:
+//
+//   - gosolar.client.requests: calls made, with error.type for failures
+//   - gosolar.client.request.duration: latency in seconds, retries included
+//   - gosolar.client.response.size: response body bytes read
+//   - gosolar.client.retries: attempts made after the first
+//
+// Every instrument carries the gosolar.operation and gosolar.entity
+// attributes.
+type MetricsRecorder struct {
+	requests metric.Int64Counter
+	duration metric.Float64Histogram
+	size     metric.Int64Histogram
+	retries  metric.Int64Counter
+}
+
+var _ gosolar.MetricsRecorder = (*MetricsRecorder)(nil)
+
+// NewMetricsRecorder creates the instruments with a meter from provider, or
+// from the global meter provider if provider is nil
+func NewMetricsRecorder(provider metric.MeterProvider) (*MetricsRecorder, error) {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	meter := provider.Meter(instrumentationName)
+
+	var r MetricsRecorder
+	var err error
+	if r.requests, err = meter.Int64Counter("gosolar.client.requests",
+		metric.WithDescription("Calls made to SWIS"), metric.WithUnit("{request}")); err != nil {
+		return nil, err
+	}
+	if r.duration, err = meter.Float64Histogram("gosolar.client.request.duration",
+		metric.WithDescription("Time taken by calls to SWIS, retries included"), metric.WithUnit("s")); err != nil {
+		return nil, err
+	}
+	if r.size, err = meter.Int64Histogram("gosolar.client.response.size",
+		metric.WithDescription("Size of the response bodies read from SWIS"), metric.WithUnit("By")); err != nil {
+		return nil, err
+	}
+	if r.retries, err = meter.Int64Counter("gosolar.client.retries",
+		metric.WithDescription("Attempts made to SWIS after the first"), metric.WithUnit("{retry}")); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RecordRequest implements gosolar.MetricsRecorder
+func (r *MetricsRecorder) RecordRequest(ctx context.Context, m gosolar.RequestMetrics) {
+	attrs := metric.WithAttributes(OperationKey.String(m.Operation), EntityKey.String(m.Entity))
+
+	if m.ErrorType != "" {
+		r.requests.Add(ctx, 1, metric.WithAttributes(
+			OperationKey.String(m.Operation), EntityKey.String(m.Entity), ErrorTypeKey.String(string(m.ErrorType))))
+	} else {
+		r.requests.Add(ctx, 1, attrs)
+	}
+	r.duration.Record(ctx, m.Duration.Seconds(), attrs)
+	r.size.Record(ctx, m.ResponseSize, attrs)
+	if retries := m.Retries(); retries > 0 {
+		r.retries.Add(ctx, int64(retries), attrs)
+	}
+}
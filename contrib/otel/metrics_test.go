@@ -0,0 +1,63 @@
+package gosolarotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMetricsRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	recorder, err := NewMetricsRecorder(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	recorder.RecordRequest(ctx, gosolar.RequestMetrics{
+		Operation: gosolar.OperationQuery, Entity: "Orion.Nodes",
+		Duration: 50 * time.Millisecond, ResponseSize: 512, Attempts: 3,
+	})
+	recorder.RecordRequest(ctx, gosolar.RequestMetrics{
+		Operation: gosolar.OperationInvoke, Entity: "Orion.Nodes",
+		Duration: time.Second, Attempts: 1, ErrorType: gosolar.ErrorTypeVerbNotFound,
+	})
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	metrics := map[string]metricdata.Aggregation{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m.Data
+	}
+
+	requests := metrics["gosolar.client.requests"].(metricdata.Sum[int64])
+	require.Len(t, requests.DataPoints, 2)
+	for _, dp := range requests.DataPoints {
+		op, _ := dp.Attributes.Value(OperationKey)
+		errType, hasErr := dp.Attributes.Value(ErrorTypeKey)
+		if op.AsString() == gosolar.OperationInvoke {
+			assert.True(t, hasErr)
+			assert.Equal(t, "verb_not_found", errType.AsString())
+		} else {
+			assert.False(t, hasErr)
+		}
+	}
+
+	retries := metrics["gosolar.client.retries"].(metricdata.Sum[int64])
+	require.Len(t, retries.DataPoints, 1)
+	assert.Equal(t, int64(2), retries.DataPoints[0].Value)
+	entity, _ := retries.DataPoints[0].Attributes.Value(EntityKey)
+	assert.Equal(t, attribute.StringValue("Orion.Nodes"), entity)
+
+	duration := metrics["gosolar.client.request.duration"].(metricdata.Histogram[float64])
+	assert.Len(t, duration.DataPoints, 2)
+	size := metrics["gosolar.client.response.size"].(metricdata.Histogram[int64])
+	assert.Len(t, size.DataPoints, 2)
+}
@@ -0,0 +1,39 @@
+// Package gosolarotel instruments a gosolar client with OpenTelemetry.
+//
+// MetricsRecorder records every call as OpenTelemetry metrics:
+//
+//	recorder, err := gosolarotel.NewMetricsRecorder(nil)
+//	...
+//	config := gosolar.DefaultConfig()
+//	config.Metrics = recorder
+//
+// Tracer starts a span around every call, with a child span for each HTTP
+// attempt that carries the W3C trace context to the server:
+//
+//	config.Tracer = gosolarotel.NewTracer(nil, nil)
+package gosolarotel
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// instrumentationName names the meter and tracer of this package
+const instrumentationName = "github.com/mrxinu/gosolar/contrib/otel"
+
+// Attribute keys set on metrics and spans
+const (
+	OperationKey = attribute.Key("gosolar.operation")
+	EntityKey    = attribute.Key("gosolar.entity")
+	VerbKey      = attribute.Key("gosolar.verb")
+	ErrorTypeKey = attribute.Key("error.type")
+
+	// StatementKey holds the SWQL of a query with its literals redacted
+	StatementKey = attribute.Key("db.statement")
+	RowsKey      = attribute.Key("gosolar.rows")
+	AttemptKey   = attribute.Key("gosolar.attempt")
+	AttemptsKey  = attribute.Key("gosolar.attempts")
+	StatusKey    = attribute.Key("http.response.status_code")
+	MethodKey    = attribute.Key("http.request.method")
+	URLKey       = attribute.Key("url.full")
+	DBSystemKey  = attribute.Key("db.system")
+)
@@ -0,0 +1,103 @@
+package gosolarotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/mrxinu/gosolar"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer is a gosolar.Tracer creating OpenTelemetry spans. A call gets an
+// internal span named after its operation and entity, such as "Query
+// Orion.Nodes", and each attempt a client span named "HTTP POST" below it.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ gosolar.Tracer = (*Tracer)(nil)
+
+// NewTracer returns a Tracer using a tracer from provider, or from the
+// global tracer provider if provider is nil. Attempts carry the trace
+// context in headers written by propagator (default: W3C trace context).
+func NewTracer(provider trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+	return &Tracer{tracer: provider.Tracer(instrumentationName), propagator: propagator}
+}
+
+// StartCall implements gosolar.Tracer
+func (t *Tracer) StartCall(ctx context.Context, call gosolar.CallInfo) (context.Context, func(gosolar.CallResult)) {
+	name := call.Operation
+	switch {
+	case call.Verb != "":
+		name += " " + call.Entity + "." + call.Verb
+	case call.Entity != "":
+		name += " " + call.Entity
+	}
+
+	attrs := []attribute.KeyValue{DBSystemKey.String("swis"), OperationKey.String(call.Operation)}
+	if call.Entity != "" {
+		attrs = append(attrs, EntityKey.String(call.Entity))
+	}
+	if call.Verb != "" {
+		attrs = append(attrs, VerbKey.String(call.Verb))
+	}
+	if call.Statement != "" {
+		attrs = append(attrs, StatementKey.String(call.Statement))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+	return ctx, func(res gosolar.CallResult) {
+		span.SetAttributes(AttemptsKey.Int(res.Attempts))
+		if res.StatusCode != 0 {
+			span.SetAttributes(StatusKey.Int(res.StatusCode))
+		}
+		if res.Rows >= 0 {
+			span.SetAttributes(RowsKey.Int(res.Rows))
+		}
+		setError(span, res.Err)
+		span.End()
+	}
+}
+
+// StartAttempt implements gosolar.Tracer
+func (t *Tracer) StartAttempt(req *http.Request, attempt int) (*http.Request, func(int, error)) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(MethodKey.String(req.Method), URLKey.String(req.URL.String()), AttemptKey.Int(attempt)))
+
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, func(statusCode int, err error) {
+		if statusCode != 0 {
+			span.SetAttributes(StatusKey.Int(statusCode))
+		}
+		setError(span, err)
+		span.End()
+	}
+}
+
+// setError marks span as failed with the type of a *gosolar.Error
+func setError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	var swErr *gosolar.Error
+	if errors.As(err, &swErr) {
+		span.SetAttributes(ErrorTypeKey.String(string(swErr.Type)))
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
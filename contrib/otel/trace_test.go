@@ -0,0 +1,111 @@
+package gosolarotel
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracer(t *testing.T) {
+	var mu sync.Mutex
+	var traceparents []string
+	var failed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		traceparents = append(traceparents, r.Header.Get("Traceparent"))
+		switch {
+		case strings.Contains(r.URL.Path, "Invoke"):
+			w.WriteHeader(http.StatusNotFound)
+		case !failed:
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			_, _ = w.Write([]byte(`{"results":[{"NodeID":1},{"NodeID":2},{"NodeID":3}]}`))
+		}
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	config := gosolar.DefaultConfig()
+	config.Host = server.URL[len("http://"):]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.RetryDelay = time.Millisecond
+	config.Tracer = NewTracer(provider, nil)
+	client, err := gosolar.NewClient(config)
+	require.NoError(t, err)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+	_, err = client.QueryContext(ctx, "SELECT NodeID FROM Orion.Nodes WHERE Caption = 'core-sw-01' AND NodeID > @min", map[string]interface{}{"min": 0})
+	require.NoError(t, err)
+	_, err = client.InvokeContext(ctx, "Orion.Nodes", "PollNow", []string{"N:1"})
+	require.Error(t, err)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 6)
+	traceID := parent.SpanContext().TraceID()
+	for _, s := range spans {
+		assert.Equal(t, traceID, s.SpanContext().TraceID(), s.Name())
+	}
+
+	// Two query attempts, the query, one invoke attempt, the invoke, the handler
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name()
+	}
+	assert.Equal(t, []string{"HTTP POST", "HTTP POST", "Query Orion.Nodes", "HTTP POST", "Invoke Orion.Nodes.PollNow", "handler"}, names)
+
+	query := spans[2]
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent().SpanID())
+	attrs := attributes(query)
+	assert.Equal(t, "SELECT NodeID FROM Orion.Nodes WHERE Caption = ? AND NodeID > @min", attrs[StatementKey].AsString())
+	assert.Equal(t, int64(3), attrs[RowsKey].AsInt64())
+	assert.Equal(t, int64(2), attrs[AttemptsKey].AsInt64())
+	assert.Equal(t, int64(200), attrs[StatusKey].AsInt64())
+	assert.Equal(t, codes.Unset, query.Status().Code)
+
+	retried := spans[0]
+	assert.Equal(t, trace.SpanKindClient, retried.SpanKind())
+	assert.Equal(t, query.SpanContext().SpanID(), retried.Parent().SpanID())
+	assert.Equal(t, int64(503), attributes(retried)[StatusKey].AsInt64())
+	assert.Equal(t, codes.Error, retried.Status().Code)
+
+	invoke := spans[4]
+	attrs = attributes(invoke)
+	assert.Equal(t, "PollNow", attrs[VerbKey].AsString())
+	assert.Equal(t, "not_found", attrs[ErrorTypeKey].AsString())
+	assert.Equal(t, codes.Error, invoke.Status().Code)
+
+	// Each attempt sent the context of its own span
+	require.Len(t, traceparents, 3)
+	for i, attempt := range []sdktrace.ReadOnlySpan{spans[0], spans[1], spans[3]} {
+		sc := attempt.SpanContext()
+		assert.Equal(t, "00-"+sc.TraceID().String()+"-"+sc.SpanID().String()+"-01", traceparents[i])
+	}
+}
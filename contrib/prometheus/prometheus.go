@@ -0,0 +1,119 @@
+// Package gosolarprom records the calls a gosolar client makes as Prometheus
+// metrics.
+//
+//	recorder := gosolarprom.New(gosolarprom.Options{})
+//	prometheus.MustRegister(recorder)
+//
+//	config := gosolar.DefaultConfig()
+//	config.Metrics = recorder
+//
+// It exports gosolar_requests_total, gosolar_request_duration_seconds,
+// gosolar_response_size_bytes and gosolar_request_retries_total, labelled by
+// operation (Query, Create, Invoke, BulkUpdate, ...) and entity. The request
+// counter is also labelled by error_type, which is empty for calls that
+// succeeded.
+package gosolarprom
+
+import (
+	"context"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options configures a Recorder
+type Options struct {
+	// Namespace prefixes the metric names (default: gosolar)
+	Namespace string
+
+	// DurationBuckets are the buckets of the latency histogram, in seconds
+	// (default: prometheus.DefBuckets)
+	DurationBuckets []float64
+
+	// SizeBuckets are the buckets of the response size histogram, in bytes
+	// (default: 256 bytes to 16 MiB, growing by a factor of 4)
+	SizeBuckets []float64
+
+	// ConstLabels are added to every metric
+	ConstLabels prometheus.Labels
+}
+
+// Recorder is a gosolar.MetricsRecorder and a prometheus.Collector
+type Recorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+}
+
+var _ gosolar.MetricsRecorder = (*Recorder)(nil)
+
+// New returns a Recorder. Register it with a prometheus.Registerer to
+// export its metrics.
+func New(opts Options) *Recorder {
+	if opts.Namespace == "" {
+		opts.Namespace = "gosolar"
+	}
+	if opts.DurationBuckets == nil {
+		opts.DurationBuckets = prometheus.DefBuckets
+	}
+	if opts.SizeBuckets == nil {
+		opts.SizeBuckets = prometheus.ExponentialBuckets(256, 4, 8)
+	}
+
+	labels := []string{"operation", "entity"}
+	return &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "requests_total",
+			Help:        "Calls made to SWIS, by outcome.",
+			ConstLabels: opts.ConstLabels,
+		}, append(labels, "error_type")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Time taken by calls to SWIS, retries included.",
+			Buckets:     opts.DurationBuckets,
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "response_size_bytes",
+			Help:        "Size of the response bodies read from SWIS.",
+			Buckets:     opts.SizeBuckets,
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "request_retries_total",
+			Help:        "Attempts made to SWIS after the first.",
+			ConstLabels: opts.ConstLabels,
+		}, labels),
+	}
+}
+
+// RecordRequest implements gosolar.MetricsRecorder
+func (r *Recorder) RecordRequest(ctx context.Context, m gosolar.RequestMetrics) {
+	r.requests.WithLabelValues(m.Operation, m.Entity, string(m.ErrorType)).Inc()
+	r.duration.WithLabelValues(m.Operation, m.Entity).Observe(m.Duration.Seconds())
+	r.size.WithLabelValues(m.Operation, m.Entity).Observe(float64(m.ResponseSize))
+	if retries := m.Retries(); retries > 0 {
+		r.retries.WithLabelValues(m.Operation, m.Entity).Add(float64(retries))
+	}
+}
+
+// Describe implements prometheus.Collector
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.requests.Describe(ch)
+	r.duration.Describe(ch)
+	r.size.Describe(ch)
+	r.retries.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.requests.Collect(ch)
+	r.duration.Collect(ch)
+	r.size.Collect(ch)
+	r.retries.Collect(ch)
+}
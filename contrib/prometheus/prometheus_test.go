@@ -0,0 +1,45 @@
+package gosolarprom
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrxinu/gosolar"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	recorder := New(Options{DurationBuckets: []float64{0.1, 1}, SizeBuckets: []float64{1024}})
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(recorder))
+
+	ctx := context.Background()
+	recorder.RecordRequest(ctx, gosolar.RequestMetrics{
+		Operation: gosolar.OperationQuery, Entity: "Orion.Nodes",
+		Duration: 50 * time.Millisecond, ResponseSize: 512, Attempts: 3,
+	})
+	recorder.RecordRequest(ctx, gosolar.RequestMetrics{
+		Operation: gosolar.OperationCreate, Entity: "Orion.Pollers",
+		Duration: 2 * time.Second, Attempts: 1, ErrorType: gosolar.ErrorTypeValidation,
+	})
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP gosolar_requests_total Calls made to SWIS, by outcome.
+# TYPE gosolar_requests_total counter
+gosolar_requests_total{entity="Orion.Nodes",error_type="",operation="Query"} 1
+gosolar_requests_total{entity="Orion.Pollers",error_type="validation",operation="Create"} 1
+# HELP gosolar_request_retries_total Attempts made to SWIS after the first.
+# TYPE gosolar_request_retries_total counter
+gosolar_request_retries_total{entity="Orion.Nodes",operation="Query"} 2
+`), "gosolar_requests_total", "gosolar_request_retries_total")
+	assert.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(registry, "gosolar_request_duration_seconds", "gosolar_response_size_bytes")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
@@ -15,14 +15,44 @@ func (c *Client) BulkSetCustomPropertyContext(ctx context.Context, uris []string
 	if len(uris) == 0 {
 		return NewError(ErrorTypeValidation, "bulk_set_custom_property", "no URIs provided")
 	}
-	if name == "" {
-		return NewError(ErrorTypeValidation, "bulk_set_custom_property", "property name cannot be empty")
+	cpuris := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		cp, err := customPropertiesURI(uri)
+		if err != nil {
+			return err
+		}
+		cpuris = append(cpuris, cp)
+	}
+	return c.bulkSetCustomProperty(ctx, cpuris, name, value)
+}
+
+// BulkSetCustomPropertyURIs sets a custom property on multiple entities by parsed URI
+func (c *Client) BulkSetCustomPropertyURIs(uris []URI, name string, value interface{}) error {
+	return c.BulkSetCustomPropertyURIsContext(context.Background(), uris, name, value)
+}
+
+// BulkSetCustomPropertyURIsContext sets a custom property on multiple entities by parsed URI with context
+func (c *Client) BulkSetCustomPropertyURIsContext(ctx context.Context, uris []URI, name string, value interface{}) error {
+	if len(uris) == 0 {
+		return NewError(ErrorTypeValidation, "bulk_set_custom_property", "no URIs provided")
 	}
 
 	// Prepare URIs for custom properties endpoint
-	cpuris := make([]string, 0, len(uris))
+	cpuris := make([]URI, 0, len(uris))
 	for _, uri := range uris {
-		cpuris = append(cpuris, uri+"/CustomProperties")
+		cpuris = append(cpuris, uri.CustomProperties())
+	}
+	strs, err := uriStrings(cpuris)
+	if err != nil {
+		return err
+	}
+	return c.bulkSetCustomProperty(ctx, strs, name, value)
+}
+
+// bulkSetCustomProperty sets a custom property on the custom properties URIs
+func (c *Client) bulkSetCustomProperty(ctx context.Context, cpuris []string, name string, value interface{}) error {
+	if name == "" {
+		return NewError(ErrorTypeValidation, "bulk_set_custom_property", "property name cannot be empty")
 	}
 
 	bulkRequest := struct {
@@ -31,7 +61,7 @@ func (c *Client) BulkSetCustomPropertyContext(ctx context.Context, uris []string
 	}{
 		URIs: cpuris,
 		Properties: map[string]interface{}{
-			name: value,
+			name: localTime(value, c.location()),
 		},
 	}
 
@@ -53,6 +83,28 @@ func (c *Client) SetCustomPropertyContext(ctx context.Context, uri, name string,
 	if uri == "" {
 		return NewError(ErrorTypeValidation, "set_custom_property", "URI cannot be empty")
 	}
+	cp, err := customPropertiesURI(uri)
+	if err != nil {
+		return err
+	}
+	return c.setCustomProperty(ctx, cp, name, value)
+}
+
+// SetCustomPropertyURI sets a single custom property on an entity by parsed URI
+func (c *Client) SetCustomPropertyURI(uri URI, name string, value interface{}) error {
+	return c.SetCustomPropertyURIContext(context.Background(), uri, name, value)
+}
+
+// SetCustomPropertyURIContext sets a single custom property by parsed URI with context
+func (c *Client) SetCustomPropertyURIContext(ctx context.Context, uri URI, name string, value interface{}) error {
+	cp := uri.CustomProperties()
+	if err := cp.Validate(); err != nil {
+		return err
+	}
+	return c.setCustomProperty(ctx, cp.String(), name, value)
+}
+
+func (c *Client) setCustomProperty(ctx context.Context, cpuri, name string, value interface{}) error {
 	if name == "" {
 		return NewError(ErrorTypeValidation, "set_custom_property", "property name cannot be empty")
 	}
@@ -60,13 +112,7 @@ func (c *Client) SetCustomPropertyContext(ctx context.Context, uri, name string,
 	property := map[string]interface{}{
 		name: value,
 	}
-
-	_, err := c.PostContext(ctx, uri+"/CustomProperties", &property)
-	if err != nil {
-		return WrapError(err, ErrorTypeInternal, "set_custom_property", "failed to update custom property")
-	}
-
-	return nil
+	return c.postCustomProperties(ctx, "set_custom_property", cpuri, property, "failed to update custom property")
 }
 
 // SetCustomProperties sets multiple custom properties on an entity
@@ -79,13 +125,52 @@ func (c *Client) SetCustomPropertiesContext(ctx context.Context, uri string, pro
 	if uri == "" {
 		return NewError(ErrorTypeValidation, "set_custom_properties", "URI cannot be empty")
 	}
+	cp, err := customPropertiesURI(uri)
+	if err != nil {
+		return err
+	}
+	return c.setCustomProperties(ctx, cp, properties)
+}
+
+// SetCustomPropertiesURI sets multiple custom properties on an entity by parsed URI
+func (c *Client) SetCustomPropertiesURI(uri URI, properties map[string]interface{}) error {
+	return c.SetCustomPropertiesURIContext(context.Background(), uri, properties)
+}
+
+// SetCustomPropertiesURIContext sets multiple custom properties by parsed URI with context
+func (c *Client) SetCustomPropertiesURIContext(ctx context.Context, uri URI, properties map[string]interface{}) error {
+	cp := uri.CustomProperties()
+	if err := cp.Validate(); err != nil {
+		return err
+	}
+	return c.setCustomProperties(ctx, cp.String(), properties)
+}
+
+func (c *Client) setCustomProperties(ctx context.Context, cpuri string, properties map[string]interface{}) error {
 	if len(properties) == 0 {
 		return NewError(ErrorTypeValidation, "set_custom_properties", "no properties provided")
 	}
+	return c.postCustomProperties(ctx, "set_custom_properties", cpuri, properties, "failed to update custom properties")
+}
+
+// customPropertiesURI checks a URI string and returns the URI of its custom
+// properties, keeping the caller's text as it was written
+func customPropertiesURI(uri string) (string, error) {
+	u, err := ParseURI(uri)
+	if err != nil {
+		return "", err
+	}
+	if n := len(u.Path); n > 0 && u.Path[n-1].Name == "CustomProperties" && len(u.Path[n-1].Keys) == 0 {
+		return uri, nil
+	}
+	return uri + "/CustomProperties", nil
+}
 
-	_, err := c.PostContext(ctx, uri+"/CustomProperties", &properties)
+// postCustomProperties updates the custom properties at cpuri
+func (c *Client) postCustomProperties(ctx context.Context, op, cpuri string, properties map[string]interface{}, message string) error {
+	_, err := c.PostContext(ctx, cpuri, c.localParams(properties))
 	if err != nil {
-		return WrapError(err, ErrorTypeInternal, "set_custom_properties", "failed to update custom properties")
+		return WrapError(err, ErrorTypeInternal, op, message)
 	}
 
 	return nil
@@ -128,26 +213,15 @@ func (c *Client) CreateCustomPropertyContext(ctx context.Context, req CreateCust
 		length = 400 // Default string length
 	}
 
-	// Build the properties array as expected by SolarWinds
-	props := []interface{}{
-		req.Name,
-		req.Description,
-		string(req.Type),
-		fmt.Sprintf("%d", length),
-		"",      // default value
-		"",      // validation expression
-		"",      // units
-		"",      // format
-		"",      // tooltip
-		"",      // category
-		"",      // subcategory
-		"false", // mandatory
-		"",      // display name
+	args := Args{
+		"PropertyName": req.Name,
+		"Description":  req.Description,
+		"ValueType":    string(req.Type),
+		"Size":         length,
+		"Mandatory":    false,
 	}
 
-	endpoint := fmt.Sprintf("Invoke/%s/CreateCustomProperty", req.Entity)
-
-	_, err := c.PostContext(ctx, endpoint, &props)
+	_, err := c.callVerb(ctx, "create_custom_property", req.Entity, "CreateCustomProperty", args, createCustomPropertyArgs)
 	if err != nil {
 		return WrapError(err, ErrorTypeInternal, "create_custom_property", "failed to create custom property")
 	}
@@ -155,6 +229,25 @@ func (c *Client) CreateCustomPropertyContext(ctx context.Context, req CreateCust
 	return nil
 }
 
+// createCustomPropertyArgs is the signature of the CreateCustomProperty verb
+// shared by every *CustomProperties entity, so creating a property doesn't
+// need a metadata lookup
+var createCustomPropertyArgs = []VerbArgument{
+	{Position: 1, Name: "PropertyName", Type: "System.String"},
+	{Position: 2, Name: "Description", Type: "System.String"},
+	{Position: 3, Name: "ValueType", Type: "System.String"},
+	{Position: 4, Name: "Size", Type: "System.Int32"},
+	{Position: 5, Name: "ValidRange", Type: "System.String", IsOptional: true},
+	{Position: 6, Name: "Parser", Type: "System.String", IsOptional: true},
+	{Position: 7, Name: "Header", Type: "System.String", IsOptional: true},
+	{Position: 8, Name: "Alignment", Type: "System.String", IsOptional: true},
+	{Position: 9, Name: "Format", Type: "System.String", IsOptional: true},
+	{Position: 10, Name: "Units", Type: "System.String", IsOptional: true},
+	{Position: 11, Name: "Usages", Type: "System.Collections.Generic.Dictionary`2[System.String,System.Boolean]", IsOptional: true},
+	{Position: 12, Name: "Mandatory", Type: "System.Boolean", IsOptional: true},
+	{Position: 13, Name: "Default", Type: "System.String", IsOptional: true},
+}
+
 // Validate checks if the CreateCustomPropertyRequest is valid
 func (req *CreateCustomPropertyRequest) Validate() error {
 	if req.Entity == "" {
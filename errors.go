@@ -1,8 +1,12 @@
 package gosolar
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"strings"
 )
 
 // ErrorType represents the category of error that occurred
@@ -14,6 +18,8 @@ const (
 	ErrorTypePermission     ErrorType = "permission"
 	ErrorTypeSWQL           ErrorType = "swql"
 	ErrorTypeNotFound       ErrorType = "not_found"
+	ErrorTypeEntityNotFound ErrorType = "entity_not_found"
+	ErrorTypeVerbNotFound   ErrorType = "verb_not_found"
 	ErrorTypeValidation     ErrorType = "validation"
 	ErrorTypeInternal       ErrorType = "internal"
 )
@@ -25,7 +31,14 @@ type Error struct {
 	Endpoint   string    `json:"endpoint,omitempty"`
 	StatusCode int       `json:"status_code,omitempty"`
 	Message    string    `json:"message"`
+	Attempts   int       `json:"attempts,omitempty"`
 	Cause      error     `json:"-"`
+
+	// ExceptionType, ServerMessage and FullException are decoded from the
+	// fault body SWIS returns when it rejects a request
+	ExceptionType string `json:"exception_type,omitempty"`
+	ServerMessage string `json:"server_message,omitempty"`
+	FullException string `json:"full_exception,omitempty"`
 }
 
 // Error implements the error interface
@@ -41,10 +54,15 @@ func (e *Error) Unwrap() error {
 	return e.Cause
 }
 
-// Is implements the errors.Is interface for error type comparison
+// Is implements the errors.Is interface for error type comparison. Entity
+// and verb not found errors also match ErrorTypeNotFound.
 func (e *Error) Is(target error) bool {
 	if t, ok := target.(*Error); ok {
-		return e.Type == t.Type
+		if e.Type == t.Type {
+			return true
+		}
+		return t.Type == ErrorTypeNotFound &&
+			(e.Type == ErrorTypeEntityNotFound || e.Type == ErrorTypeVerbNotFound)
 	}
 	return false
 }
@@ -58,32 +76,96 @@ func NewError(errType ErrorType, operation, message string) *Error {
 	}
 }
 
-// NewHTTPError creates a new error from an HTTP response
+// NewHTTPError creates a new error from an HTTP response. If message is a
+// SWIS fault body, its fields are decoded into the error and used to refine
+// the error type.
 func NewHTTPError(operation, endpoint string, resp *http.Response, message string) *Error {
-	var errType ErrorType
-	switch resp.StatusCode {
+	swErr := &Error{
+		Type:       errorTypeForStatus(resp.StatusCode),
+		Operation:  operation,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+
+	if f, ok := parseFault(message); ok {
+		swErr.ExceptionType = f.ExceptionType
+		swErr.ServerMessage = f.Message
+		swErr.FullException = f.FullException
+		if f.Message != "" {
+			swErr.Message = f.Message
+		}
+		if swErr.Type != ErrorTypeAuthentication && swErr.Type != ErrorTypePermission {
+			if errType, ok := classifyFault(f); ok {
+				swErr.Type = errType
+			}
+		}
+	}
+
+	return swErr
+}
+
+// fault is the JSON body SWIS returns when it rejects a request
+type fault struct {
+	Message       string `json:"Message"`
+	ExceptionType string `json:"ExceptionType"`
+	FullException string `json:"FullException"`
+}
+
+// parseFault decodes a SWIS fault body, reporting false if body isn't one
+func parseFault(body string) (fault, bool) {
+	var f fault
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "{") {
+		return f, false
+	}
+	if err := json.Unmarshal([]byte(body), &f); err != nil {
+		return f, false
+	}
+	if f.Message == "" && f.ExceptionType == "" && f.FullException == "" {
+		return f, false
+	}
+	return f, true
+}
+
+var (
+	verbNotFoundPattern   = regexp.MustCompile(`(?i)\bverb\b.*\b(not found|does not exist|doesn't exist)`)
+	entityNotFoundPattern = regexp.MustCompile(`(?i)\bentity\b.*\b(not found|does not exist|doesn't exist)`)
+	swqlPattern           = regexp.MustCompile(`(?i)(mismatched input|no viable alternative|extraneous input|missing .+ at |cannot resolve property|in (select|from|where|order by|group by) clause)`)
+)
+
+// classifyFault picks an error type for a SWIS fault, reporting false if the
+// fault doesn't match a more specific type than the HTTP status gives
+func classifyFault(f fault) (ErrorType, bool) {
+	switch {
+	case verbNotFoundPattern.MatchString(f.Message):
+		return ErrorTypeVerbNotFound, true
+	case entityNotFoundPattern.MatchString(f.Message):
+		return ErrorTypeEntityNotFound, true
+	case strings.Contains(f.ExceptionType, "SolarWinds.Data.Query"),
+		strings.HasSuffix(f.ExceptionType, "ParserException"),
+		swqlPattern.MatchString(f.Message):
+		return ErrorTypeSWQL, true
+	}
+	return "", false
+}
+
+// errorTypeForStatus maps an HTTP status code to an error type
+func errorTypeForStatus(statusCode int) ErrorType {
+	switch statusCode {
 	case http.StatusUnauthorized:
-		errType = ErrorTypeAuthentication
+		return ErrorTypeAuthentication
 	case http.StatusForbidden:
-		errType = ErrorTypePermission
+		return ErrorTypePermission
 	case http.StatusNotFound:
-		errType = ErrorTypeNotFound
+		return ErrorTypeNotFound
 	case http.StatusBadRequest:
-		errType = ErrorTypeValidation
+		return ErrorTypeValidation
 	default:
-		if resp.StatusCode >= 500 {
-			errType = ErrorTypeInternal
-		} else {
-			errType = ErrorTypeNetwork
+		if statusCode >= 500 {
+			return ErrorTypeInternal
 		}
-	}
-
-	return &Error{
-		Type:       errType,
-		Operation:  operation,
-		Endpoint:   endpoint,
-		StatusCode: resp.StatusCode,
-		Message:    message,
+		return ErrorTypeNetwork
 	}
 }
 
@@ -96,3 +178,17 @@ func WrapError(err error, errType ErrorType, operation, message string) *Error {
 		Cause:     err,
 	}
 }
+
+// wrapCallError wraps an error from a request made for operation like
+// WrapError, but keeps the type and status code of a gosolar Error so that
+// callers can still tell an authentication failure or a missing entity from
+// other errors. Errors of other kinds get errType.
+func wrapCallError(err error, errType ErrorType, operation, message string) *Error {
+	wrapped := WrapError(err, errType, operation, message)
+	var swErr *Error
+	if errors.As(err, &swErr) {
+		wrapped.Type = swErr.Type
+		wrapped.StatusCode = swErr.StatusCode
+	}
+	return wrapped
+}
@@ -148,3 +148,95 @@ func TestNewError(t *testing.T) {
 	assert.Nil(t, err.Cause)
 	assert.Equal(t, 0, err.StatusCode)
 }
+
+func TestNewHTTPError_Fault(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		body          string
+		expectedType  ErrorType
+		exceptionType string
+		message       string
+	}{
+		{
+			name:          "swql parser error",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"Message":"mismatched input 'FORM' expecting 'FROM' in Select clause","ExceptionType":"SolarWinds.Data.Query.ParserException","FullException":"SolarWinds.Data.Query.ParserException: mismatched input\r\n   at SolarWinds.Data.Query.Parser"}`,
+			expectedType:  ErrorTypeSWQL,
+			exceptionType: "SolarWinds.Data.Query.ParserException",
+			message:       "mismatched input 'FORM' expecting 'FROM' in Select clause",
+		},
+		{
+			name:          "swql error by message",
+			statusCode:    http.StatusInternalServerError,
+			body:          `{"Message":"Cannot resolve property Captoin","ExceptionType":"SolarWinds.InformationService.Contract2.InfoServiceFaultContract"}`,
+			expectedType:  ErrorTypeSWQL,
+			exceptionType: "SolarWinds.InformationService.Contract2.InfoServiceFaultContract",
+			message:       "Cannot resolve property Captoin",
+		},
+		{
+			name:          "entity not found",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"Message":"Source entity [Orion.Nodez] not found in catalog","ExceptionType":"SolarWinds.Data.Query.InvalidQueryException"}`,
+			expectedType:  ErrorTypeEntityNotFound,
+			exceptionType: "SolarWinds.Data.Query.InvalidQueryException",
+			message:       "Source entity [Orion.Nodez] not found in catalog",
+		},
+		{
+			name:          "verb not found",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"Message":"Verb Orion.Nodes.PollNoww not found","ExceptionType":"SolarWinds.InformationService.Verb.VerbExecutorException"}`,
+			expectedType:  ErrorTypeVerbNotFound,
+			exceptionType: "SolarWinds.InformationService.Verb.VerbExecutorException",
+			message:       "Verb Orion.Nodes.PollNoww not found",
+		},
+		{
+			name:          "generic fault keeps status classification",
+			statusCode:    http.StatusBadRequest,
+			body:          `{"Message":"Invalid value for NodeID","ExceptionType":"System.ArgumentException"}`,
+			expectedType:  ErrorTypeValidation,
+			exceptionType: "System.ArgumentException",
+			message:       "Invalid value for NodeID",
+		},
+		{
+			name:          "authentication is never reclassified",
+			statusCode:    http.StatusUnauthorized,
+			body:          `{"Message":"Entity Orion.Nodes not found","ExceptionType":"System.ServiceModel.FaultException"}`,
+			expectedType:  ErrorTypeAuthentication,
+			exceptionType: "System.ServiceModel.FaultException",
+			message:       "Entity Orion.Nodes not found",
+		},
+		{
+			name:         "non-JSON body",
+			statusCode:   http.StatusBadRequest,
+			body:         "<html>Bad Request</html>",
+			expectedType: ErrorTypeValidation,
+			message:      "<html>Bad Request</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			recorder.WriteHeader(tt.statusCode)
+
+			err := NewHTTPError("request", "Query", recorder.Result(), tt.body)
+
+			assert.Equal(t, tt.expectedType, err.Type)
+			assert.Equal(t, tt.exceptionType, err.ExceptionType)
+			assert.Equal(t, tt.message, err.Message)
+			if tt.exceptionType != "" {
+				assert.Equal(t, tt.message, err.ServerMessage)
+			}
+		})
+	}
+}
+
+func TestError_IsNotFoundRefinements(t *testing.T) {
+	notFound := &Error{Type: ErrorTypeNotFound}
+
+	assert.True(t, errors.Is(&Error{Type: ErrorTypeEntityNotFound}, notFound))
+	assert.True(t, errors.Is(&Error{Type: ErrorTypeVerbNotFound}, notFound))
+	assert.False(t, errors.Is(&Error{Type: ErrorTypeSWQL}, notFound))
+	assert.False(t, errors.Is(notFound, &Error{Type: ErrorTypeEntityNotFound}))
+}
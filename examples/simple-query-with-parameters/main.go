@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -66,17 +65,11 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	res, err := client.QueryContext(ctx, query, parameters)
+	nodes, err := gosolar.QueryAs[Node](ctx, client, query, parameters)
 	if err != nil {
 		log.Fatalf("Query failed: %v", err)
 	}
 
-	// Unmarshal results
-	var nodes []Node
-	if err := json.Unmarshal(res, &nodes); err != nil {
-		log.Fatalf("Failed to unmarshal results: %v", err)
-	}
-
 	// Display results
 	if len(nodes) == 0 {
 		fmt.Printf("No %s nodes found with status %d\n", vendor, status)
@@ -10,15 +10,18 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
-	"time"
+	"strings"
 )
 
 // Client represents a SolarWinds SWIS API client
 type Client struct {
-	config     *Config
-	baseURL    *url.URL
-	httpClient *http.Client
-	logger     *slog.Logger
+	config      *Config
+	baseURL     *url.URL
+	httpClient  *http.Client
+	logger      *slog.Logger
+	retryPolicy RetryPolicy
+	metadata    *metadataCache
+	queries     *queryDescriber
 }
 
 // NewClient creates a new SolarWinds client with the provided configuration
@@ -27,34 +30,57 @@ func NewClient(config *Config) (*Client, error) {
 		return nil, err
 	}
 
-	baseURL, err := url.Parse(fmt.Sprintf("https://%s:17778/SolarWinds/InformationService/v3/Json/", config.Host))
+	baseURL, err := config.endpointURL()
 	if err != nil {
-		return nil, WrapError(err, ErrorTypeValidation, "new_client", "invalid host URL")
+		return nil, err
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: config.InsecureSkipVerify,
-		},
-		MaxIdleConnsPerHost: config.MaxIdleConns,
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
 
-	httpClient := &http.Client{
-		Transport: transport,
-		Timeout:   config.Timeout,
+	retryPolicy := config.RetryPolicy
+	if retryPolicy == nil {
+		retryPolicy = &DefaultRetryPolicy{
+			BaseDelay: config.RetryDelay,
+			MaxDelay:  config.MaxRetryDelay,
+		}
 	}
 
-	logger := config.Logger
-	if logger == nil {
-		logger = slog.Default()
+	c := &Client{
+		config:      config,
+		baseURL:     baseURL,
+		logger:      logger,
+		retryPolicy: retryPolicy,
+		metadata:    newMetadataCache(),
+		queries:     &queryDescriber{statements: config.Tracer != nil},
 	}
 
-	return &Client{
-		config:     config,
-		baseURL:    baseURL,
-		httpClient: httpClient,
-		logger:     logger,
-	}, nil
+	// Config.Timeout bounds each attempt inside the retry middleware, so the
+	// client itself only has the timeout of a Config.HTTPClient
+	httpClient := &http.Client{}
+	if config.HTTPClient != nil {
+		copied := *config.HTTPClient
+		httpClient = &copied
+	}
+
+	base := config.Transport
+	if base == nil {
+		base = httpClient.Transport
+	}
+	if base == nil {
+		base = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				InsecureSkipVerify: config.InsecureSkipVerify,
+			},
+			MaxIdleConnsPerHost: config.MaxIdleConns,
+		}
+	}
+	httpClient.Transport = c.transport(base)
+	c.httpClient = httpClient
+
+	return c, nil
 }
 
 // NewClientLegacy creates a client using the legacy constructor signature for backward compatibility
@@ -69,63 +95,99 @@ func NewClientLegacy(host, user, pass string, ignoreSSL bool) (*Client, error) {
 }
 
 func (c *Client) doRequest(ctx context.Context, method, endpoint string, body interface{}) ([]byte, error) {
-	var reqBody io.Reader
+	resp, err := c.send(ctx, method, endpoint, body, false)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeNetwork, "response", "failed to read response body")
+	}
+	return data, nil
+}
+
+// send makes a call through the middleware chain, which retries it as
+// needed, and returns the response if its status isn't an error. Unless
+// stream is set the body has already been read into memory, so a failure
+// partway through it is retried too. The caller must close the body.
+func (c *Client) send(ctx context.Context, method, endpoint string, body interface{}, stream bool) (*http.Response, error) {
+	// Buffer the body once so every attempt can send it again from the start
+	var payload []byte
 	if body != nil {
 		var buf bytes.Buffer
 		if err := json.NewEncoder(&buf).Encode(body); err != nil {
 			return nil, WrapError(err, ErrorTypeValidation, "request", "failed to marshal request body")
 		}
-		reqBody = &buf
+		payload = buf.Bytes()
 	}
 
-	endpointURL, err := c.baseURL.Parse(endpoint)
+	endpointURL, err := c.resolve(endpoint)
 	if err != nil {
 		return nil, WrapError(err, ErrorTypeValidation, "request", "invalid endpoint")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, method, endpointURL.String(), reqBody)
+	c.logger.DebugContext(ctx, "making request", "method", method, "endpoint", endpoint)
+
+	cl := &call{
+		method:     method,
+		endpoint:   endpoint,
+		payload:    payload,
+		idempotent: isIdempotent(ctx, method, endpoint),
+		stream:     stream,
+		rows:       -1,
+	}
+	req, err := c.newRequest(context.WithValue(ctx, callKey{}, cl), method, endpointURL.String(), payload)
 	if err != nil {
-		return nil, WrapError(err, ErrorTypeNetwork, "request", "failed to create request")
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", c.config.UserAgent)
-	req.SetBasicAuth(c.config.Username, c.config.Password)
-
-	c.logger.DebugContext(ctx, "making request", "method", method, "endpoint", endpoint)
-
-	var resp *http.Response
-	var lastErr error
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, requestError(cl, nil, err)
+	}
+	if resp.StatusCode >= 400 {
+		swErr := requestError(cl, resp, nil)
+		_ = resp.Body.Close()
+		return nil, swErr
+	}
 
-	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
-		if attempt > 0 {
-			c.logger.DebugContext(ctx, "retrying request", "attempt", attempt)
-			time.Sleep(c.config.RetryDelay)
-		}
+	c.logger.DebugContext(ctx, "request completed", "status", resp.StatusCode, "attempts", cl.attempts)
+	return resp, nil
+}
 
-		resp, err = c.httpClient.Do(req)
-		if err == nil {
-			break
-		}
-		lastErr = err
+// resolve turns an endpoint into a full URL. SWIS URIs are appended to the
+// base path as they are, since resolving them as references would treat
+// swis:// as the scheme of an absolute URL, and escaped on the way out so
+// the server decodes exactly the URI it was given.
+func (c *Client) resolve(endpoint string) (*url.URL, error) {
+	if strings.HasPrefix(strings.ToLower(endpoint), URIScheme+"://") {
+		u := *c.baseURL
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + endpoint
+		u.RawPath = ""
+		return &u, nil
 	}
+	return c.baseURL.Parse(endpoint)
+}
 
-	if err != nil {
-		return nil, WrapError(lastErr, ErrorTypeNetwork, "request", "request failed after retries")
+// newRequest builds the request for a call. Its GetBody lets the retry
+// middleware send the payload again from the start.
+func (c *Client) newRequest(ctx context.Context, method, rawURL string, payload []byte) (*http.Request, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	output, err := io.ReadAll(resp.Body)
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reqBody)
 	if err != nil {
-		return nil, WrapError(err, ErrorTypeNetwork, "response", "failed to read response body")
+		return nil, WrapError(err, ErrorTypeNetwork, "request", "failed to create request")
 	}
 
-	if resp.StatusCode >= 400 {
-		return nil, NewHTTPError("request", endpoint, resp, string(output))
-	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.config.UserAgent)
 
-	c.logger.DebugContext(ctx, "request completed", "status", resp.StatusCode)
-	return output, nil
+	return req, nil
 }
 
 func (c *Client) post(endpoint string, body interface{}) ([]byte, error) {
@@ -149,12 +211,16 @@ func (c *Client) Query(query string, parameters interface{}) ([]byte, error) {
 
 // QueryContext executes a SWQL query with context
 func (c *Client) QueryContext(ctx context.Context, query string, parameters interface{}) ([]byte, error) {
+	if err := c.validateQuery(query, parameters); err != nil {
+		return nil, err
+	}
+
 	req := struct {
 		Query      string      `json:"query"`
 		Parameters interface{} `json:"parameters"`
 	}{
 		Query:      query,
-		Parameters: parameters,
+		Parameters: c.localParams(parameters),
 	}
 
 	result, err := c.PostContext(ctx, "Query", &req)
@@ -186,23 +252,20 @@ func (c *Client) QueryOne(query string, parameters interface{}) (interface{}, er
 	return c.QueryOneContext(context.Background(), query, parameters)
 }
 
-// QueryOneContext executes a query with context and returns a single value
+// QueryOneContext executes a query with context and returns the value of the
+// first column of the first row, following the order of the SELECT list. It
+// returns nil if the query returns no rows.
 func (c *Client) QueryOneContext(ctx context.Context, query string, parameters interface{}) (interface{}, error) {
-	res, err := c.QueryRowContext(ctx, query, parameters)
+	rows, err := c.queryOrderedRows(ctx, query, parameters, "query_one")
 	if err != nil {
 		return nil, err
 	}
 
-	m := make(map[string]interface{})
-	if err := json.Unmarshal(res, &m); err != nil {
-		return nil, WrapError(err, ErrorTypeInternal, "query_one", "failed to unmarshal result")
-	}
-
-	for _, v := range m {
-		return v, nil
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, nil
 	}
 
-	return nil, nil
+	return rows[0].Value(0)
 }
 
 // QueryRow executes a query and returns a single row
@@ -210,18 +273,43 @@ func (c *Client) QueryRow(query string, parameters interface{}) ([]byte, error)
 	return c.QueryRowContext(context.Background(), query, parameters)
 }
 
-// QueryRowContext executes a query with context and returns a single row
+// QueryRowContext executes a query with context and returns the first row.
+// It returns an empty object if the query returns no rows.
 func (c *Client) QueryRowContext(ctx context.Context, query string, parameters interface{}) ([]byte, error) {
-	res, err := c.QueryContext(ctx, query, parameters)
+	rows, err := c.queryRawRows(ctx, query, parameters, "query_row")
 	if err != nil {
 		return nil, err
 	}
 
-	if len(res) < 2 {
+	if len(rows) == 0 {
 		return []byte("{}"), nil
 	}
 
-	return res[1 : len(res)-1], nil
+	return rows[0], nil
+}
+
+// QueryRowStrict executes a query that must return exactly one row
+func (c *Client) QueryRowStrict(query string, parameters interface{}) ([]byte, error) {
+	return c.QueryRowStrictContext(context.Background(), query, parameters)
+}
+
+// QueryRowStrictContext executes a query with context that must return
+// exactly one row. It returns an ErrorTypeNotFound error if there are no rows
+// and an ErrorTypeValidation error if there is more than one.
+func (c *Client) QueryRowStrictContext(ctx context.Context, query string, parameters interface{}) ([]byte, error) {
+	rows, err := c.queryRawRows(ctx, query, parameters, "query_row")
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(rows) {
+	case 0:
+		return nil, NewError(ErrorTypeNotFound, "query_row", "query returned no rows")
+	case 1:
+		return rows[0], nil
+	default:
+		return nil, NewError(ErrorTypeValidation, "query_row", fmt.Sprintf("query returned %d rows, expected 1", len(rows)))
+	}
 }
 
 // QueryColumn executes a query and returns values from a single column
@@ -229,27 +317,74 @@ func (c *Client) QueryColumn(query string, parameters interface{}) ([]interface{
 	return c.QueryColumnContext(context.Background(), query, parameters)
 }
 
-// QueryColumnContext executes a query with context and returns values from a single column
+// QueryColumnContext executes a query with context and returns the values of
+// the first column of every row, following the order of the SELECT list
 func (c *Client) QueryColumnContext(ctx context.Context, query string, parameters interface{}) ([]interface{}, error) {
+	rows, err := c.queryOrderedRows(ctx, query, parameters, "query_column")
+	if err != nil {
+		return nil, err
+	}
+
+	var values []interface{}
+	for _, row := range rows {
+		if len(row) == 0 {
+			values = append(values, nil)
+			continue
+		}
+		v, err := row.Value(0)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// queryRawRows executes a query and splits the results into one raw JSON
+// value per row
+func (c *Client) queryRawRows(ctx context.Context, query string, parameters interface{}, operation string) ([]json.RawMessage, error) {
 	res, err := c.QueryContext(ctx, query, parameters)
 	if err != nil {
 		return nil, err
 	}
 
-	var rows []map[string]interface{}
+	var rows []json.RawMessage
 	if err := json.Unmarshal(res, &rows); err != nil {
-		return nil, WrapError(err, ErrorTypeInternal, "query_column", "failed to unmarshal result")
+		return nil, WrapError(err, ErrorTypeInternal, operation, "failed to unmarshal result")
 	}
+	return rows, nil
+}
 
-	var values []interface{}
-	for _, m := range rows {
-		for _, v := range m {
-			values = append(values, v)
-			break // Only take the first column
-		}
+// queryOrderedRows executes a query and decodes each row keeping the order
+// of its columns
+func (c *Client) queryOrderedRows(ctx context.Context, query string, parameters interface{}, operation string) ([]OrderedRow, error) {
+	res, err := c.QueryContext(ctx, query, parameters)
+	if err != nil {
+		return nil, err
 	}
 
-	return values, nil
+	var rows []OrderedRow
+	if err := json.Unmarshal(res, &rows); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, operation, "failed to unmarshal result")
+	}
+	return rows, nil
+}
+
+// queryScan runs a query and scans its rows into dest, reporting scan
+// errors under operation
+func (c *Client) queryScan(ctx context.Context, operation, query string, params interface{}, dest interface{}) error {
+	data, err := c.QueryContext(ctx, query, params)
+	if err != nil {
+		return err
+	}
+	if err := (&Scanner{Location: c.location()}).Scan(data, dest); err != nil {
+		if swErr, ok := err.(*Error); ok {
+			swErr.Operation = operation
+		}
+		return err
+	}
+	return nil
 }
 
 // Create creates a new entity in SolarWinds
@@ -270,9 +405,25 @@ func (c *Client) Read(uri string) ([]byte, error) {
 
 // ReadContext retrieves an entity by URI with context
 func (c *Client) ReadContext(ctx context.Context, uri string) ([]byte, error) {
+	if _, err := ParseURI(uri); err != nil {
+		return nil, err
+	}
 	return c.GetContext(ctx, uri)
 }
 
+// ReadURI retrieves an entity by parsed URI
+func (c *Client) ReadURI(uri URI) ([]byte, error) {
+	return c.ReadURIContext(context.Background(), uri)
+}
+
+// ReadURIContext retrieves an entity by parsed URI with context
+func (c *Client) ReadURIContext(ctx context.Context, uri URI) ([]byte, error) {
+	if err := uri.Validate(); err != nil {
+		return nil, err
+	}
+	return c.GetContext(ctx, uri.String())
+}
+
 // Invoke executes a SolarWinds verb on an entity
 func (c *Client) Invoke(entity, verb string, body interface{}) ([]byte, error) {
 	return c.InvokeContext(context.Background(), entity, verb, body)
@@ -291,12 +442,32 @@ func (c *Client) BulkDelete(uris []string) ([]byte, error) {
 
 // BulkDeleteContext deletes multiple entities with context
 func (c *Client) BulkDeleteContext(ctx context.Context, uris []string) ([]byte, error) {
+	if _, err := parseURIs(uris); err != nil {
+		return nil, err
+	}
 	req := map[string][]string{
 		"uris": uris,
 	}
 	return c.PostContext(ctx, "BulkDelete", req)
 }
 
+// BulkDeleteURIs deletes multiple entities by parsed URI
+func (c *Client) BulkDeleteURIs(uris []URI) ([]byte, error) {
+	return c.BulkDeleteURIsContext(context.Background(), uris)
+}
+
+// BulkDeleteURIsContext deletes multiple entities by parsed URI with context
+func (c *Client) BulkDeleteURIsContext(ctx context.Context, uris []URI) ([]byte, error) {
+	strs, err := uriStrings(uris)
+	if err != nil {
+		return nil, err
+	}
+	req := map[string][]string{
+		"uris": strs,
+	}
+	return c.PostContext(ctx, "BulkDelete", req)
+}
+
 // Delete removes an entity by URI
 func (c *Client) Delete(uri string) ([]byte, error) {
 	return c.DeleteContext(context.Background(), uri)
@@ -304,9 +475,25 @@ func (c *Client) Delete(uri string) ([]byte, error) {
 
 // DeleteContext removes an entity by URI with context
 func (c *Client) DeleteContext(ctx context.Context, uri string) ([]byte, error) {
+	if _, err := ParseURI(uri); err != nil {
+		return nil, err
+	}
 	return c.doRequest(ctx, "DELETE", uri, nil)
 }
 
+// DeleteURI removes an entity by parsed URI
+func (c *Client) DeleteURI(uri URI) ([]byte, error) {
+	return c.DeleteURIContext(context.Background(), uri)
+}
+
+// DeleteURIContext removes an entity by parsed URI with context
+func (c *Client) DeleteURIContext(ctx context.Context, uri URI) ([]byte, error) {
+	if err := uri.Validate(); err != nil {
+		return nil, err
+	}
+	return c.doRequest(ctx, "DELETE", uri.String(), nil)
+}
+
 // Update modifies an existing entity
 func (c *Client) Update(uri string, body map[string]interface{}) ([]byte, error) {
 	return c.UpdateContext(context.Background(), uri, body)
@@ -314,5 +501,21 @@ func (c *Client) Update(uri string, body map[string]interface{}) ([]byte, error)
 
 // UpdateContext modifies an existing entity with context
 func (c *Client) UpdateContext(ctx context.Context, uri string, body map[string]interface{}) ([]byte, error) {
-	return c.PostContext(ctx, uri, body)
+	if _, err := ParseURI(uri); err != nil {
+		return nil, err
+	}
+	return c.PostContext(ctx, uri, c.localParams(body))
+}
+
+// UpdateURI modifies an existing entity by parsed URI
+func (c *Client) UpdateURI(uri URI, body map[string]interface{}) ([]byte, error) {
+	return c.UpdateURIContext(context.Background(), uri, body)
+}
+
+// UpdateURIContext modifies an existing entity by parsed URI with context
+func (c *Client) UpdateURIContext(ctx context.Context, uri URI, body map[string]interface{}) ([]byte, error) {
+	if err := uri.Validate(); err != nil {
+		return nil, err
+	}
+	return c.PostContext(ctx, uri.String(), c.localParams(body))
 }
@@ -0,0 +1,430 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrxinu/gosolar/swql"
+)
+
+// MaintenanceMode is how entities are taken out of monitoring
+type MaintenanceMode string
+
+const (
+	// MaintenanceUnmanage stops polling the entities
+	MaintenanceUnmanage MaintenanceMode = "unmanage"
+
+	// MaintenanceMute keeps polling but suppresses the entities' alerts
+	MaintenanceMute MaintenanceMode = "mute"
+)
+
+// DefaultTicketProperty is the custom property tagged with the change ticket
+// of a maintenance window
+const DefaultTicketProperty = "ChangeTicket"
+
+// MaintenanceWindow is a period during which a set of entities is unmanaged
+// or muted. The entities are URIs, the rows of Selector, or both.
+type MaintenanceWindow struct {
+	URIs []URI
+
+	// Selector is a SWQL query whose first column is the URI of each entity,
+	// such as SELECT Uri FROM Orion.Nodes WHERE Vendor = @vendor
+	Selector   string
+	Parameters interface{}
+
+	From  time.Time
+	Until time.Time
+
+	// Mode is how the entities are taken out of monitoring (default: unmanage)
+	Mode MaintenanceMode
+
+	// Ticket is written to TicketProperty on every entity if set
+	Ticket         string
+	TicketProperty string
+}
+
+// MaintenanceStatus reports whether an entity is unmanaged or muted and
+// until when
+type MaintenanceStatus struct {
+	URI URI
+
+	Unmanaged      bool
+	UnmanagedFrom  Time
+	UnmanagedUntil Time
+
+	Muted      bool
+	MutedFrom  Time
+	MutedUntil Time
+}
+
+// MaintenanceService schedules, extends and cancels maintenance windows
+//
+//	uris, err := client.Maintenance().Schedule(ctx, gosolar.MaintenanceWindow{
+//		Selector: "SELECT Uri FROM Orion.Nodes WHERE Caption LIKE 'core-%'",
+//		From:     start,
+//		Until:    start.Add(2 * time.Hour),
+//		Ticket:   "CHG0012345",
+//	})
+type MaintenanceService struct {
+	client *Client
+}
+
+// Maintenance returns the maintenance service of the client
+func (c *Client) Maintenance() *MaintenanceService {
+	return &MaintenanceService{client: c}
+}
+
+// managedEntity describes an entity type that can be unmanaged
+type managedEntity struct {
+	entity string
+	prefix string // net object prefix, such as N for N:1
+}
+
+// managedEntities are the entity types that can be unmanaged, by lowercase name
+var managedEntities = map[string]managedEntity{
+	"orion.nodes":           {entity: "Orion.Nodes", prefix: "N"},
+	"orion.npm.interfaces":  {entity: "Orion.NPM.Interfaces", prefix: "I"},
+	"orion.apm.application": {entity: "Orion.APM.Application", prefix: "AA"},
+}
+
+// Schedule takes the window's entities out of monitoring from From until
+// Until and tags them with the ticket. It returns the entities affected.
+func (m *MaintenanceService) Schedule(ctx context.Context, w MaintenanceWindow) ([]URI, error) {
+	if !w.Until.After(w.From) {
+		return nil, NewError(ErrorTypeValidation, "schedule_maintenance", "until must be after from")
+	}
+
+	uris, err := m.resolve(ctx, "schedule_maintenance", w)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.apply(ctx, "schedule_maintenance", w.mode(), uris, w.From, w.Until); err != nil {
+		return nil, err
+	}
+
+	if w.Ticket != "" {
+		if err := m.client.BulkSetCustomPropertyURIsContext(ctx, uris, w.ticketProperty(), w.Ticket); err != nil {
+			return nil, wrapCallError(err, ErrorTypeInternal, "schedule_maintenance", "failed to tag entities with the change ticket")
+		}
+	}
+	return uris, nil
+}
+
+// Extend moves the end of the window's maintenance to until, keeping the
+// start of each entity's current window. It fails without changing anything
+// if an entity isn't unmanaged or muted, as the window's mode asks.
+func (m *MaintenanceService) Extend(ctx context.Context, w MaintenanceWindow, until time.Time) error {
+	uris, err := m.resolve(ctx, "extend_maintenance", w)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := m.Status(ctx, uris...)
+	if err != nil {
+		return err
+	}
+
+	state := "unmanaged"
+	if w.mode() == MaintenanceMute {
+		state = "muted"
+	}
+	var idle []string
+	for _, s := range statuses {
+		if (w.mode() == MaintenanceMute && !s.Muted) || (w.mode() != MaintenanceMute && !s.Unmanaged) {
+			idle = append(idle, s.URI.String())
+		}
+	}
+	if len(idle) > 0 {
+		return NewError(ErrorTypeValidation, "extend_maintenance",
+			fmt.Sprintf("cannot extend entities that aren't %s: %s", state, strings.Join(idle, ", ")))
+	}
+
+	// Entities sharing a start are updated together
+	byStart := map[time.Time][]URI{}
+	var starts []time.Time
+	for _, s := range statuses {
+		from := s.UnmanagedFrom.Time
+		if w.mode() == MaintenanceMute {
+			from = s.MutedFrom.Time
+		}
+		if !until.After(from) {
+			return NewError(ErrorTypeValidation, "extend_maintenance",
+				fmt.Sprintf("until is before the start of the window of %s", s.URI))
+		}
+		if _, ok := byStart[from]; !ok {
+			starts = append(starts, from)
+		}
+		byStart[from] = append(byStart[from], s.URI)
+	}
+
+	for _, from := range starts {
+		if err := m.apply(ctx, "extend_maintenance", w.mode(), byStart[from], from, until); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel ends the window's maintenance now and clears the ticket if the
+// window has one
+func (m *MaintenanceService) Cancel(ctx context.Context, w MaintenanceWindow) error {
+	uris, err := m.resolve(ctx, "cancel_maintenance", w)
+	if err != nil {
+		return err
+	}
+
+	if w.mode() == MaintenanceMute {
+		args := Args{"entityUris": uriStringList(uris)}
+		if _, err := m.client.callVerb(ctx, "cancel_maintenance", "Orion.AlertSuppression", "ResumeAlerts", args, resumeAlertsArgs); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, "cancel_maintenance", "failed to resume alerts")
+		}
+	} else {
+		for _, uri := range uris {
+			target, netObject, err := netObjectOf("cancel_maintenance", uri)
+			if err != nil {
+				return err
+			}
+			if _, err := m.client.callVerb(ctx, "cancel_maintenance", target.entity, "Remanage", Args{"netObjectId": netObject}, netObjectArgs); err != nil {
+				return wrapCallError(err, ErrorTypeInternal, "cancel_maintenance", fmt.Sprintf("failed to remanage %s", uri))
+			}
+		}
+	}
+
+	if w.Ticket != "" {
+		if err := m.client.BulkSetCustomPropertyURIsContext(ctx, uris, w.ticketProperty(), nil); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, "cancel_maintenance", "failed to clear the change ticket")
+		}
+	}
+	return nil
+}
+
+// Status reports whether each entity is unmanaged or muted, in the order
+// given
+func (m *MaintenanceService) Status(ctx context.Context, uris ...URI) ([]MaintenanceStatus, error) {
+	if len(uris) == 0 {
+		return []MaintenanceStatus{}, nil
+	}
+	if _, err := uriStrings(uris); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MaintenanceStatus, len(uris))
+	index := make(map[string]int, len(uris))
+	byEntity := map[string][]interface{}{}
+	var entities []string
+	for i, uri := range uris {
+		statuses[i].URI = uri
+		index[uri.String()] = i
+
+		if target, _, ok := managedEntityOf(uri); ok {
+			if _, seen := byEntity[target.entity]; !seen {
+				entities = append(entities, target.entity)
+			}
+			byEntity[target.entity] = append(byEntity[target.entity], uri.String())
+		}
+	}
+
+	for _, entity := range entities {
+		query, params, err := swql.Select("Uri", "Unmanaged", "UnmanageFrom", "UnmanageUntil").
+			From(entity).
+			In("Uri", byEntity[entity]...).
+			Build()
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeInternal, "maintenance_status", "failed to build status query")
+		}
+
+		var rows []struct {
+			URI           URI  `swis:"Uri"`
+			Unmanaged     bool `swis:"Unmanaged"`
+			UnmanageFrom  Time `swis:"UnmanageFrom"`
+			UnmanageUntil Time `swis:"UnmanageUntil"`
+		}
+		if err := m.client.queryScan(ctx, "maintenance_status", query, params, &rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if i, ok := index[row.URI.String()]; ok {
+				statuses[i].Unmanaged = row.Unmanaged
+				statuses[i].UnmanagedFrom = row.UnmanageFrom
+				statuses[i].UnmanagedUntil = row.UnmanageUntil
+			}
+		}
+	}
+
+	args := Args{"entityUris": uriStringList(uris)}
+	raw, err := m.client.callVerb(ctx, "maintenance_status", "Orion.AlertSuppression", "GetAlertSuppressionState", args, resumeAlertsArgs)
+	if err != nil {
+		return nil, wrapCallError(err, ErrorTypeInternal, "maintenance_status", "failed to get alert suppression state")
+	}
+
+	var states []struct {
+		EntityURI       URI  `swis:"EntityUri"`
+		SuppressionMode int  `swis:"SuppressionMode"`
+		SuppressedFrom  Time `swis:"SuppressedFrom"`
+		SuppressedUntil Time `swis:"SuppressedUntil"`
+	}
+	if err := decodeVerbResult(raw, &states, m.client.location()); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "maintenance_status", "failed to parse alert suppression state")
+	}
+	for _, state := range states {
+		if i, ok := index[state.EntityURI.String()]; ok {
+			statuses[i].Muted = state.SuppressionMode != 0
+			statuses[i].MutedFrom = state.SuppressedFrom
+			statuses[i].MutedUntil = state.SuppressedUntil
+		}
+	}
+
+	return statuses, nil
+}
+
+// apply unmanages or mutes uris from from until until
+func (m *MaintenanceService) apply(ctx context.Context, operation string, mode MaintenanceMode, uris []URI, from, until time.Time) error {
+	if mode == MaintenanceMute {
+		args := Args{
+			"entityUris":    uriStringList(uris),
+			"suppressFrom":  from,
+			"suppressUntil": until,
+		}
+		if _, err := m.client.callVerb(ctx, operation, "Orion.AlertSuppression", "SuppressAlerts", args, suppressAlertsArgs); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, operation, "failed to suppress alerts")
+		}
+		return nil
+	}
+
+	// Check every entity up front so a bad one doesn't leave the rest half done
+	targets := make([]managedEntity, len(uris))
+	netObjects := make([]string, len(uris))
+	for i, uri := range uris {
+		var err error
+		if targets[i], netObjects[i], err = netObjectOf(operation, uri); err != nil {
+			return err
+		}
+	}
+
+	for i, uri := range uris {
+		args := Args{
+			"netObjectId":  netObjects[i],
+			"unmanageTime": from,
+			"remanageTime": until,
+			"isRelative":   false,
+		}
+		if _, err := m.client.callVerb(ctx, operation, targets[i].entity, "Unmanage", args, unmanageArgs); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, operation, fmt.Sprintf("failed to unmanage %s", uri))
+		}
+	}
+	return nil
+}
+
+// resolve returns the window's URIs followed by those its selector returns,
+// without duplicates
+func (m *MaintenanceService) resolve(ctx context.Context, operation string, w MaintenanceWindow) ([]URI, error) {
+	switch w.mode() {
+	case MaintenanceUnmanage, MaintenanceMute:
+	default:
+		return nil, NewError(ErrorTypeValidation, operation, fmt.Sprintf("unknown maintenance mode %q", w.Mode))
+	}
+
+	uris := append([]URI(nil), w.URIs...)
+	if w.Selector != "" {
+		values, err := m.client.QueryColumnContext(ctx, w.Selector, w.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return nil, NewError(ErrorTypeValidation, operation, "selector must return entity URIs in its first column")
+			}
+			uri, err := ParseURI(s)
+			if err != nil {
+				return nil, err
+			}
+			uris = append(uris, uri)
+		}
+	}
+
+	seen := make(map[string]bool, len(uris))
+	unique := uris[:0]
+	for _, uri := range uris {
+		if err := uri.Validate(); err != nil {
+			return nil, err
+		}
+		if key := uri.String(); !seen[key] {
+			seen[key] = true
+			unique = append(unique, uri)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, NewError(ErrorTypeValidation, operation, "maintenance window has no entities")
+	}
+	return unique, nil
+}
+
+func (w *MaintenanceWindow) mode() MaintenanceMode {
+	if w.Mode == "" {
+		return MaintenanceUnmanage
+	}
+	return w.Mode
+}
+
+func (w *MaintenanceWindow) ticketProperty() string {
+	if w.TicketProperty == "" {
+		return DefaultTicketProperty
+	}
+	return w.TicketProperty
+}
+
+// nodeChildren maps the node navigations SWIS uses in interface and
+// application URIs, such as .../NodeID=1/Interfaces/InterfaceID=5, to their
+// entity types
+var nodeChildren = map[string]string{
+	"interfaces":   "orion.npm.interfaces",
+	"applications": "orion.apm.application",
+}
+
+// managedEntityOf returns the entity type and ID of an entity that can be
+// unmanaged
+func managedEntityOf(uri URI) (managedEntity, string, bool) {
+	switch {
+	case len(uri.Path) == 0 && len(uri.Keys) == 1:
+		target, ok := managedEntities[strings.ToLower(uri.Entity)]
+		return target, uri.Keys[0].Value, ok
+	case len(uri.Path) == 1 && len(uri.Path[0].Keys) == 1 && strings.EqualFold(uri.Entity, "Orion.Nodes"):
+		target, ok := managedEntities[nodeChildren[strings.ToLower(uri.Path[0].Name)]]
+		return target, uri.Path[0].Keys[0].Value, ok
+	}
+	return managedEntity{}, "", false
+}
+
+// netObjectOf returns the entity type and net object ID, such as N:1, of an
+// entity that can be unmanaged
+func netObjectOf(operation string, uri URI) (managedEntity, string, error) {
+	target, id, ok := managedEntityOf(uri)
+	if !ok {
+		return managedEntity{}, "", NewError(ErrorTypeValidation, operation, fmt.Sprintf("%s can't be unmanaged", uri))
+	}
+	return target, target.prefix + ":" + id, nil
+}
+
+func uriStringList(uris []URI) []string {
+	strs := make([]string, len(uris))
+	for i, uri := range uris {
+		strs[i] = uri.String()
+	}
+	return strs
+}
+
+// suppressAlertsArgs is the signature of Orion.AlertSuppression.SuppressAlerts
+var suppressAlertsArgs = []VerbArgument{
+	{Position: 1, Name: "entityUris", Type: "System.String[]"},
+	{Position: 2, Name: "suppressFrom", Type: "System.DateTime"},
+	{Position: 3, Name: "suppressUntil", Type: "System.DateTime", IsOptional: true},
+}
+
+// resumeAlertsArgs is the signature of the Orion.AlertSuppression verbs
+// taking only a list of entity URIs
+var resumeAlertsArgs = []VerbArgument{
+	{Position: 1, Name: "entityUris", Type: "System.String[]"},
+}
@@ -0,0 +1,191 @@
+package gosolar
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	maintenanceFrom  = time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	maintenanceUntil = maintenanceFrom.Add(2 * time.Hour)
+)
+
+func TestMaintenanceService_ScheduleUnmanage(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"SELECT Uri FROM Orion.Nodes": `[{"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=1"},{"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=2"}]`,
+	})
+	client := newTestClient(t, server.Server)
+
+	uris, err := client.Maintenance().Schedule(context.Background(), MaintenanceWindow{
+		URIs: []URI{
+			MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1"),
+			MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1/Interfaces/InterfaceID=5"),
+		},
+		Selector: "SELECT Uri FROM Orion.Nodes WHERE Vendor = 'Cisco'",
+		From:     maintenanceFrom,
+		Until:    maintenanceUntil,
+		Ticket:   "CHG0012345",
+	})
+	require.NoError(t, err)
+	require.Len(t, uris, 3)
+
+	assert.Equal(t, []string{
+		`["N:1","2024-03-01T22:00:00","2024-03-02T00:00:00",false]`,
+		`["N:2","2024-03-01T22:00:00","2024-03-02T00:00:00",false]`,
+	}, server.bodies("Invoke/Orion.Nodes/Unmanage"))
+	assert.Equal(t, []string{`["I:5","2024-03-01T22:00:00","2024-03-02T00:00:00",false]`}, server.bodies("Invoke/Orion.NPM.Interfaces/Unmanage"))
+
+	require.Len(t, server.bodies("BulkUpdate"), 1)
+	assert.JSONEq(t, `{"uris":[
+		"swis://orion/Orion/Orion.Nodes/NodeID=1/CustomProperties",
+		"swis://orion/Orion/Orion.Nodes/NodeID=1/Interfaces/InterfaceID=5/CustomProperties",
+		"swis://orion/Orion/Orion.Nodes/NodeID=2/CustomProperties"],
+		"properties":{"ChangeTicket":"CHG0012345"}}`, server.bodies("BulkUpdate")[0])
+}
+
+func TestMaintenanceService_ScheduleMute(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+
+	volume := MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1/Volumes/VolumeID=3")
+	_, err := client.Maintenance().Schedule(context.Background(), MaintenanceWindow{
+		URIs:  []URI{volume},
+		From:  maintenanceFrom,
+		Until: maintenanceUntil,
+		Mode:  MaintenanceMute,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{`[["swis://orion/Orion/Orion.Nodes/NodeID=1/Volumes/VolumeID=3"],"2024-03-01T22:00:00","2024-03-02T00:00:00"]`},
+		server.bodies("Invoke/Orion.AlertSuppression/SuppressAlerts"))
+	assert.Empty(t, server.bodies("BulkUpdate"))
+}
+
+func TestMaintenanceService_Invalid(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	maintenance := client.Maintenance()
+	node := MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1")
+
+	tests := []struct {
+		name   string
+		window MaintenanceWindow
+	}{
+		{"backwards", MaintenanceWindow{URIs: []URI{node}, From: maintenanceUntil, Until: maintenanceFrom}},
+		{"no entities", MaintenanceWindow{From: maintenanceFrom, Until: maintenanceUntil}},
+		{"unknown mode", MaintenanceWindow{URIs: []URI{node}, From: maintenanceFrom, Until: maintenanceUntil, Mode: "pause"}},
+		{"not unmanageable", MaintenanceWindow{
+			URIs:  []URI{node, MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1/Volumes/VolumeID=3")},
+			From:  maintenanceFrom,
+			Until: maintenanceUntil,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := maintenance.Schedule(ctx, tt.window)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+		})
+	}
+	assert.Zero(t, server.count())
+}
+
+func TestMaintenanceService_ErrorType(t *testing.T) {
+	server := newFakeSWIS(t, nil, func(w http.ResponseWriter, r *http.Request, req fakeRequest) bool {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"Message":"access denied"}`))
+		return true
+	})
+	client := newTestClient(t, server.Server)
+
+	_, err := client.Maintenance().Schedule(context.Background(), MaintenanceWindow{
+		URIs:  []URI{MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1")},
+		From:  maintenanceFrom,
+		Until: maintenanceUntil,
+		Mode:  MaintenanceMute,
+	})
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypePermission, swErr.Type)
+	assert.Equal(t, http.StatusForbidden, swErr.StatusCode)
+	assert.Equal(t, "schedule_maintenance", swErr.Operation)
+}
+
+func TestMaintenanceService_Status(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"SELECT Uri, Unmanaged, UnmanageFrom, UnmanageUntil FROM Orion.Nodes": `[
+			{"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=1","Unmanaged":true,
+			 "UnmanageFrom":"2024-03-01T22:00:00","UnmanageUntil":"2024-03-02T00:00:00"}]`,
+		"Invoke/Orion.AlertSuppression/GetAlertSuppressionState": `[
+			{"EntityUri":"swis://orion/Orion/Orion.Nodes/NodeID=1","SuppressionMode":0,"SuppressedFrom":null,"SuppressedUntil":null},
+			{"EntityUri":"swis://orion/Orion/Orion.Nodes/NodeID=2","SuppressionMode":1,
+			 "SuppressedFrom":"2024-03-01T22:00:00","SuppressedUntil":"2024-03-02T00:00:00"}]`,
+	})
+	client := newTestClient(t, server.Server)
+
+	statuses, err := client.Maintenance().Status(context.Background(),
+		MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1"),
+		MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=2"))
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+
+	assert.True(t, statuses[0].Unmanaged)
+	assert.True(t, statuses[0].UnmanagedUntil.Equal(maintenanceUntil))
+	assert.False(t, statuses[0].Muted)
+
+	assert.False(t, statuses[1].Unmanaged)
+	assert.True(t, statuses[1].Muted)
+	assert.True(t, statuses[1].MutedFrom.Equal(maintenanceFrom))
+}
+
+func TestMaintenanceService_ExtendAndCancel(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"SELECT Uri, Unmanaged, UnmanageFrom, UnmanageUntil FROM Orion.Nodes": `[
+			{"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=1","Unmanaged":true,
+			 "UnmanageFrom":"2024-03-01T22:00:00","UnmanageUntil":"2024-03-02T00:00:00"}]`,
+		"Invoke/Orion.AlertSuppression/GetAlertSuppressionState": `[]`,
+	})
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	window := MaintenanceWindow{
+		URIs:   []URI{MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=1")},
+		Ticket: "CHG0012345",
+	}
+
+	require.NoError(t, client.Maintenance().Extend(ctx, window, maintenanceUntil.Add(time.Hour)))
+	assert.Equal(t, []string{`["N:1","2024-03-01T22:00:00","2024-03-02T01:00:00",false]`}, server.bodies("Invoke/Orion.Nodes/Unmanage"))
+
+	err := client.Maintenance().Extend(ctx, window, maintenanceFrom.Add(-time.Hour))
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+
+	// Node 2 isn't unmanaged, so there is no window to extend
+	idle := window
+	idle.URIs = append(idle.URIs, MustParseURI("swis://orion/Orion/Orion.Nodes/NodeID=2"))
+	err = client.Maintenance().Extend(ctx, idle, maintenanceUntil.Add(time.Hour))
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+	assert.Contains(t, err.Error(), "NodeID=2")
+	assert.Len(t, server.bodies("Invoke/Orion.Nodes/Unmanage"), 1)
+
+	idle.Mode = MaintenanceMute
+	err = client.Maintenance().Extend(ctx, idle, maintenanceUntil.Add(time.Hour))
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+	assert.Empty(t, server.bodies("Invoke/Orion.AlertSuppression/SuppressAlerts"))
+
+	require.NoError(t, client.Maintenance().Cancel(ctx, window))
+	assert.Equal(t, []string{`["N:1"]`}, server.bodies("Invoke/Orion.Nodes/Remanage"))
+	require.Len(t, server.bodies("BulkUpdate"), 1)
+	assert.JSONEq(t, `{"uris":["swis://orion/Orion/Orion.Nodes/NodeID=1/CustomProperties"],"properties":{"ChangeTicket":null}}`,
+		server.bodies("BulkUpdate")[0])
+
+	window.Mode = MaintenanceMute
+	window.Ticket = ""
+	require.NoError(t, client.Maintenance().Cancel(ctx, window))
+	assert.Equal(t, []string{`[["swis://orion/Orion/Orion.Nodes/NodeID=1"]]`}, server.bodies("Invoke/Orion.AlertSuppression/ResumeAlerts"))
+}
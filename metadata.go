@@ -0,0 +1,323 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EntityInfo describes a SWIS entity type from Metadata.Entity
+type EntityInfo struct {
+	FullName     string `json:"fullname" swis:"FullName"`
+	Namespace    string `json:"namespace" swis:"Namespace"`
+	Name         string `json:"name" swis:"Name"`
+	BaseType     string `json:"basetype" swis:"BaseType"`
+	Summary      string `json:"summary" swis:"Summary"`
+	IsAbstract   bool   `json:"isabstract" swis:"IsAbstract"`
+	IsIndication bool   `json:"isindication" swis:"IsIndication"`
+	CanCreate    bool   `json:"cancreate" swis:"CanCreate"`
+	CanUpdate    bool   `json:"canupdate" swis:"CanUpdate"`
+	CanDelete    bool   `json:"candelete" swis:"CanDelete"`
+}
+
+// PropertyInfo describes a property of an entity from Metadata.Property
+type PropertyInfo struct {
+	Name        string `json:"name" swis:"Name"`
+	Type        string `json:"type" swis:"Type"`
+	Summary     string `json:"summary" swis:"Summary"`
+	IsKey       bool   `json:"iskey" swis:"IsKey"`
+	IsNullable  bool   `json:"isnullable" swis:"IsNullable"`
+	IsNavigable bool   `json:"isnavigable" swis:"IsNavigable"`
+	IsInherited bool   `json:"isinherited" swis:"IsInherited"`
+}
+
+// Relationship is a navigation property linking an entity to another, such
+// as Orion.Nodes.Interfaces, from Metadata.Relationship
+type Relationship struct {
+	// Name is the navigation property name on the entity
+	Name string `json:"name"`
+
+	// Target is the entity the navigation property leads to
+	Target string `json:"target"`
+
+	// Type is the relationship type, such as System.Hosting or System.Reference
+	Type string `json:"type"`
+
+	// Many reports whether the navigation property yields more than one row
+	Many bool `json:"many"`
+}
+
+// EntityMetadata describes an entity with its properties and relationships
+type EntityMetadata struct {
+	EntityInfo
+	Keys          []string       `json:"keys"`
+	Properties    []PropertyInfo `json:"properties"`
+	Relationships []Relationship `json:"relationships"`
+}
+
+// Property returns the property with the given name, matched
+// case-insensitively
+func (m *EntityMetadata) Property(name string) (PropertyInfo, bool) {
+	for _, p := range m.Properties {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return PropertyInfo{}, false
+}
+
+// clone returns a copy of the metadata that shares none of its slices
+func (m *EntityMetadata) clone() *EntityMetadata {
+	out := *m
+	out.Keys = append([]string{}, m.Keys...)
+	out.Properties = append([]PropertyInfo{}, m.Properties...)
+	out.Relationships = append([]Relationship{}, m.Relationships...)
+	return &out
+}
+
+// VerbInfo describes a verb from Metadata.Verb
+type VerbInfo struct {
+	EntityName string `json:"entityname" swis:"EntityName"`
+	Name       string `json:"name" swis:"Name"`
+	Summary    string `json:"summary" swis:"Summary"`
+}
+
+// VerbArgument describes an argument of a verb from Metadata.VerbArgument.
+// Verb arguments are passed by position, starting at 1.
+type VerbArgument struct {
+	Position   int    `json:"position" swis:"Position"`
+	Name       string `json:"name" swis:"Name"`
+	Type       string `json:"type" swis:"Type"`
+	IsOptional bool   `json:"isoptional" swis:"IsOptional"`
+}
+
+// relationshipRow is a row of Metadata.Relationship
+type relationshipRow struct {
+	BaseType             string `swis:"BaseType"`
+	SourceType           string `swis:"SourceType"`
+	TargetType           string `swis:"TargetType"`
+	SourcePropertyName   string `swis:"SourcePropertyName"`
+	TargetPropertyName   string `swis:"TargetPropertyName"`
+	SourceCardinalityMax string `swis:"SourceCardinalityMax"`
+	TargetCardinalityMax string `swis:"TargetCardinalityMax"`
+}
+
+// metadataCache holds metadata already fetched by a client. Failed lookups
+// aren't cached.
+type metadataCache struct {
+	mu       sync.Mutex
+	entities []EntityInfo
+	entity   map[string]*EntityMetadata
+	verbs    map[string][]VerbInfo
+	args     map[string][]VerbArgument
+}
+
+func newMetadataCache() *metadataCache {
+	return &metadataCache{
+		entity: make(map[string]*EntityMetadata),
+		verbs:  make(map[string][]VerbInfo),
+		args:   make(map[string][]VerbArgument),
+	}
+}
+
+// ClearMetadataCache drops cached metadata, for example after installing a
+// module that adds entities
+func (c *Client) ClearMetadataCache() {
+	c.metadata.mu.Lock()
+	defer c.metadata.mu.Unlock()
+
+	c.metadata.entities = nil
+	c.metadata.entity = make(map[string]*EntityMetadata)
+	c.metadata.verbs = make(map[string][]VerbInfo)
+	c.metadata.args = make(map[string][]VerbArgument)
+}
+
+const entityColumns = "FullName, Namespace, Name, BaseType, Summary, IsAbstract, IsIndication, CanCreate, CanUpdate, CanDelete"
+
+// Entities lists every entity type SWIS exposes, ordered by name. The result
+// is cached for the lifetime of the client; each call returns a copy.
+func (c *Client) Entities(ctx context.Context) ([]EntityInfo, error) {
+	c.metadata.mu.Lock()
+	cached := c.metadata.entities
+	c.metadata.mu.Unlock()
+	if cached != nil {
+		return append([]EntityInfo{}, cached...), nil
+	}
+
+	var entities []EntityInfo
+	query := "SELECT " + entityColumns + " FROM Metadata.Entity ORDER BY FullName"
+	if err := c.queryScan(ctx, "entities", query, nil, &entities); err != nil {
+		return nil, err
+	}
+	if entities == nil {
+		entities = []EntityInfo{}
+	}
+
+	c.metadata.mu.Lock()
+	c.metadata.entities = entities
+	c.metadata.mu.Unlock()
+	return append([]EntityInfo{}, entities...), nil
+}
+
+// Entity describes an entity type with its keys, properties and navigation
+// relationships. It returns an ErrorTypeEntityNotFound error if SWIS doesn't
+// know the entity. The result is cached for the lifetime of the client; each
+// call returns a copy.
+func (c *Client) Entity(ctx context.Context, name string) (*EntityMetadata, error) {
+	key := strings.ToLower(name)
+
+	c.metadata.mu.Lock()
+	cached := c.metadata.entity[key]
+	c.metadata.mu.Unlock()
+	if cached != nil {
+		return cached.clone(), nil
+	}
+
+	params := map[string]interface{}{"entity": name}
+
+	var infos []EntityInfo
+	query := "SELECT " + entityColumns + " FROM Metadata.Entity WHERE FullName = @entity"
+	if err := c.queryScan(ctx, "entity", query, params, &infos); err != nil {
+		return nil, err
+	}
+	if len(infos) == 0 {
+		return nil, NewError(ErrorTypeEntityNotFound, "entity", fmt.Sprintf("entity %s not found", name))
+	}
+
+	meta := &EntityMetadata{
+		EntityInfo:    infos[0],
+		Keys:          []string{},
+		Properties:    []PropertyInfo{},
+		Relationships: []Relationship{},
+	}
+
+	query = "SELECT Name, Type, Summary, IsKey, IsNullable, IsNavigable, IsInherited " +
+		"FROM Metadata.Property WHERE EntityName = @entity ORDER BY Name"
+	if err := c.queryScan(ctx, "entity", query, params, &meta.Properties); err != nil {
+		return nil, err
+	}
+	for _, p := range meta.Properties {
+		if p.IsKey {
+			meta.Keys = append(meta.Keys, p.Name)
+		}
+	}
+
+	var rels []relationshipRow
+	query = "SELECT BaseType, SourceType, TargetType, SourcePropertyName, TargetPropertyName, " +
+		"SourceCardinalityMax, TargetCardinalityMax " +
+		"FROM Metadata.Relationship WHERE SourceType = @entity OR TargetType = @entity"
+	if err := c.queryScan(ctx, "entity", query, params, &rels); err != nil {
+		return nil, err
+	}
+	meta.Relationships = relationshipsOf(meta.FullName, rels)
+
+	c.metadata.mu.Lock()
+	c.metadata.entity[key] = meta
+	c.metadata.mu.Unlock()
+	return meta.clone(), nil
+}
+
+// relationshipsOf turns relationship rows into the navigation properties
+// they give entity. A relationship is navigable from its source through
+// SourcePropertyName and from its target through TargetPropertyName.
+func relationshipsOf(entity string, rows []relationshipRow) []Relationship {
+	rels := []Relationship{}
+	seen := map[string]bool{}
+
+	add := func(name, target, typ, cardinality string) {
+		if name == "" || seen[strings.ToLower(name)] {
+			return
+		}
+		seen[strings.ToLower(name)] = true
+		rels = append(rels, Relationship{
+			Name:   name,
+			Target: target,
+			Type:   typ,
+			Many:   cardinality != "1" && cardinality != "0",
+		})
+	}
+
+	for _, r := range rows {
+		if strings.EqualFold(r.SourceType, entity) {
+			add(r.SourcePropertyName, r.TargetType, r.BaseType, r.TargetCardinalityMax)
+		}
+		if strings.EqualFold(r.TargetType, entity) {
+			add(r.TargetPropertyName, r.SourceType, r.BaseType, r.SourceCardinalityMax)
+		}
+	}
+	return rels
+}
+
+// Verbs lists the verbs an entity supports. The result is cached for the
+// lifetime of the client; each call returns a copy.
+func (c *Client) Verbs(ctx context.Context, entity string) ([]VerbInfo, error) {
+	key := strings.ToLower(entity)
+
+	c.metadata.mu.Lock()
+	cached, ok := c.metadata.verbs[key]
+	c.metadata.mu.Unlock()
+	if ok {
+		return append([]VerbInfo{}, cached...), nil
+	}
+
+	var verbs []VerbInfo
+	query := "SELECT EntityName, Name, Summary FROM Metadata.Verb WHERE EntityName = @entity ORDER BY Name"
+	if err := c.queryScan(ctx, "verbs", query, map[string]interface{}{"entity": entity}, &verbs); err != nil {
+		return nil, err
+	}
+	if verbs == nil {
+		verbs = []VerbInfo{}
+	}
+
+	c.metadata.mu.Lock()
+	c.metadata.verbs[key] = verbs
+	c.metadata.mu.Unlock()
+	return append([]VerbInfo{}, verbs...), nil
+}
+
+// VerbArguments lists the arguments of a verb in the order they are passed.
+// It returns an ErrorTypeVerbNotFound error if the entity has no such verb.
+// The result is cached for the lifetime of the client; each call returns a
+// copy.
+func (c *Client) VerbArguments(ctx context.Context, entity, verb string) ([]VerbArgument, error) {
+	key := strings.ToLower(entity + "." + verb)
+
+	c.metadata.mu.Lock()
+	cached, ok := c.metadata.args[key]
+	c.metadata.mu.Unlock()
+	if ok {
+		return append([]VerbArgument{}, cached...), nil
+	}
+
+	verbs, err := c.Verbs(ctx, entity)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	for _, v := range verbs {
+		if strings.EqualFold(v.Name, verb) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, NewError(ErrorTypeVerbNotFound, "verb_arguments", fmt.Sprintf("verb %s.%s not found", entity, verb))
+	}
+
+	var args []VerbArgument
+	query := "SELECT Position, Name, Type, IsOptional FROM Metadata.VerbArgument " +
+		"WHERE EntityName = @entity AND VerbName = @verb ORDER BY Position"
+	params := map[string]interface{}{"entity": entity, "verb": verb}
+	if err := c.queryScan(ctx, "verb_arguments", query, params, &args); err != nil {
+		return nil, err
+	}
+	if args == nil {
+		args = []VerbArgument{}
+	}
+
+	c.metadata.mu.Lock()
+	c.metadata.args[key] = args
+	c.metadata.mu.Unlock()
+	return append([]VerbArgument{}, args...), nil
+}
@@ -0,0 +1,149 @@
+package gosolar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodesMetadata answers Metadata.* queries about Orion.Nodes
+var nodesMetadata = map[string]string{
+	"Metadata.Entity ORDER BY": `[
+			{"FullName":"Orion.Nodes","Namespace":"Orion","Name":"Nodes","BaseType":"Orion.Node","Summary":"Nodes","IsAbstract":false,"IsIndication":false,"CanCreate":true,"CanUpdate":true,"CanDelete":true},
+			{"FullName":"Orion.Volumes","Namespace":"Orion","Name":"Volumes","BaseType":"System.ManagedEntity","Summary":null,"IsAbstract":false,"IsIndication":false,"CanCreate":true,"CanUpdate":true,"CanDelete":true}]`,
+	"Metadata.Entity WHERE": `[
+			{"FullName":"Orion.Nodes","Namespace":"Orion","Name":"Nodes","BaseType":"Orion.Node","Summary":"Nodes","IsAbstract":false,"IsIndication":false,"CanCreate":true,"CanUpdate":true,"CanDelete":true}]`,
+	"Metadata.Property": `[
+			{"Name":"Caption","Type":"System.String","Summary":null,"IsKey":false,"IsNullable":true,"IsNavigable":false,"IsInherited":false},
+			{"Name":"Interfaces","Type":"Orion.NPM.Interfaces","Summary":null,"IsKey":false,"IsNullable":true,"IsNavigable":true,"IsInherited":false},
+			{"Name":"NodeID","Type":"System.Int32","Summary":null,"IsKey":true,"IsNullable":false,"IsNavigable":false,"IsInherited":false}]`,
+	"Metadata.Relationship": `[
+			{"BaseType":"System.Hosting","SourceType":"Orion.Nodes","TargetType":"Orion.NPM.Interfaces","SourcePropertyName":"Interfaces","TargetPropertyName":"Node","SourceCardinalityMax":"1","TargetCardinalityMax":"*"},
+			{"BaseType":"System.Reference","SourceType":"Orion.Engines","TargetType":"Orion.Nodes","SourcePropertyName":"Nodes","TargetPropertyName":"Engine","SourceCardinalityMax":"1","TargetCardinalityMax":"*"}]`,
+	"Metadata.Verb ": `[
+			{"EntityName":"Orion.Nodes","Name":"PollNow","Summary":"Polls a node"},
+			{"EntityName":"Orion.Nodes","Name":"Unmanage","Summary":null}]`,
+	"Metadata.VerbArgument": `[
+			{"Position":1,"Name":"netObjectId","Type":"System.String","IsOptional":false},
+			{"Position":2,"Name":"unmanageTime","Type":"System.DateTime","IsOptional":false},
+			{"Position":3,"Name":"remanageTime","Type":"System.DateTime","IsOptional":false},
+			{"Position":4,"Name":"isRelative","Type":"System.Boolean","IsOptional":true}]`,
+}
+
+// onlyNodes answers metadata queries about entities other than Orion.Nodes
+// with no results
+func onlyNodes(w http.ResponseWriter, r *http.Request, req fakeRequest) bool {
+	if entity, _ := req.Params["entity"].(string); entity == "" || strings.EqualFold(entity, "Orion.Nodes") {
+		return false
+	}
+	_, _ = w.Write([]byte(`{"results":[]}`))
+	return true
+}
+
+func TestEntities(t *testing.T) {
+	server := newFakeSWIS(t, nodesMetadata, onlyNodes)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	entities, err := client.Entities(ctx)
+	require.NoError(t, err)
+	require.Len(t, entities, 2)
+	assert.Equal(t, "Orion.Nodes", entities[0].FullName)
+	assert.True(t, entities[0].CanCreate)
+	assert.Equal(t, "", entities[1].Summary)
+
+	entities[0].FullName = "changed"
+	entities, err = client.Entities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.count(), "second call should be cached")
+	assert.Equal(t, "Orion.Nodes", entities[0].FullName, "callers get a copy of the cache")
+
+	client.ClearMetadataCache()
+	_, err = client.Entities(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, server.count())
+}
+
+func TestEntity(t *testing.T) {
+	server := newFakeSWIS(t, nodesMetadata, onlyNodes)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	meta, err := client.Entity(ctx, "Orion.Nodes")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Orion.Nodes", meta.FullName)
+	assert.True(t, meta.CanUpdate)
+	assert.Equal(t, []string{"NodeID"}, meta.Keys)
+	require.Len(t, meta.Properties, 3)
+
+	caption, ok := meta.Property("caption")
+	require.True(t, ok)
+	assert.Equal(t, "System.String", caption.Type)
+	assert.True(t, caption.IsNullable)
+
+	assert.Equal(t, []Relationship{
+		{Name: "Interfaces", Target: "Orion.NPM.Interfaces", Type: "System.Hosting", Many: true},
+		{Name: "Engine", Target: "Orion.Engines", Type: "System.Reference", Many: false},
+	}, meta.Relationships)
+
+	meta.Keys[0] = "changed"
+	meta.Properties[0].Name = "changed"
+	meta.Relationships = nil
+	meta, err = client.Entity(ctx, "orion.nodes")
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.count(), "second call should be cached")
+	assert.Equal(t, []string{"NodeID"}, meta.Keys, "callers get a copy of the cache")
+	assert.NotEqual(t, "changed", meta.Properties[0].Name)
+	assert.Len(t, meta.Relationships, 2)
+}
+
+func TestEntity_NotFound(t *testing.T) {
+	server := newFakeSWIS(t, nodesMetadata, onlyNodes)
+	client := newTestClient(t, server.Server)
+
+	_, err := client.Entity(context.Background(), "Orion.Nope")
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeEntityNotFound}))
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeNotFound}))
+}
+
+func TestVerbs(t *testing.T) {
+	server := newFakeSWIS(t, nodesMetadata, onlyNodes)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	verbs, err := client.Verbs(ctx, "Orion.Nodes")
+	require.NoError(t, err)
+	require.Len(t, verbs, 2)
+	assert.Equal(t, "PollNow", verbs[0].Name)
+
+	none, err := client.Verbs(ctx, "Orion.Volumes")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+
+	args, err := client.VerbArguments(ctx, "Orion.Nodes", "Unmanage")
+	require.NoError(t, err)
+	require.Len(t, args, 4)
+	assert.Equal(t, VerbArgument{Position: 2, Name: "unmanageTime", Type: "System.DateTime"}, args[1])
+	assert.True(t, args[3].IsOptional)
+
+	args[1].Name = "changed"
+	verbs[0].Name = "changed"
+	args, err = client.VerbArguments(ctx, "Orion.Nodes", "Unmanage")
+	require.NoError(t, err)
+	assert.Equal(t, 3, server.count(), "verbs and arguments should be cached")
+	assert.Equal(t, "unmanageTime", args[1].Name, "callers get a copy of the cache")
+
+	verbs, err = client.Verbs(ctx, "Orion.Nodes")
+	require.NoError(t, err)
+	assert.Equal(t, "PollNow", verbs[0].Name)
+
+	_, err = client.VerbArguments(ctx, "Orion.Nodes", "Explode")
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeVerbNotFound}))
+}
@@ -0,0 +1,208 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrxinu/gosolar/swql/parser"
+)
+
+// MetricsRecorder receives a RequestMetrics for every call the client makes
+// to SWIS. RecordRequest runs on the calling goroutine once the call has
+// returned, so it should be quick and safe for concurrent use.
+//
+// The gosolarprom and gosolarotel packages in contrib adapt it to Prometheus
+// and OpenTelemetry metrics without adding either dependency to this module.
+type MetricsRecorder interface {
+	RecordRequest(ctx context.Context, m RequestMetrics)
+}
+
+// MetricsRecorderFunc adapts a function to a MetricsRecorder
+type MetricsRecorderFunc func(ctx context.Context, m RequestMetrics)
+
+// RecordRequest implements MetricsRecorder
+func (f MetricsRecorderFunc) RecordRequest(ctx context.Context, m RequestMetrics) {
+	f(ctx, m)
+}
+
+// Operations RequestMetrics.Operation takes
+const (
+	OperationQuery      = "Query"
+	OperationCreate     = "Create"
+	OperationRead       = "Read"
+	OperationUpdate     = "Update"
+	OperationDelete     = "Delete"
+	OperationInvoke     = "Invoke"
+	OperationBulkUpdate = "BulkUpdate"
+	OperationBulkDelete = "BulkDelete"
+)
+
+// RequestMetrics describes a call to SWIS once it has finished, its retries
+// included
+type RequestMetrics struct {
+	// Operation is one of the Operation constants, or the first segment of
+	// the endpoint for other endpoints
+	Operation string
+
+	// Entity is the entity the call acts on: the entity a query reads from
+	// first, the entity created or invoked, or the entity of the (first)
+	// URI. It is empty when it can't be told from the request.
+	Entity string
+
+	// Verb is the verb of an Invoke
+	Verb string
+
+	Method   string
+	Endpoint string
+
+	// StatusCode is the HTTP status of the last attempt, or 0 if no response
+	// was received
+	StatusCode int
+
+	// Duration is the time from the first attempt to the end of the last,
+	// waits between retries included
+	Duration time.Duration
+
+	// ResponseSize is the number of response body bytes read over all
+	// attempts
+	ResponseSize int64
+
+	// Attempts is the number of requests sent
+	Attempts int
+
+	// ErrorType classifies the error the call failed with. It is empty when
+	// the call succeeded.
+	ErrorType ErrorType
+}
+
+// Retries returns the number of attempts after the first
+func (m RequestMetrics) Retries() int {
+	if m.Attempts <= 1 {
+		return 0
+	}
+	return m.Attempts - 1
+}
+
+// describeRequest works out the operation, entity and verb of a request and,
+// for queries, the redacted statement. Queries are described by q, which
+// parses them afresh if nil.
+func describeRequest(method, endpoint string, payload []byte, q *queryDescriber) CallInfo {
+	info := CallInfo{Method: method, Endpoint: endpoint}
+
+	if strings.HasPrefix(strings.ToLower(endpoint), URIScheme+"://") {
+		if uri, err := ParseURI(endpoint); err == nil {
+			info.Entity = uri.Entity
+		}
+		switch method {
+		case http.MethodGet:
+			info.Operation = OperationRead
+		case http.MethodDelete:
+			info.Operation = OperationDelete
+		default:
+			info.Operation = OperationUpdate
+		}
+		return info
+	}
+
+	parts := strings.Split(strings.Trim(endpoint, "/"), "/")
+	info.Operation = parts[0]
+	switch info.Operation {
+	case OperationQuery:
+		var req struct {
+			Query string `json:"query"`
+		}
+		if json.Unmarshal(payload, &req) == nil {
+			info.Entity, info.Statement = q.describe(req.Query)
+		}
+	case OperationCreate:
+		if len(parts) > 1 {
+			info.Entity = parts[1]
+		}
+	case OperationInvoke:
+		if len(parts) > 2 {
+			info.Entity, info.Verb = parts[1], parts[2]
+		}
+	case OperationBulkUpdate, OperationBulkDelete:
+		var req struct {
+			URIs []string `json:"uris"`
+		}
+		if json.Unmarshal(payload, &req) == nil && len(req.URIs) > 0 {
+			if uri, err := ParseURI(req.URIs[0]); err == nil {
+				info.Entity = uri.Entity
+			}
+		}
+	}
+	return info
+}
+
+// countingBody counts the bytes read from a response body
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (b countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	*b.n += int64(n)
+	return n, err
+}
+
+// maxDescribedQueries caps the queries a queryDescriber remembers
+const maxDescribedQueries = 256
+
+// queryDescriber works out the entity of queries and, if statements is set,
+// their redacted text. It remembers recent queries so one run over and over
+// isn't parsed on every call.
+type queryDescriber struct {
+	statements bool
+
+	mu   sync.Mutex
+	seen map[string]queryDescription
+}
+
+type queryDescription struct {
+	entity    string
+	statement string
+}
+
+// describe returns the entity and redacted statement of query
+func (q *queryDescriber) describe(query string) (entity, statement string) {
+	if q == nil {
+		return parseQueryDescription(query, true)
+	}
+
+	q.mu.Lock()
+	d, ok := q.seen[query]
+	q.mu.Unlock()
+	if ok {
+		return d.entity, d.statement
+	}
+
+	d.entity, d.statement = parseQueryDescription(query, q.statements)
+	q.mu.Lock()
+	if q.seen == nil || len(q.seen) >= maxDescribedQueries {
+		q.seen = make(map[string]queryDescription)
+	}
+	q.seen[query] = d
+	q.mu.Unlock()
+	return d.entity, d.statement
+}
+
+// parseQueryDescription parses query for its first entity and, if statement
+// is set, redacts it
+func parseQueryDescription(query string, statement bool) (entity, redacted string) {
+	if stmt, err := parser.Parse(query); err == nil {
+		if entities := stmt.Entities(); len(entities) > 0 {
+			entity = entities[0]
+		}
+	}
+	if statement {
+		redacted, _ = parser.Redact(query)
+	}
+	return entity, redacted
+}
@@ -0,0 +1,157 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instrumentedConfig returns the config of a client for a server that fails
+// the first request to an endpoint containing "Flaky" with a 503 and every
+// request to one containing "Missing" with a 404
+func instrumentedConfig(t *testing.T) *Config {
+	var mu sync.Mutex
+	var flaked bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case strings.Contains(r.URL.Path, "Missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.Contains(r.URL.Path, "Flaky") && !flaked:
+			flaked = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case strings.HasSuffix(r.URL.Path, "/Query"):
+			_, _ = w.Write([]byte(`{"results":[{"NodeID":1},{"NodeID":2}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"results":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.RetryDelay = time.Millisecond
+	return config
+}
+
+// metricsClient returns a client for instrumentedConfig recording into the
+// returned slice
+func metricsClient(t *testing.T) (*Client, func() []RequestMetrics) {
+	var mu sync.Mutex
+	var recorded []RequestMetrics
+
+	config := instrumentedConfig(t)
+	config.Metrics = MetricsRecorderFunc(func(ctx context.Context, m RequestMetrics) {
+		mu.Lock()
+		defer mu.Unlock()
+		recorded = append(recorded, m)
+	})
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	return client, func() []RequestMetrics {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]RequestMetrics(nil), recorded...)
+	}
+}
+
+func TestMetricsRecorder(t *testing.T) {
+	client, recorded := metricsClient(t)
+	ctx := context.Background()
+
+	_, err := client.QueryContext(ctx, "SELECT n.NodeID FROM Orion.Nodes n JOIN Orion.Volumes v ON v.NodeID = n.NodeID", nil)
+	require.NoError(t, err)
+	_, err = client.InvokeContext(ctx, "Orion.Nodes", "PollNow", []string{"N:1"})
+	require.NoError(t, err)
+	_, err = client.ReadURIContext(ctx, MustParseURI("swis://orion/Orion/Orion.Flaky/ID=1"))
+	require.NoError(t, err)
+	_, err = client.BulkDeleteURIsContext(ctx, []URI{MustParseURI("swis://orion/Orion/Orion.Pollers/PollerID=4")})
+	require.NoError(t, err)
+	_, err = client.CreateContext(ctx, "Orion.Missing", map[string]interface{}{})
+	require.Error(t, err)
+
+	got := recorded()
+	require.Len(t, got, 5)
+
+	type summary struct {
+		Operation, Entity, Verb string
+		Status, Attempts        int
+		ErrorType               ErrorType
+	}
+	var summaries []summary
+	for _, m := range got {
+		summaries = append(summaries, summary{m.Operation, m.Entity, m.Verb, m.StatusCode, m.Attempts, m.ErrorType})
+		assert.Positive(t, m.Duration)
+	}
+	assert.Equal(t, []summary{
+		{OperationQuery, "Orion.Nodes", "", 200, 1, ""},
+		{OperationInvoke, "Orion.Nodes", "PollNow", 200, 1, ""},
+		{OperationRead, "Orion.Flaky", "", 200, 2, ""},
+		{OperationBulkDelete, "Orion.Pollers", "", 200, 1, ""},
+		{OperationCreate, "Orion.Missing", "", 404, 1, ErrorTypeNotFound},
+	}, summaries)
+
+	assert.Equal(t, int64(len(`{"results":[{"NodeID":1},{"NodeID":2}]}`)), got[0].ResponseSize)
+	assert.Equal(t, 1, got[2].Retries())
+	assert.Equal(t, 0, got[0].Retries())
+}
+
+func TestDescribeRequest(t *testing.T) {
+	tests := []struct {
+		method, endpoint, payload string
+		operation, entity, verb   string
+	}{
+		{"POST", "Query", `{"query":"SELECT Caption FROM Orion.Nodes WHERE Vendor = 'Cisco'"}`, OperationQuery, "Orion.Nodes", ""},
+		{"POST", "Query", `{"query":"not swql"}`, OperationQuery, "", ""},
+		{"POST", "Create/Orion.Pollers", `{}`, OperationCreate, "Orion.Pollers", ""},
+		{"POST", "Invoke/Orion.AlertActive/Acknowledge", `[]`, OperationInvoke, "Orion.AlertActive", "Acknowledge"},
+		{"POST", "BulkUpdate", `{"uris":["swis://orion/Orion/Orion.Nodes/NodeID=1/CustomProperties"]}`, OperationBulkUpdate, "Orion.Nodes", ""},
+		{"GET", "swis://orion/Orion/Orion.Nodes/NodeID=1", ``, OperationRead, "Orion.Nodes", ""},
+		{"POST", "swis://orion/Orion/Orion.Nodes/NodeID=1", `{}`, OperationUpdate, "Orion.Nodes", ""},
+		{"DELETE", "swis://orion/Orion/Orion.Nodes/NodeID=1", ``, OperationDelete, "Orion.Nodes", ""},
+		{"GET", "Metadata/Entities", ``, "Metadata", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			info := describeRequest(tt.method, tt.endpoint, []byte(tt.payload), nil)
+			assert.Equal(t, tt.operation, info.Operation)
+			assert.Equal(t, tt.entity, info.Entity)
+			assert.Equal(t, tt.verb, info.Verb)
+		})
+	}
+}
+
+func TestQueryDescriber(t *testing.T) {
+	query := "SELECT Caption FROM Orion.Nodes WHERE Vendor = 'Cisco'"
+
+	metricsOnly := &queryDescriber{}
+	entity, statement := metricsOnly.describe(query)
+	assert.Equal(t, "Orion.Nodes", entity)
+	assert.Empty(t, statement, "statements are only redacted for a tracer")
+
+	traced := &queryDescriber{statements: true}
+	entity, statement = traced.describe(query)
+	assert.Equal(t, "Orion.Nodes", entity)
+	assert.Equal(t, "SELECT Caption FROM Orion.Nodes WHERE Vendor = ?", statement)
+	assert.Zero(t, testing.AllocsPerRun(100, func() { traced.describe(query) }), "a query seen before isn't parsed again")
+
+	for i := 0; i < maxDescribedQueries+1; i++ {
+		traced.describe(fmt.Sprintf("SELECT NodeID FROM Orion.Nodes WHERE NodeID = %d", i))
+	}
+	assert.LessOrEqual(t, len(traced.seen), maxDescribedQueries)
+}
@@ -0,0 +1,290 @@
+package gosolar
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Middleware wraps the RoundTripper requests to SWIS are sent with. Use it
+// for logging, header injection, recording, fault injection or extra
+// authentication:
+//
+//	config.Middleware = []gosolar.Middleware{
+//		func(next http.RoundTripper) http.RoundTripper {
+//			return gosolar.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+//				req = req.Clone(req.Context())
+//				req.Header.Set("X-Request-ID", uuid.NewString())
+//				return next.RoundTrip(req)
+//			})
+//		},
+//	}
+type Middleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to an http.RoundTripper
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// transport chains the built-in middleware and Config.Middleware around
+// base. From the outside in:
+//
+//   - instrument reports each call to Config.Metrics and Config.Tracer
+//   - retry sends the attempts of a call, each bounded by Config.Timeout
+//   - traceAttempts starts a span for each attempt
+//   - authenticate adds the credentials
+//   - Config.Middleware, the first outermost, sees every attempt
+func (c *Client) transport(base http.RoundTripper) http.RoundTripper {
+	rt := base
+	for i := len(c.config.Middleware) - 1; i >= 0; i-- {
+		rt = c.config.Middleware[i](rt)
+	}
+	rt = c.authenticate(rt)
+	if c.config.Tracer != nil {
+		rt = c.traceAttempts(rt)
+	}
+	rt = c.retry(rt)
+	if c.config.Metrics != nil || c.config.Tracer != nil {
+		rt = c.instrument(rt)
+	}
+	return rt
+}
+
+// authenticate adds the basic auth credentials of the config
+func (c *Client) authenticate(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+		return next.RoundTrip(req)
+	})
+}
+
+// retryCanceledError is returned by retry when the context ends while waiting
+// to retry
+type retryCanceledError struct {
+	err error
+}
+
+func (e *retryCanceledError) Error() string {
+	return "canceled while waiting to retry: " + e.err.Error()
+}
+func (e *retryCanceledError) Unwrap() error { return e.err }
+
+// retry sends the attempts of a call until one succeeds, the retry policy
+// gives up or Config.MaxRetries is reached. Requests not made by send pass
+// straight through.
+func (c *Client) retry(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		cl := callFromContext(req.Context())
+		if cl == nil {
+			return next.RoundTrip(req)
+		}
+		ctx := req.Context()
+
+		for attempt := 1; ; attempt++ {
+			cl.attempts = attempt
+			resp, err := c.sendAttempt(next, req, cl, attempt)
+
+			info := RetryInfo{
+				Attempt:    attempt,
+				Method:     cl.method,
+				Endpoint:   cl.endpoint,
+				Idempotent: cl.idempotent,
+			}
+			switch {
+			case err != nil:
+				cl.statusCode = 0
+				info.Err = err
+				info.ErrorType = ErrorTypeNetwork
+			case resp.StatusCode >= 400:
+				cl.statusCode = resp.StatusCode
+				info.Response = resp
+				info.ErrorType = httpError(cl.endpoint, resp).Type
+			default:
+				cl.statusCode = resp.StatusCode
+				return resp, nil
+			}
+
+			// No point retrying once the caller has given up
+			if ctx.Err() != nil || attempt > c.config.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+				return resp, err
+			}
+
+			retry, delay := c.retryPolicy.ShouldRetry(ctx, info)
+			if !retry {
+				return resp, err
+			}
+			if resp != nil {
+				_ = resp.Body.Close()
+			}
+
+			c.logger.DebugContext(ctx, "retrying request", "attempt", attempt+1, "delay", delay, "error_type", info.ErrorType)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, &retryCanceledError{err: err}
+			}
+		}
+	})
+}
+
+// sendAttempt sends one attempt bounded by Config.Timeout. Unless the call
+// streams its response, the body is read before returning so a connection
+// failing mid-body fails the attempt and can be retried. A streamed call only
+// has the timeout until the response headers arrive; reading its body is
+// bounded by the caller's context alone.
+func (c *Client) sendAttempt(next http.RoundTripper, req *http.Request, cl *call, attempt int) (*http.Response, error) {
+	var (
+		ctx       context.Context
+		cancel    context.CancelFunc
+		headersIn = func() bool { return true }
+	)
+	if cl.stream {
+		ctx, cancel = context.WithCancel(req.Context())
+		timer := time.AfterFunc(c.config.Timeout, cancel)
+		headersIn = timer.Stop
+	} else {
+		ctx, cancel = context.WithTimeout(req.Context(), c.config.Timeout)
+	}
+
+	r := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		r.Body = body
+	}
+
+	resp, err := next.RoundTrip(r)
+	if !headersIn() {
+		// The timer fired, so the attempt was canceled or is about to be
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		err = fmt.Errorf("waiting for response headers: %w", context.DeadlineExceeded)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if cl.stream && resp.StatusCode < 400 {
+		resp.Body = &closeHook{ReadCloser: countingBody{ReadCloser: resp.Body, n: &cl.size}, hook: cancel}
+		return resp, nil
+	}
+
+	defer cancel()
+	data, err := readBody(resp)
+	cl.size += int64(len(data))
+	if err != nil {
+		return nil, err
+	}
+	// Only the tracer reports rows, so other calls skip the count
+	if cl.endSpan != nil && cl.info.Operation == OperationQuery && resp.StatusCode < 400 {
+		cl.rows = countResults(data)
+	}
+	return resp, nil
+}
+
+// instrument reports calls to the metrics recorder and tracer. Buffered
+// calls are reported when the response arrives, streamed ones when their
+// body is closed.
+func (c *Client) instrument(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		cl := callFromContext(req.Context())
+		if cl == nil {
+			return next.RoundTrip(req)
+		}
+
+		ctx := c.startCall(req.Context(), cl)
+		resp, err := next.RoundTrip(req.WithContext(ctx))
+		switch {
+		case err != nil:
+			c.finishCall(ctx, cl, requestError(cl, nil, err))
+		case resp.StatusCode >= 400:
+			c.finishCall(ctx, cl, requestError(cl, resp, nil))
+		case cl.stream:
+			resp.Body = &closeHook{ReadCloser: resp.Body, hook: func() { c.finishCall(ctx, cl, nil) }}
+		default:
+			c.finishCall(ctx, cl, nil)
+		}
+		return resp, err
+	})
+}
+
+// traceAttempts starts a span for each attempt
+func (c *Client) traceAttempts(next http.RoundTripper) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempt, endpoint := 1, req.URL.String()
+		if cl := callFromContext(req.Context()); cl != nil {
+			attempt, endpoint = cl.attempts, cl.endpoint
+		}
+
+		req, end := c.config.Tracer.StartAttempt(req, attempt)
+		resp, err := next.RoundTrip(req)
+		switch {
+		case err != nil:
+			end(0, err)
+		case resp.StatusCode >= 400:
+			end(resp.StatusCode, httpError(endpoint, resp))
+		default:
+			end(resp.StatusCode, nil)
+		}
+		return resp, err
+	})
+}
+
+// requestError returns the error a call failed with, from the transport
+// error or the error status of resp
+func requestError(cl *call, resp *http.Response, err error) *Error {
+	var swErr *Error
+	var canceled *retryCanceledError
+	switch {
+	case errors.As(err, &canceled):
+		swErr = WrapError(canceled.err, ErrorTypeNetwork, "request", "request canceled while waiting to retry")
+	case err != nil:
+		swErr = WrapError(err, ErrorTypeNetwork, "request", "request failed after retries")
+	default:
+		swErr = httpError(cl.endpoint, resp)
+	}
+	swErr.Endpoint = cl.endpoint
+	swErr.Attempts = cl.attempts
+	return swErr
+}
+
+// httpError returns the error for an error status, leaving the body of resp
+// readable
+func httpError(endpoint string, resp *http.Response) *Error {
+	data, _ := readBody(resp)
+	return NewHTTPError("request", endpoint, resp, string(data))
+}
+
+// readBody reads the body of resp and replaces it with an in-memory copy,
+// so it can be read again
+func readBody(resp *http.Response) ([]byte, error) {
+	data, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	return data, err
+}
+
+// closeHook runs hook once when the body is closed
+type closeHook struct {
+	io.ReadCloser
+	hook func()
+	once sync.Once
+}
+
+func (b *closeHook) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.hook)
+	return err
+}
@@ -0,0 +1,227 @@
+package gosolar
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standIn is a Transport answering every request itself, so no server is
+// needed
+func standIn(t *testing.T, handler http.HandlerFunc) http.RoundTripper {
+	return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		resp := rec.Result()
+		resp.Request = req
+		return resp, nil
+	})
+}
+
+func standInConfig() *Config {
+	config := DefaultConfig()
+	config.Host = "orion.example.com"
+	config.Username = "admin"
+	config.Password = "password"
+	config.RetryDelay = time.Millisecond
+	return config
+}
+
+func TestClient_Transport(t *testing.T) {
+	var urls []string
+	config := standInConfig()
+	config.Transport = standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "password", pass)
+		urls = append(urls, r.URL.String())
+		_, _ = w.Write([]byte(`{"results":[{"NodeID":1}]}`))
+	})
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	result, err := client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"NodeID":1}]`, string(result))
+	assert.Equal(t, []string{"https://orion.example.com:17778/SolarWinds/InformationService/v3/Json/Query"}, urls)
+}
+
+func TestClient_HTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	// The test server's client trusts its certificate, so verification stays on
+	config := standInConfig()
+	config.BaseURL = server.URL + DefaultAPIPath
+	config.HTTPClient = server.Client()
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.NotSame(t, server.Client(), client.httpClient, "the caller's client is copied, not modified")
+}
+
+func TestClient_HTTPClientWithoutTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	// Without a Transport of its own the client gets the one built from the
+	// config, so InsecureSkipVerify decides whether the test certificate passes
+	for _, insecure := range []bool{true, false} {
+		config := standInConfig()
+		config.BaseURL = server.URL + DefaultAPIPath
+		config.HTTPClient = &http.Client{Timeout: 5 * time.Second}
+		config.InsecureSkipVerify = insecure
+
+		client, err := NewClient(config)
+		require.NoError(t, err)
+
+		_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+		if insecure {
+			assert.NoError(t, err)
+		} else {
+			var certErr *tls.CertificateVerificationError
+			assert.ErrorAs(t, err, &certErr)
+		}
+		assert.Nil(t, config.HTTPClient.Transport, "the caller's client is copied, not modified")
+	}
+}
+
+func TestClient_Middleware(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	logging := func(name string) Middleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				_, _, auth := req.BasicAuth()
+				mu.Lock()
+				seen = append(seen, fmt.Sprintf("%s %s auth=%t", name, req.Header.Get("X-Tenant"), auth))
+				mu.Unlock()
+				return next.RoundTrip(req)
+			})
+		}
+	}
+	tenant := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Tenant", "east")
+			return next.RoundTrip(req)
+		})
+	}
+
+	// Fail the first attempt in transport, as a chaos middleware would
+	var attempts int
+	chaos := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			if attempts == 1 {
+				return nil, errors.New("connection reset by chaos")
+			}
+			return next.RoundTrip(req)
+		})
+	}
+
+	var bodies []string
+	config := standInConfig()
+	config.Middleware = []Middleware{logging("outer"), tenant, logging("inner"), chaos}
+	config.Transport = standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(data))
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	})
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"outer  auth=true", "inner east auth=true",
+		"outer  auth=true", "inner east auth=true",
+	}, seen)
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], "SELECT NodeID FROM Orion.Nodes")
+}
+
+func TestClient_TimeoutPerAttempt(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+
+		if n == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	config := standInConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Timeout = 50 * time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	_, err = client.QueryContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestClient_StreamedCallFinishesOnClose(t *testing.T) {
+	config := standInConfig()
+	config.Transport = standIn(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"results":[{"NodeID":1},{"NodeID":2}]}`))
+	})
+
+	var finished []RequestMetrics
+	config.Metrics = MetricsRecorderFunc(func(ctx context.Context, m RequestMetrics) {
+		finished = append(finished, m)
+	})
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	rows, err := client.QueryIterContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	var n int
+	for rows.Next() {
+		n++
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, 2, n)
+	assert.Empty(t, finished, "a streamed call is reported once its body is closed")
+
+	require.NoError(t, rows.Close())
+	require.Len(t, finished, 1)
+	assert.Equal(t, int64(len(`{"results":[{"NodeID":1},{"NodeID":2}]}`)), finished[0].ResponseSize)
+	assert.True(t, strings.HasPrefix(finished[0].Endpoint, "Query"))
+}
@@ -0,0 +1,484 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrxinu/gosolar/swql"
+)
+
+// NodeService manages Orion nodes: adding them with their pollers, polling,
+// rediscovery, maintenance and removal
+//
+//	node, err := client.Nodes().Add(ctx, gosolar.NodeSpec{
+//		IPAddress: "10.0.0.1",
+//		Caption:   "core-sw1",
+//		Method:    gosolar.PollingSNMPv2c,
+//		Community: "public",
+//	})
+type NodeService struct {
+	client *Client
+}
+
+// Nodes returns the node service of the client
+func (c *Client) Nodes() *NodeService {
+	return &NodeService{client: c}
+}
+
+// PollingMethod is how Orion polls a node
+type PollingMethod string
+
+const (
+	PollingICMP    PollingMethod = "ICMP"
+	PollingSNMPv2c PollingMethod = "SNMPv2c"
+	PollingSNMPv3  PollingMethod = "SNMPv3"
+	PollingWMI     PollingMethod = "WMI"
+	PollingAgent   PollingMethod = "Agent"
+)
+
+// DefaultPollers are the pollers created for a new node by polling method
+var DefaultPollers = map[PollingMethod][]string{
+	PollingICMP: {
+		"N.Status.ICMP.Native",
+		"N.ResponseTime.ICMP.Native",
+	},
+	PollingSNMPv2c: snmpPollers,
+	PollingSNMPv3:  snmpPollers,
+	PollingWMI: {
+		"N.Status.ICMP.Native",
+		"N.ResponseTime.ICMP.Native",
+		"N.Details.WMI.Vista",
+		"N.Uptime.WMI.XP",
+		"N.Cpu.WMI.Windows",
+		"N.Memory.WMI.Windows",
+		"N.AssetInventory.Wmi.Generic",
+	},
+	PollingAgent: {
+		"N.Status.Agent.Native",
+		"N.ResponseTime.Agent.Native",
+		"N.Details.Agent.Generic",
+		"N.Uptime.Agent.Generic",
+	},
+}
+
+var snmpPollers = []string{
+	"N.Status.ICMP.Native",
+	"N.Status.SNMP.Native",
+	"N.ResponseTime.ICMP.Native",
+	"N.ResponseTime.SNMP.Native",
+	"N.Details.SNMP.Generic",
+	"N.Uptime.SNMP.Generic",
+	"N.Cpu.SNMP.HrProcessorLoad",
+	"N.Memory.SNMP.NetSnmpReal",
+	"N.AssetInventory.Snmp.Generic",
+}
+
+// SNMPv3Credentials are the SNMPv3 settings of a node
+type SNMPv3Credentials struct {
+	Username string
+	Context  string
+
+	// AuthMethod is None, MD5 or SHA1
+	AuthMethod        string
+	AuthKey           string
+	AuthKeyIsPassword bool
+
+	// PrivMethod is None, DES56, AES128, AES192 or AES256
+	PrivMethod        string
+	PrivKey           string
+	PrivKeyIsPassword bool
+}
+
+// NodeSpec describes a node to add
+type NodeSpec struct {
+	IPAddress string
+	Caption   string
+
+	// EngineID is the polling engine (default: 1)
+	EngineID int
+
+	// Method is how the node is polled (default: ICMP)
+	Method PollingMethod
+
+	// Community is the SNMPv2c community string
+	Community string
+
+	// SNMPPort is the SNMP port (default: 161)
+	SNMPPort int
+
+	// SNMPv3 holds the SNMPv3 credentials
+	SNMPv3 *SNMPv3Credentials
+
+	// CredentialID is the Orion credential used for WMI polling
+	CredentialID int
+
+	// Pollers replaces the default pollers of Method
+	Pollers []string
+
+	// CustomProperties are set on the node once it's created
+	CustomProperties map[string]interface{}
+}
+
+// Validate checks if the NodeSpec is valid
+func (s *NodeSpec) Validate() error {
+	if net.ParseIP(s.IPAddress) == nil {
+		return NewError(ErrorTypeValidation, "add_node", fmt.Sprintf("invalid IP address %q", s.IPAddress))
+	}
+	if s.EngineID < 0 || s.SNMPPort < 0 || s.SNMPPort > 65535 {
+		return NewError(ErrorTypeValidation, "add_node", "engine ID and SNMP port must be valid")
+	}
+
+	switch s.method() {
+	case PollingICMP, PollingAgent:
+	case PollingSNMPv2c:
+		if s.Community == "" {
+			return NewError(ErrorTypeValidation, "add_node", "SNMPv2c requires a community string")
+		}
+	case PollingSNMPv3:
+		if s.SNMPv3 == nil || s.SNMPv3.Username == "" {
+			return NewError(ErrorTypeValidation, "add_node", "SNMPv3 requires a username")
+		}
+	case PollingWMI:
+		if s.CredentialID <= 0 {
+			return NewError(ErrorTypeValidation, "add_node", "WMI requires a credential ID")
+		}
+	default:
+		return NewError(ErrorTypeValidation, "add_node", fmt.Sprintf("unknown polling method %q", s.Method))
+	}
+	return nil
+}
+
+func (s *NodeSpec) method() PollingMethod {
+	if s.Method == "" {
+		return PollingICMP
+	}
+	return s.Method
+}
+
+// properties returns the Orion.Nodes properties of the spec
+func (s *NodeSpec) properties() map[string]interface{} {
+	engineID := s.EngineID
+	if engineID == 0 {
+		engineID = 1
+	}
+	caption := s.Caption
+	if caption == "" {
+		caption = s.IPAddress
+	}
+
+	props := map[string]interface{}{
+		"IPAddress": s.IPAddress,
+		"Caption":   caption,
+		"EngineID":  engineID,
+	}
+
+	method := s.method()
+	switch method {
+	case PollingSNMPv2c, PollingSNMPv3:
+		port := s.SNMPPort
+		if port == 0 {
+			port = 161
+		}
+		props["ObjectSubType"] = "SNMP"
+		props["AgentPort"] = port
+	default:
+		props["ObjectSubType"] = string(method)
+	}
+
+	switch method {
+	case PollingSNMPv2c:
+		props["SNMPVersion"] = 2
+		props["Community"] = s.Community
+	case PollingSNMPv3:
+		v3 := s.SNMPv3
+		props["SNMPVersion"] = 3
+		props["SNMPV3Username"] = v3.Username
+		props["SNMPV3Context"] = v3.Context
+		props["SNMPV3AuthMethod"] = orDefault(v3.AuthMethod, "None")
+		props["SNMPV3AuthKey"] = v3.AuthKey
+		props["SNMPV3AuthKeyIsPwd"] = v3.AuthKeyIsPassword
+		props["SNMPV3PrivMethod"] = orDefault(v3.PrivMethod, "None")
+		props["SNMPV3PrivKey"] = v3.PrivKey
+		props["SNMPV3PrivKeyIsPwd"] = v3.PrivKeyIsPassword
+	default:
+		props["SNMPVersion"] = 0
+	}
+	return props
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// Node is an Orion node
+type Node struct {
+	NodeID            int    `json:"nodeid" swis:"NodeID"`
+	URI               URI    `json:"uri" swis:"Uri"`
+	Caption           string `json:"caption" swis:"Caption"`
+	IPAddress         string `json:"ipaddress" swis:"IPAddress"`
+	DNS               string `json:"dns" swis:"DNS"`
+	SysName           string `json:"sysname" swis:"SysName"`
+	Status            int    `json:"status" swis:"Status"`
+	StatusDescription string `json:"statusdescription" swis:"StatusDescription"`
+	ObjectSubType     string `json:"objectsubtype" swis:"ObjectSubType"`
+	SNMPVersion       int    `json:"snmpversion" swis:"SNMPVersion"`
+	EngineID          int    `json:"engineid" swis:"EngineID"`
+	Vendor            string `json:"vendor" swis:"Vendor"`
+	MachineType       string `json:"machinetype" swis:"MachineType"`
+	Unmanaged         bool   `json:"unmanaged" swis:"Unmanaged"`
+	UnmanageFrom      Time   `json:"unmanagefrom" swis:"UnmanageFrom"`
+	UnmanageUntil     Time   `json:"unmanageuntil" swis:"UnmanageUntil"`
+	LastBoot          Time   `json:"lastboot" swis:"LastBoot"`
+	LastSync          Time   `json:"lastsync" swis:"LastSync"`
+}
+
+var nodeColumns = []interface{}{
+	"NodeID", "Uri", "Caption", "IPAddress", "DNS", "SysName", "Status",
+	"StatusDescription", "ObjectSubType", "SNMPVersion", "EngineID", "Vendor",
+	"MachineType", "Unmanaged", "UnmanageFrom", "UnmanageUntil", "LastBoot", "LastSync",
+}
+
+// NodeFilter selects the nodes List returns. Empty fields match every node.
+type NodeFilter struct {
+	NodeIDs []int
+
+	// Caption, IPAddress and Vendor are SWQL LIKE patterns
+	Caption   string
+	IPAddress string
+	Vendor    string
+
+	Status    []int
+	EngineID  int
+	Unmanaged *bool
+
+	// Where adds further conditions on Orion.Nodes
+	Where []swql.Condition
+
+	// Limit caps the number of nodes returned (0: no limit)
+	Limit int
+}
+
+func (f NodeFilter) query() *swql.Query {
+	q := swql.Select(nodeColumns...).From("Orion.Nodes")
+	if len(f.NodeIDs) > 0 {
+		q.In("NodeID", intValues(f.NodeIDs)...)
+	}
+	if f.Caption != "" {
+		q.Where(swql.Like("Caption", f.Caption))
+	}
+	if f.IPAddress != "" {
+		q.Where(swql.Like("IPAddress", f.IPAddress))
+	}
+	if f.Vendor != "" {
+		q.Where(swql.Like("Vendor", f.Vendor))
+	}
+	if len(f.Status) > 0 {
+		q.In("Status", intValues(f.Status)...)
+	}
+	if f.EngineID > 0 {
+		q.Where(swql.Eq("EngineID", f.EngineID))
+	}
+	if f.Unmanaged != nil {
+		q.Where(swql.Eq("Unmanaged", *f.Unmanaged))
+	}
+	q.Where(f.Where...)
+	if f.Limit > 0 {
+		q.Limit(f.Limit)
+	}
+	return q.OrderBy("NodeID")
+}
+
+// Add creates a node and its pollers, then sets its custom properties. If a
+// later step fails the node is deleted again so no half-configured node is
+// left behind, even when the failure is ctx being canceled.
+func (s *NodeService) Add(ctx context.Context, spec NodeSpec) (*Node, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	res, err := s.client.CreateContext(ctx, "Orion.Nodes", spec.properties())
+	if err != nil {
+		return nil, wrapCallError(err, ErrorTypeInternal, "add_node", "failed to create node")
+	}
+
+	var uri URI
+	if err := json.Unmarshal(res, &uri); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "add_node", "failed to parse created node URI")
+	}
+	id, err := nodeIDOf(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.configure(ctx, id, uri, spec); err != nil {
+		// The cleanup outlives ctx, which may be why configure failed
+		cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.client.config.Timeout)
+		defer cancel()
+		if _, delErr := s.client.DeleteURIContext(cleanupCtx, uri); delErr != nil {
+			s.client.logger.WarnContext(ctx, "failed to remove partially added node", "uri", uri.String(), "error", delErr)
+		}
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// configure creates the pollers and settings of a new node
+func (s *NodeService) configure(ctx context.Context, id int, uri URI, spec NodeSpec) error {
+	netObject := netObjectID(id)
+
+	if spec.method() == PollingWMI {
+		setting := map[string]interface{}{
+			"NodeID":       id,
+			"SettingName":  "WMICredential",
+			"SettingValue": strconv.Itoa(spec.CredentialID),
+		}
+		if _, err := s.client.CreateContext(ctx, "Orion.NodeSettings", setting); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, "add_node", "failed to assign WMI credential")
+		}
+	}
+
+	pollers := spec.Pollers
+	if pollers == nil {
+		pollers = DefaultPollers[spec.method()]
+	}
+	for _, pollerType := range pollers {
+		poller := map[string]interface{}{
+			"PollerType":    pollerType,
+			"NetObject":     netObject,
+			"NetObjectType": "N",
+			"NetObjectID":   id,
+			"Enabled":       true,
+		}
+		if _, err := s.client.CreateContext(ctx, "Orion.Pollers", poller); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, "add_node", fmt.Sprintf("failed to create poller %s", pollerType))
+		}
+	}
+
+	if len(spec.CustomProperties) > 0 {
+		if err := s.client.SetCustomPropertiesURIContext(ctx, uri, spec.CustomProperties); err != nil {
+			return wrapCallError(err, ErrorTypeInternal, "add_node", "failed to set custom properties")
+		}
+	}
+	return nil
+}
+
+// Get returns a node by ID. It returns an ErrorTypeNotFound error if there's
+// no such node.
+func (s *NodeService) Get(ctx context.Context, id int) (*Node, error) {
+	nodes, err := s.list(ctx, "get_node", NodeFilter{NodeIDs: []int{id}})
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, NewError(ErrorTypeNotFound, "get_node", fmt.Sprintf("node %d not found", id))
+	}
+	return &nodes[0], nil
+}
+
+// List returns the nodes matching filter ordered by ID
+func (s *NodeService) List(ctx context.Context, filter NodeFilter) ([]Node, error) {
+	return s.list(ctx, "list_nodes", filter)
+}
+
+func (s *NodeService) list(ctx context.Context, operation string, filter NodeFilter) ([]Node, error) {
+	query, params, err := filter.query().Build()
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeValidation, operation, "invalid node filter")
+	}
+
+	nodes := []Node{}
+	if err := s.client.queryScan(ctx, operation, query, params, &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// Delete removes a node and everything Orion monitors on it
+func (s *NodeService) Delete(ctx context.Context, id int) error {
+	node, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.DeleteURIContext(ctx, node.URI); err != nil {
+		return wrapCallError(err, ErrorTypeInternal, "delete_node", fmt.Sprintf("failed to delete node %d", id))
+	}
+	return nil
+}
+
+// PollNow asks Orion to poll a node right away
+func (s *NodeService) PollNow(ctx context.Context, id int) error {
+	return s.invoke(ctx, "poll_node", "PollNow", Args{"netObjectId": netObjectID(id)}, netObjectArgs)
+}
+
+// Rediscover schedules a rediscovery of a node's resources
+func (s *NodeService) Rediscover(ctx context.Context, id int) error {
+	return s.invoke(ctx, "rediscover_node", "Rediscover", Args{"netObjectId": netObjectID(id)}, netObjectArgs)
+}
+
+// Unmanage stops monitoring a node between from and until
+func (s *NodeService) Unmanage(ctx context.Context, id int, from, until time.Time) error {
+	if !until.After(from) {
+		return NewError(ErrorTypeValidation, "unmanage_node", "until must be after from")
+	}
+	args := Args{
+		"netObjectId":  netObjectID(id),
+		"unmanageTime": from,
+		"remanageTime": until,
+		"isRelative":   false,
+	}
+	return s.invoke(ctx, "unmanage_node", "Unmanage", args, unmanageArgs)
+}
+
+// Remanage resumes monitoring of an unmanaged node
+func (s *NodeService) Remanage(ctx context.Context, id int) error {
+	return s.invoke(ctx, "remanage_node", "Remanage", Args{"netObjectId": netObjectID(id)}, netObjectArgs)
+}
+
+func (s *NodeService) invoke(ctx context.Context, operation, verb string, args Args, signature []VerbArgument) error {
+	if _, err := s.client.callVerb(ctx, operation, "Orion.Nodes", verb, args, signature); err != nil {
+		return wrapCallError(err, ErrorTypeInternal, operation, fmt.Sprintf("failed to invoke Orion.Nodes.%s", verb))
+	}
+	return nil
+}
+
+// netObjectArgs is the signature of the Orion.Nodes verbs taking only a net
+// object ID, which interfaces and applications share
+var netObjectArgs = []VerbArgument{
+	{Position: 1, Name: "netObjectId", Type: "System.String"},
+}
+
+// unmanageArgs is the signature of Unmanage on nodes, interfaces and
+// applications
+var unmanageArgs = []VerbArgument{
+	{Position: 1, Name: "netObjectId", Type: "System.String"},
+	{Position: 2, Name: "unmanageTime", Type: "System.DateTime"},
+	{Position: 3, Name: "remanageTime", Type: "System.DateTime"},
+	{Position: 4, Name: "isRelative", Type: "System.Boolean"},
+}
+
+// netObjectID returns the net object ID of a node, such as N:1
+func netObjectID(id int) string {
+	return "N:" + strconv.Itoa(id)
+}
+
+// nodeIDOf reads the NodeID key of a node URI
+func nodeIDOf(uri URI) (int, error) {
+	value, ok := uri.Key("NodeID")
+	if !ok || !strings.EqualFold(uri.Entity, "Orion.Nodes") {
+		return 0, NewError(ErrorTypeInternal, "add_node", fmt.Sprintf("unexpected node URI %s", uri))
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, WrapError(err, ErrorTypeInternal, "add_node", fmt.Sprintf("unexpected node URI %s", uri))
+	}
+	return id, nil
+}
@@ -0,0 +1,231 @@
+package gosolar
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeResults answers queries with a single node with ID 42 and its
+// creation with its URI
+var nodeResults = map[string]string{
+	"Create/Orion.Nodes": `"swis://orion/Orion/Orion.Nodes/NodeID=42"`,
+	"": `[{"NodeID":42,"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=42","Caption":"core-sw1",` +
+		`"IPAddress":"10.0.0.1","Status":1,"ObjectSubType":"SNMP","SNMPVersion":2,"EngineID":1,` +
+		`"Unmanaged":false,"UnmanageFrom":null,"UnmanageUntil":null,"LastBoot":"2024-03-01T12:30:00"}]`,
+}
+
+// fakeNode answers requests about node 42 that nodeResults can't, which is
+// gone once it has been deleted
+type fakeNode struct {
+	// fail rejects requests to endpoints starting with it
+	fail string
+
+	// hook is called with each request before it is answered
+	hook func(path string, r *http.Request)
+
+	deleted atomic.Bool
+}
+
+func (n *fakeNode) handle(w http.ResponseWriter, r *http.Request, req fakeRequest) bool {
+	if n.hook != nil {
+		n.hook(req.Path, r)
+	}
+
+	switch {
+	case n.fail != "" && strings.HasPrefix(req.Path, n.fail):
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"Message":"rejected"}`))
+	case strings.HasPrefix(req.Path, "Create/") && req.Path != "Create/Orion.Nodes":
+		_, _ = w.Write([]byte(`"swis://orion/Orion/Orion.Pollers/PollerID=1"`))
+	case req.Method == http.MethodDelete:
+		n.deleted.Store(true)
+		return false
+	case req.Path == "Query" && n.deleted.Load():
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	default:
+		return false
+	}
+	return true
+}
+
+func TestNodeService_Add(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{}).handle)
+	client := newTestClient(t, server.Server)
+
+	node, err := client.Nodes().Add(context.Background(), NodeSpec{
+		IPAddress:        "10.0.0.1",
+		Caption:          "core-sw1",
+		Method:           PollingSNMPv2c,
+		Community:        "public",
+		CustomProperties: map[string]interface{}{"City": "Austin"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 42, node.NodeID)
+	assert.Equal(t, "core-sw1", node.Caption)
+	assert.Equal(t, "swis://orion/Orion/Orion.Nodes/NodeID=42", node.URI.String())
+	assert.Equal(t, 2024, node.LastBoot.Year())
+
+	require.Len(t, server.bodies("Create/Orion.Nodes"), 1)
+	assert.JSONEq(t, `{"IPAddress":"10.0.0.1","Caption":"core-sw1","EngineID":1,"ObjectSubType":"SNMP",
+		"AgentPort":161,"SNMPVersion":2,"Community":"public"}`, server.bodies("Create/Orion.Nodes")[0])
+
+	pollers := server.bodies("Create/Orion.Pollers")
+	require.Len(t, pollers, len(DefaultPollers[PollingSNMPv2c]))
+	assert.JSONEq(t, `{"PollerType":"N.Status.ICMP.Native","NetObject":"N:42","NetObjectType":"N","NetObjectID":42,"Enabled":true}`,
+		pollers[0])
+
+	cp := server.bodies("swis://orion/Orion/Orion.Nodes/NodeID=42/CustomProperties")
+	require.Len(t, cp, 1)
+	assert.JSONEq(t, `{"City":"Austin"}`, cp[0])
+}
+
+func TestNodeService_AddWMI(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{}).handle)
+	client := newTestClient(t, server.Server)
+
+	_, err := client.Nodes().Add(context.Background(), NodeSpec{
+		IPAddress:    "10.0.0.2",
+		Method:       PollingWMI,
+		CredentialID: 7,
+		Pollers:      []string{"N.Status.ICMP.Native"},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, server.bodies("Create/Orion.NodeSettings"), 1)
+	assert.JSONEq(t, `{"NodeID":42,"SettingName":"WMICredential","SettingValue":"7"}`, server.bodies("Create/Orion.NodeSettings")[0])
+	assert.Len(t, server.bodies("Create/Orion.Pollers"), 1)
+	assert.JSONEq(t, `{"IPAddress":"10.0.0.2","Caption":"10.0.0.2","EngineID":1,"ObjectSubType":"WMI","SNMPVersion":0}`,
+		server.bodies("Create/Orion.Nodes")[0])
+}
+
+func TestNodeService_AddRollsBack(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{fail: "Create/Orion.Pollers"}).handle)
+	client := newTestClient(t, server.Server)
+
+	_, err := client.Nodes().Add(context.Background(), NodeSpec{IPAddress: "10.0.0.1"})
+	require.Error(t, err)
+
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, "add_node", swErr.Operation)
+	assert.Equal(t, ErrorTypeValidation, swErr.Type, "the type SWIS failed with is kept")
+	assert.Contains(t, server.calls(), "DELETE swis://orion/Orion/Orion.Nodes/NodeID=42")
+}
+
+func TestNodeService_AddRollsBackCanceled(t *testing.T) {
+	// The caller gives up while the pollers are being created
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	node := &fakeNode{hook: func(path string, r *http.Request) {
+		if path == "Create/Orion.Pollers" {
+			cancel()
+			<-r.Context().Done()
+		}
+	}}
+	server := newFakeSWIS(t, nodeResults, node.handle)
+	client := newTestClient(t, server.Server)
+
+	_, err := client.Nodes().Add(ctx, NodeSpec{IPAddress: "10.0.0.1"})
+	require.ErrorIs(t, err, context.Canceled)
+
+	assert.Contains(t, server.calls(), "DELETE swis://orion/Orion/Orion.Nodes/NodeID=42")
+}
+
+func TestNodeService_VerbErrorType(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{fail: "Invoke/Orion.Nodes/PollNow"}).handle)
+	client := newTestClient(t, server.Server)
+
+	err := client.Nodes().PollNow(context.Background(), 42)
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeValidation, swErr.Type)
+	assert.Equal(t, http.StatusBadRequest, swErr.StatusCode)
+	assert.Equal(t, "poll_node", swErr.Operation)
+}
+
+func TestNodeSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec NodeSpec
+	}{
+		{"bad address", NodeSpec{IPAddress: "core-sw1"}},
+		{"no community", NodeSpec{IPAddress: "10.0.0.1", Method: PollingSNMPv2c}},
+		{"no v3 user", NodeSpec{IPAddress: "10.0.0.1", Method: PollingSNMPv3, SNMPv3: &SNMPv3Credentials{}}},
+		{"no credential", NodeSpec{IPAddress: "10.0.0.1", Method: PollingWMI}},
+		{"unknown method", NodeSpec{IPAddress: "10.0.0.1", Method: "Telnet"}},
+		{"bad port", NodeSpec{IPAddress: "10.0.0.1", SNMPPort: 70000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.spec.Validate()
+			require.Error(t, err)
+			assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+		})
+	}
+
+	v3 := NodeSpec{
+		IPAddress: "10.0.0.1",
+		Method:    PollingSNMPv3,
+		SNMPv3:    &SNMPv3Credentials{Username: "monitor", AuthMethod: "SHA1", AuthKey: "secret", AuthKeyIsPassword: true},
+	}
+	require.NoError(t, v3.Validate())
+	props := v3.properties()
+	assert.Equal(t, 3, props["SNMPVersion"])
+	assert.Equal(t, "SHA1", props["SNMPV3AuthMethod"])
+	assert.Equal(t, "None", props["SNMPV3PrivMethod"])
+}
+
+func TestNodeService_Verbs(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{}).handle)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	nodes := client.Nodes()
+
+	require.NoError(t, nodes.PollNow(ctx, 42))
+	require.NoError(t, nodes.Rediscover(ctx, 42))
+	require.NoError(t, nodes.Remanage(ctx, 42))
+
+	from := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	require.NoError(t, nodes.Unmanage(ctx, 42, from, from.Add(2*time.Hour)))
+
+	assert.JSONEq(t, `["N:42"]`, server.bodies("Invoke/Orion.Nodes/PollNow")[0])
+	assert.JSONEq(t, `["N:42"]`, server.bodies("Invoke/Orion.Nodes/Rediscover")[0])
+	assert.JSONEq(t, `["N:42"]`, server.bodies("Invoke/Orion.Nodes/Remanage")[0])
+	assert.JSONEq(t, `["N:42","2024-03-01T22:00:00","2024-03-02T00:00:00",false]`, server.bodies("Invoke/Orion.Nodes/Unmanage")[0])
+
+	err := nodes.Unmanage(ctx, 42, from, from)
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+}
+
+func TestNodeService_GetListDelete(t *testing.T) {
+	server := newFakeSWIS(t, nodeResults, (&fakeNode{}).handle)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	nodes := client.Nodes()
+
+	unmanaged := false
+	list, err := nodes.List(ctx, NodeFilter{Caption: "core-%", Status: []int{NodeStatusUp}, Unmanaged: &unmanaged, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	req := server.queries()[0]
+	assert.Contains(t, req.Query, "SELECT TOP 10 NodeID, Uri, Caption")
+	assert.Contains(t, req.Query, "WHERE (Caption LIKE @p0 AND Status IN (@p1) AND Unmanaged = @p2)")
+	assert.Contains(t, req.Query, "ORDER BY NodeID")
+	assert.Equal(t, map[string]interface{}{"p0": "core-%", "p1": float64(1), "p2": false}, req.Params)
+
+	require.NoError(t, nodes.Delete(ctx, 42))
+	assert.Contains(t, server.calls(), "DELETE swis://orion/Orion/Orion.Nodes/NodeID=42")
+
+	_, err = nodes.Get(ctx, 42)
+	assert.ErrorIs(t, err, &Error{Type: ErrorTypeNotFound})
+	assert.ErrorIs(t, nodes.Delete(ctx, 42), &Error{Type: ErrorTypeNotFound})
+}
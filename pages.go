@@ -0,0 +1,361 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mrxinu/gosolar/swql/parser"
+)
+
+// DefaultPageSize is the number of rows fetched per page when PageOptions
+// doesn't set one
+const DefaultPageSize = 1000
+
+// PageOptions controls how a query is split into pages
+type PageOptions struct {
+	// PageSize is the number of rows requested per page (default: 1000)
+	PageSize int
+
+	// MaxRows stops paging once this many rows have been returned (0: no limit)
+	MaxRows int
+
+	// Parallelism is the number of pages fetched concurrently (default: 1).
+	// Parallel paging needs the total row count, so it implies TotalRows.
+	Parallelism int
+
+	// TotalRows asks SWIS for the total number of rows the query matches
+	TotalRows bool
+}
+
+// rowsPattern finds WITH ROWS and WITH TOTALROWS in queries the parser
+// can't read
+var rowsPattern = regexp.MustCompile(`(?i)\bWITH\s+(TOTAL)?ROWS\b`)
+
+// checkPageable rejects a query that already limits or counts its rows, as
+// paging adds its own WITH ROWS and WITH TOTALROWS. Queries the parser can't
+// read are only checked for the WITH clauses by pattern.
+func checkPageable(query string) error {
+	stmt, err := parser.Parse(query)
+	if err != nil {
+		if rowsPattern.MatchString(query) {
+			return NewError(ErrorTypeValidation, "query_pages", "query already contains WITH ROWS or WITH TOTALROWS")
+		}
+		return nil
+	}
+
+	switch {
+	case stmt.Top != nil:
+		return NewError(ErrorTypeValidation, "query_pages", "query already contains TOP; use PageOptions.MaxRows")
+	case stmt.Rows != nil:
+		return NewError(ErrorTypeValidation, "query_pages", "query already contains WITH ROWS")
+	case stmt.TotalRows:
+		return NewError(ErrorTypeValidation, "query_pages", "query already contains WITH TOTALROWS; use PageOptions.TotalRows")
+	}
+	return nil
+}
+
+// pagedQuery appends a WITH ROWS clause (and WITH TOTALROWS if asked) to
+// query, which checkPageable has accepted. Rows are 1-based and both bounds
+// are inclusive. The clauses go on a new line so a trailing line comment in
+// the query can't swallow them.
+func pagedQuery(query string, first, last int, totalRows bool) string {
+	q := strings.TrimRight(strings.TrimSpace(query), ";")
+	q = fmt.Sprintf("%s\nWITH ROWS %d TO %d", q, first, last)
+	if totalRows {
+		q += " WITH TOTALROWS"
+	}
+	return q
+}
+
+// Pages iterates over the pages of a query. Stop at any point by calling
+// Close; pages not yet requested are never fetched.
+//
+//	pages, err := client.QueryPagesContext(ctx, query, nil, gosolar.PageOptions{PageSize: 500})
+//	if err != nil {
+//		return err
+//	}
+//	defer pages.Close()
+//
+//	for pages.Next() {
+//		var nodes []gosolar.CommonNode
+//		if err := pages.Scan(&nodes); err != nil {
+//			return err
+//		}
+//	}
+//	return pages.Err()
+type Pages struct {
+	client *Client
+	ctx    context.Context
+	cancel context.CancelFunc
+	query  string
+	params interface{}
+	opts   PageOptions
+
+	next      int // index of the next page to deliver
+	delivered int // rows delivered so far, after MaxRows
+	seen      int // rows received from the server
+	total     int // total rows reported by SWIS, -1 if unknown
+	exhausted bool
+	rows      []json.RawMessage
+	pending   []chan pageResult // pages 1..launched, fetched in parallel
+	launched  int
+	done      bool
+	err       error
+}
+
+type pageResult struct {
+	rows  []json.RawMessage
+	total int
+	err   error
+}
+
+// QueryPages executes a query page by page using WITH ROWS
+func (c *Client) QueryPages(query string, parameters interface{}, opts PageOptions) (*Pages, error) {
+	return c.QueryPagesContext(context.Background(), query, parameters, opts)
+}
+
+// QueryPagesContext executes a query page by page with context. The query
+// must not already contain TOP, WITH ROWS or WITH TOTALROWS and should have
+// an ORDER BY so page boundaries are stable.
+func (c *Client) QueryPagesContext(ctx context.Context, query string, parameters interface{}, opts PageOptions) (*Pages, error) {
+	if err := checkPageable(query); err != nil {
+		return nil, err
+	}
+	if err := c.validateQuery(query, parameters); err != nil {
+		return nil, err
+	}
+	if opts.PageSize < 0 || opts.MaxRows < 0 || opts.Parallelism < 0 {
+		return nil, NewError(ErrorTypeValidation, "query_pages", "page options cannot be negative")
+	}
+	if opts.PageSize == 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.Parallelism == 0 {
+		opts.Parallelism = 1
+	}
+	if opts.Parallelism > 1 {
+		opts.TotalRows = true
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Pages{
+		client: c,
+		ctx:    ctx,
+		cancel: cancel,
+		query:  query,
+		params: parameters,
+		opts:   opts,
+		total:  -1,
+	}, nil
+}
+
+// QueryPaged executes a query page by page and collects every row. The total
+// row count, if requested, is reported in the result's Meta.
+func (c *Client) QueryPaged(query string, parameters interface{}, opts PageOptions) (*QueryResult[json.RawMessage], error) {
+	return c.QueryPagedContext(context.Background(), query, parameters, opts)
+}
+
+// QueryPagedContext executes a query page by page with context and collects
+// every row
+func (c *Client) QueryPagedContext(ctx context.Context, query string, parameters interface{}, opts PageOptions) (*QueryResult[json.RawMessage], error) {
+	start := time.Now()
+
+	pages, err := c.QueryPagesContext(ctx, query, parameters, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer pages.Close()
+
+	results := []json.RawMessage{}
+	for pages.Next() {
+		results = append(results, pages.Rows()...)
+	}
+	if err := pages.Err(); err != nil {
+		return nil, err
+	}
+
+	meta := &QueryMeta{
+		ExecutionTime: time.Since(start),
+		RowCount:      len(results),
+		Query:         query,
+	}
+	if total, ok := pages.TotalRows(); ok {
+		meta.TotalRows = total
+	}
+
+	return &QueryResult[json.RawMessage]{
+		Results: results,
+		Count:   len(results),
+		Meta:    meta,
+	}, nil
+}
+
+// Next fetches the next page, returning false when there are no more pages
+// or an error occurred. Check Err to tell the two apart.
+func (p *Pages) Next() bool {
+	if p.done {
+		return false
+	}
+	if p.exhausted ||
+		(p.opts.MaxRows > 0 && p.delivered >= p.opts.MaxRows) ||
+		(p.total >= 0 && p.next*p.opts.PageSize >= p.total) {
+		return p.finish(nil)
+	}
+
+	var res pageResult
+	if p.next > 0 && p.opts.Parallelism > 1 && p.total >= 0 {
+		p.launch()
+		res = <-p.pending[p.next-1]
+	} else {
+		res = p.fetch(p.next)
+	}
+
+	if res.err != nil {
+		return p.finish(res.err)
+	}
+	if res.total >= 0 {
+		p.total = res.total
+	}
+
+	p.seen += len(res.rows)
+	// A short page means the server ran out of rows
+	if len(res.rows) < p.opts.PageSize {
+		p.exhausted = true
+	}
+
+	rows := res.rows
+	if p.opts.MaxRows > 0 && p.delivered+len(rows) > p.opts.MaxRows {
+		rows = rows[:p.opts.MaxRows-p.delivered]
+	}
+	if len(rows) == 0 {
+		return p.finish(nil)
+	}
+
+	p.next++
+	p.delivered += len(rows)
+	p.rows = rows
+	return true
+}
+
+// launch starts fetching further pages in the background, keeping at most
+// Parallelism requests in flight counting the page about to be delivered.
+// Page 0 is always fetched on its own since it reports the total row count.
+func (p *Pages) launch() {
+	last := p.pageCount() - 1
+	for p.launched < last && p.launched-p.next+1 < p.opts.Parallelism {
+		p.launched++
+		ch := make(chan pageResult, 1)
+		p.pending = append(p.pending, ch)
+
+		index := p.launched
+		go func() {
+			ch <- p.fetch(index)
+		}()
+	}
+}
+
+// pageCount returns the number of pages needed for the known total,
+// honoring MaxRows
+func (p *Pages) pageCount() int {
+	total := p.total
+	if p.opts.MaxRows > 0 && p.opts.MaxRows < total {
+		total = p.opts.MaxRows
+	}
+	return (total + p.opts.PageSize - 1) / p.opts.PageSize
+}
+
+// fetch requests a single page by 0-based index
+func (p *Pages) fetch(index int) pageResult {
+	first := index*p.opts.PageSize + 1
+	last := first + p.opts.PageSize - 1
+
+	req := struct {
+		Query      string      `json:"query"`
+		Parameters interface{} `json:"parameters"`
+	}{
+		Query:      pagedQuery(p.query, first, last, p.opts.TotalRows),
+		Parameters: p.client.localParams(p.params),
+	}
+
+	res, err := p.client.PostContext(p.ctx, "Query", &req)
+	if err != nil {
+		return pageResult{err: err}
+	}
+
+	sr := struct {
+		TotalRows *int              `json:"totalRows"`
+		Results   []json.RawMessage `json:"results"`
+	}{}
+	if err := json.Unmarshal(res, &sr); err != nil {
+		return pageResult{err: WrapError(err, ErrorTypeInternal, "query_pages", "failed to parse query response")}
+	}
+
+	total := -1
+	if sr.TotalRows != nil {
+		total = *sr.TotalRows
+	}
+	return pageResult{rows: sr.Results, total: total}
+}
+
+func (p *Pages) finish(err error) bool {
+	p.done = true
+	p.rows = nil
+	p.err = err
+	p.cancel()
+	return false
+}
+
+// Rows returns the rows of the current page
+func (p *Pages) Rows() []json.RawMessage {
+	return p.rows
+}
+
+// Scan decodes the rows of the current page into dest, which must be a
+// pointer to a slice. Structs are decoded like the package-level Scan does,
+// so swis tags apply.
+func (p *Pages) Scan(dest interface{}) error {
+	if p.rows == nil {
+		return NewError(ErrorTypeValidation, "scan", "Scan called without a current page")
+	}
+	data, err := json.Marshal(p.rows)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "scan", "failed to marshal page")
+	}
+	if err := decodeRows(data, dest, p.client.location()); err != nil {
+		return WrapError(err, ErrorTypeInternal, "scan", "failed to unmarshal page")
+	}
+	return nil
+}
+
+// Page returns the 1-based number of the current page
+func (p *Pages) Page() int {
+	return p.next
+}
+
+// TotalRows returns the total number of rows the query matches, if SWIS
+// reported it or paging has reached the last page
+func (p *Pages) TotalRows() (int, bool) {
+	if p.total >= 0 {
+		return p.total, true
+	}
+	if p.exhausted {
+		return p.seen, true
+	}
+	return 0, false
+}
+
+// Err returns the error, if any, that ended paging
+func (p *Pages) Err() error {
+	return p.err
+}
+
+// Close stops paging and cancels any page requests still in flight
+func (p *Pages) Close() {
+	if !p.done {
+		p.finish(nil)
+	}
+}
@@ -0,0 +1,178 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testRowsPattern = regexp.MustCompile(`WITH ROWS (\d+) TO (\d+)`)
+
+// pageNodes answers queries with the rows of total rows of {"NodeID": n}
+// asked for by WITH ROWS, and the total if asked for by WITH TOTALROWS
+func pageNodes(total int) fakeHandler {
+	return func(w http.ResponseWriter, r *http.Request, req fakeRequest) bool {
+		m := testRowsPattern.FindStringSubmatch(req.Query)
+		if m == nil {
+			http.Error(w, "query has no WITH ROWS: "+req.Query, http.StatusBadRequest)
+			return true
+		}
+		first, _ := strconv.Atoi(m[1])
+		last, _ := strconv.Atoi(m[2])
+
+		results := []map[string]int{}
+		for i := first; i <= last && i <= total; i++ {
+			results = append(results, map[string]int{"NodeID": i})
+		}
+
+		response := map[string]interface{}{"results": results}
+		if strings.Contains(req.Query, "WITH TOTALROWS") {
+			response["totalRows"] = total
+		}
+		_ = json.NewEncoder(w).Encode(response)
+		return true
+	}
+}
+
+func TestPagedQuery(t *testing.T) {
+	assert.Equal(t,
+		"SELECT NodeID FROM Orion.Nodes ORDER BY NodeID\nWITH ROWS 1 TO 100",
+		pagedQuery("SELECT NodeID FROM Orion.Nodes ORDER BY NodeID;", 1, 100, false))
+	assert.Equal(t,
+		"SELECT NodeID FROM Orion.Nodes -- all nodes\nWITH ROWS 101 TO 200 WITH TOTALROWS",
+		pagedQuery("SELECT NodeID FROM Orion.Nodes -- all nodes", 101, 200, true))
+}
+
+func TestClient_QueryPagedContext(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		opts         PageOptions
+		wantRows     int
+		wantTotal    int
+		wantRequests int
+	}{
+		{
+			name:         "sequential",
+			total:        25,
+			opts:         PageOptions{PageSize: 10},
+			wantRows:     25,
+			wantTotal:    25,
+			wantRequests: 3,
+		},
+		{
+			name:         "exact multiple of page size",
+			total:        20,
+			opts:         PageOptions{PageSize: 10},
+			wantRows:     20,
+			wantTotal:    20,
+			wantRequests: 3,
+		},
+		{
+			name:         "total rows avoids trailing request",
+			total:        20,
+			opts:         PageOptions{PageSize: 10, TotalRows: true},
+			wantRows:     20,
+			wantTotal:    20,
+			wantRequests: 2,
+		},
+		{
+			name:         "parallel",
+			total:        95,
+			opts:         PageOptions{PageSize: 10, Parallelism: 4},
+			wantRows:     95,
+			wantTotal:    95,
+			wantRequests: 10,
+		},
+		{
+			name:         "max rows",
+			total:        95,
+			opts:         PageOptions{PageSize: 10, MaxRows: 35, TotalRows: true},
+			wantRows:     35,
+			wantTotal:    95,
+			wantRequests: 4,
+		},
+		{
+			name:         "parallel with max rows",
+			total:        95,
+			opts:         PageOptions{PageSize: 10, MaxRows: 35, Parallelism: 3},
+			wantRows:     35,
+			wantTotal:    95,
+			wantRequests: 4,
+		},
+		{
+			name:         "empty",
+			total:        0,
+			opts:         PageOptions{PageSize: 10},
+			wantRows:     0,
+			wantTotal:    0,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSWIS(t, nil, pageNodes(tt.total))
+			client := newTestClient(t, server.Server)
+			result, err := client.QueryPagedContext(context.Background(), "SELECT NodeID FROM Orion.Nodes ORDER BY NodeID", nil, tt.opts)
+			require.NoError(t, err)
+
+			assert.Len(t, result.Results, tt.wantRows)
+			assert.Equal(t, tt.wantRows, result.Count)
+			assert.Equal(t, tt.wantRows, result.Meta.RowCount)
+			assert.Equal(t, tt.wantTotal, result.Meta.TotalRows)
+			assert.Equal(t, tt.wantRequests, server.count())
+
+			for i, row := range result.Results {
+				assert.JSONEq(t, fmt.Sprintf(`{"NodeID":%d}`, i+1), string(row))
+			}
+		})
+	}
+}
+
+func TestPages_StopEarly(t *testing.T) {
+	server := newFakeSWIS(t, nil, pageNodes(1000))
+	client := newTestClient(t, server.Server)
+	pages, err := client.QueryPagesContext(context.Background(), "SELECT NodeID FROM Orion.Nodes ORDER BY NodeID", nil, PageOptions{PageSize: 10})
+	require.NoError(t, err)
+
+	require.True(t, pages.Next())
+	var nodes []struct{ NodeID int }
+	require.NoError(t, pages.Scan(&nodes))
+	assert.Len(t, nodes, 10)
+	assert.Equal(t, 1, pages.Page())
+
+	require.True(t, pages.Next())
+	pages.Close()
+
+	assert.False(t, pages.Next())
+	assert.NoError(t, pages.Err())
+	assert.Equal(t, 2, server.count())
+}
+
+func TestClient_QueryPagesContext_RejectsWithRows(t *testing.T) {
+	client, err := NewClient(&Config{Host: "example.com", Username: "admin", Password: "password", Timeout: 1})
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT NodeID FROM Orion.Nodes WITH ROWS 1 TO 10",
+		"SELECT NodeID FROM Orion.Nodes WITH TOTALROWS",
+		"SELECT NodeID FROM Orion.Nodes WITH TOTALROWS WITH ROWS 1 TO 10",
+		"SELECT TOP 10 NodeID FROM Orion.Nodes ORDER BY NodeID",
+	} {
+		_, err = client.QueryPagesContext(context.Background(), query, nil, PageOptions{})
+		assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation}, query)
+	}
+
+	// A literal mentioning the clauses is not a clause
+	_, err = client.QueryPagesContext(context.Background(), "SELECT NodeID FROM Orion.Nodes WHERE Caption = 'with rows'", nil, PageOptions{})
+	assert.NoError(t, err)
+}
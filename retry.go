@@ -0,0 +1,188 @@
+package gosolar
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy decides whether a failed attempt should be retried and how
+// long to wait before the next one. The client never calls it more than
+// Config.MaxRetries times for a single request.
+type RetryPolicy interface {
+	ShouldRetry(ctx context.Context, info RetryInfo) (retry bool, delay time.Duration)
+}
+
+// RetryInfo describes a failed attempt for a RetryPolicy
+type RetryInfo struct {
+	// Attempt is the 1-based number of the attempt that just failed
+	Attempt int
+
+	// Method and Endpoint identify the request
+	Method   string
+	Endpoint string
+
+	// Idempotent reports whether the request is safe to send again, either
+	// because of what it does or because the caller opted in with WithIdempotent
+	Idempotent bool
+
+	// Response is the HTTP response, or nil if the request failed in transport.
+	// Its body has already been read; the client closes it.
+	Response *http.Response
+
+	// Err is the transport error, or nil if a response was received
+	Err error
+
+	// ErrorType is the classification the error would be returned with
+	ErrorType ErrorType
+}
+
+// DefaultRetryPolicy retries transport errors and the transient statuses a
+// SWIS server or its IIS front end return while recycling (408, 429, 502,
+// 503 and 504). It never retries non-idempotent requests such as Create and
+// Invoke unless the caller marked the call with WithIdempotent. Waits back
+// off exponentially from BaseDelay with jitter, capped at MaxDelay; a
+// Retry-After header on the response takes precedence, still capped at MaxDelay.
+type DefaultRetryPolicy struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// ShouldRetry implements RetryPolicy
+func (p *DefaultRetryPolicy) ShouldRetry(ctx context.Context, info RetryInfo) (bool, time.Duration) {
+	if !info.Idempotent {
+		return false, 0
+	}
+
+	if info.Response == nil {
+		if info.Err == nil {
+			return false, 0
+		}
+		return true, backoff(info.Attempt, p.BaseDelay, p.MaxDelay)
+	}
+
+	switch info.Response.StatusCode {
+	case http.StatusRequestTimeout,
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+	default:
+		return false, 0
+	}
+
+	if wait, ok := parseRetryAfter(info.Response.Header.Get("Retry-After"), time.Now()); ok {
+		if p.MaxDelay > 0 && wait > p.MaxDelay {
+			wait = p.MaxDelay
+		}
+		return true, wait
+	}
+
+	return true, backoff(info.Attempt, p.BaseDelay, p.MaxDelay)
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent returns a context that marks requests made with it as safe
+// to retry, so the default retry policy will also retry Create, Invoke and
+// other calls it would otherwise send only once.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent reports whether a request can safely be sent more than once.
+// Queries are POSTs but only read, and updates set absolute values, so both
+// are treated as idempotent; creating entities and invoking verbs are not.
+func isIdempotent(ctx context.Context, method, endpoint string) bool {
+	if v, ok := ctx.Value(idempotentKey{}).(bool); ok && v {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	case http.MethodPost:
+		return !strings.HasPrefix(endpoint, "Create/") && !strings.HasPrefix(endpoint, "Invoke/")
+	}
+	return false
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as
+// an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		wait := t.Sub(now)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+
+	return 0, false
+}
+
+// backoff returns the delay to wait before the given retry attempt (1-based).
+// The delay doubles with every attempt starting at base, is capped at max, and
+// has "equal jitter" applied: half of the delay is fixed and the other half is
+// random, so concurrent clients don't retry in lockstep.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	if base <= 0 || attempt <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if max > 0 && delay >= max {
+			delay = max
+			break
+		}
+		// Guard against overflow for very large attempt counts
+		if delay <= 0 {
+			delay = max
+			break
+		}
+	}
+	if max > 0 && delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// sleepContext waits for d or until ctx is done, whichever comes first. It
+// returns ctx.Err() if the context ended the wait early.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
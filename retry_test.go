@@ -0,0 +1,154 @@
+package gosolar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := time.Second
+
+	assert.Equal(t, time.Duration(0), backoff(0, base, max))
+	assert.Equal(t, time.Duration(0), backoff(1, 0, max))
+
+	tests := []struct {
+		attempt int
+		ceiling time.Duration
+	}{
+		{attempt: 1, ceiling: 100 * time.Millisecond},
+		{attempt: 2, ceiling: 200 * time.Millisecond},
+		{attempt: 3, ceiling: 400 * time.Millisecond},
+		{attempt: 4, ceiling: 800 * time.Millisecond},
+		{attempt: 5, ceiling: time.Second},
+		{attempt: 100, ceiling: time.Second},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			d := backoff(tt.attempt, base, max)
+			assert.GreaterOrEqual(t, d, tt.ceiling/2, "attempt %d", tt.attempt)
+			assert.LessOrEqual(t, d, tt.ceiling, "attempt %d", tt.attempt)
+		}
+	}
+}
+
+func TestSleepContext(t *testing.T) {
+	assert.NoError(t, sleepContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	assert.ErrorIs(t, sleepContext(ctx, time.Minute), context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	policy := &DefaultRetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+	response := func(status int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name      string
+		info      RetryInfo
+		wantRetry bool
+		wantDelay time.Duration
+	}{
+		{
+			name:      "transport error",
+			info:      RetryInfo{Attempt: 1, Idempotent: true, Err: errors.New("connection reset")},
+			wantRetry: true,
+		},
+		{
+			name: "transport error on non-idempotent request",
+			info: RetryInfo{Attempt: 1, Err: errors.New("connection reset")},
+		},
+		{
+			name:      "service unavailable",
+			info:      RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusServiceUnavailable, "")},
+			wantRetry: true,
+		},
+		{
+			name:      "bad gateway",
+			info:      RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusBadGateway, "")},
+			wantRetry: true,
+		},
+		{
+			name:      "too many requests with retry-after",
+			info:      RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusTooManyRequests, "2")},
+			wantRetry: true,
+			wantDelay: 2 * time.Second,
+		},
+		{
+			name:      "retry-after capped at max delay",
+			info:      RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusServiceUnavailable, "120")},
+			wantRetry: true,
+			wantDelay: 5 * time.Second,
+		},
+		{
+			name: "unauthorized",
+			info: RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusUnauthorized, "")},
+		},
+		{
+			name: "bad request",
+			info: RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusBadRequest, "")},
+		},
+		{
+			name: "internal server error",
+			info: RetryInfo{Attempt: 1, Idempotent: true, Response: response(http.StatusInternalServerError, "")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retry, delay := policy.ShouldRetry(context.Background(), tt.info)
+			assert.Equal(t, tt.wantRetry, retry)
+			if tt.wantDelay > 0 {
+				assert.Equal(t, tt.wantDelay, delay)
+			}
+		})
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+
+	assert.True(t, isIdempotent(ctx, http.MethodGet, "swis://host/Orion/Orion.Nodes/NodeID=1"))
+	assert.True(t, isIdempotent(ctx, http.MethodDelete, "swis://host/Orion/Orion.Nodes/NodeID=1"))
+	assert.True(t, isIdempotent(ctx, http.MethodPost, "Query"))
+	assert.True(t, isIdempotent(ctx, http.MethodPost, "BulkUpdate"))
+	assert.False(t, isIdempotent(ctx, http.MethodPost, "Create/Orion.Nodes"))
+	assert.False(t, isIdempotent(ctx, http.MethodPost, "Invoke/Orion.Nodes/PollNow"))
+
+	assert.True(t, isIdempotent(WithIdempotent(ctx), http.MethodPost, "Invoke/Orion.Nodes/PollNow"))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	d, ok := parseRetryAfter("30", now)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Second, d)
+
+	d, ok = parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now)
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	_, ok = parseRetryAfter("", now)
+	assert.False(t, ok)
+
+	_, ok = parseRetryAfter("soon", now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,112 @@
+package gosolar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Column is a single named value in a result row
+type Column struct {
+	Name  string
+	Value json.RawMessage
+}
+
+// OrderedRow is a result row that keeps the columns in the order SWIS
+// returned them, which is the order of the SELECT list. Decoding a row into a
+// map loses that order.
+type OrderedRow []Column
+
+// UnmarshalJSON decodes a JSON object into columns, preserving key order
+func (r *OrderedRow) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return fmt.Errorf("gosolar: row is not a JSON object: %s", data)
+	}
+
+	row := OrderedRow{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		name, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("gosolar: unexpected token %v in row", tok)
+		}
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		row = append(row, Column{Name: name, Value: value})
+	}
+
+	*r = row
+	return nil
+}
+
+// MarshalJSON encodes the row as a JSON object in column order
+func (r OrderedRow) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, col := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(col.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		if len(col.Value) == 0 {
+			buf.WriteString("null")
+		} else {
+			buf.Write(col.Value)
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Names returns the column names in order
+func (r OrderedRow) Names() []string {
+	names := make([]string, len(r))
+	for i, col := range r {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// Get returns the raw value of the named column
+func (r OrderedRow) Get(name string) (json.RawMessage, bool) {
+	for _, col := range r {
+		if col.Name == name {
+			return col.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Value decodes the column at index i into a generic Go value, the same way
+// encoding/json decodes into interface{}
+func (r OrderedRow) Value(i int) (interface{}, error) {
+	if i < 0 || i >= len(r) {
+		return nil, NewError(ErrorTypeValidation, "row_value", fmt.Sprintf("column %d out of range, row has %d columns", i, len(r)))
+	}
+
+	var v interface{}
+	if len(r[i].Value) == 0 {
+		return nil, nil
+	}
+	if err := json.Unmarshal(r[i].Value, &v); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "row_value", "failed to unmarshal column value")
+	}
+	return v, nil
+}
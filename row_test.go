@@ -0,0 +1,41 @@
+package gosolar
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedRow_JSON(t *testing.T) {
+	var rows []OrderedRow
+	require.NoError(t, json.Unmarshal([]byte(`[{"Zeta":1,"Alpha":"a","Mid":null,"Nested":{"x":[1,2]}}]`), &rows))
+	require.Len(t, rows, 1)
+
+	row := rows[0]
+	assert.Equal(t, []string{"Zeta", "Alpha", "Mid", "Nested"}, row.Names())
+
+	v, err := row.Value(0)
+	require.NoError(t, err)
+	assert.Equal(t, float64(1), v)
+
+	raw, ok := row.Get("Nested")
+	require.True(t, ok)
+	assert.JSONEq(t, `{"x":[1,2]}`, string(raw))
+
+	_, ok = row.Get("Missing")
+	assert.False(t, ok)
+
+	_, err = row.Value(4)
+	assert.Error(t, err)
+
+	data, err := json.Marshal(row)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Zeta":1,"Alpha":"a","Mid":null,"Nested":{"x":[1,2]}}`, string(data))
+}
+
+func TestOrderedRow_NotObject(t *testing.T) {
+	var row OrderedRow
+	assert.Error(t, json.Unmarshal([]byte(`[1,2]`), &row))
+}
@@ -0,0 +1,210 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Rows is a streaming cursor over the results of a SWQL query. Rows are
+// decoded one at a time straight from the response body, so memory use stays
+// flat no matter how many rows the query returns. Always Close a Rows.
+//
+//	rows, err := client.QueryIterContext(ctx, query, nil)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//
+//	for rows.Next() {
+//		var node gosolar.CommonNode
+//		if err := rows.Scan(&node); err != nil {
+//			return err
+//		}
+//	}
+//	return rows.Err()
+type Rows struct {
+	body    io.ReadCloser
+	dec     *json.Decoder
+	current json.RawMessage
+	count   int
+	started bool
+	done    bool
+	err     error
+	loc     *time.Location
+}
+
+// QueryIter executes a SWQL query and returns a streaming cursor over its rows
+func (c *Client) QueryIter(query string, parameters interface{}) (*Rows, error) {
+	return c.QueryIterContext(context.Background(), query, parameters)
+}
+
+// QueryIterContext executes a SWQL query with context and returns a streaming
+// cursor over its rows. Retries only cover the request itself; once rows
+// start arriving, a failure is reported by Rows.Err. Config.Timeout bounds
+// the wait for the response to start, not reading it, so a long result set
+// is limited only by ctx.
+func (c *Client) QueryIterContext(ctx context.Context, query string, parameters interface{}) (*Rows, error) {
+	if err := c.validateQuery(query, parameters); err != nil {
+		return nil, err
+	}
+
+	req := struct {
+		Query      string      `json:"query"`
+		Parameters interface{} `json:"parameters"`
+	}{
+		Query:      query,
+		Parameters: c.localParams(parameters),
+	}
+
+	resp, err := c.send(ctx, http.MethodPost, "Query", &req, true)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := newRows(resp.Body)
+	rows.loc = c.location()
+	return rows, nil
+}
+
+func newRows(body io.ReadCloser) *Rows {
+	return &Rows{
+		body: body,
+		dec:  json.NewDecoder(body),
+	}
+}
+
+// Next advances to the next row, returning false when there are no more rows
+// or an error occurred. Check Err to tell the two apart.
+func (r *Rows) Next() bool {
+	if r.done {
+		return false
+	}
+
+	if !r.started {
+		r.started = true
+		if err := r.seekResults(); err != nil {
+			return r.fail(err)
+		}
+		if r.done {
+			return false
+		}
+	}
+
+	if !r.dec.More() {
+		// Consume the closing bracket of the results array
+		if _, err := r.dec.Token(); err != nil {
+			return r.fail(WrapError(err, ErrorTypeNetwork, "query_iter", "failed to read query response"))
+		}
+		r.done = true
+		r.current = nil
+		return false
+	}
+
+	var row json.RawMessage
+	if err := r.dec.Decode(&row); err != nil {
+		return r.fail(WrapError(err, ErrorTypeNetwork, "query_iter", "failed to decode row"))
+	}
+
+	r.current = row
+	r.count++
+	return true
+}
+
+// seekResults positions the decoder at the first element of the "results"
+// array, skipping any other top-level fields SWIS includes in the response
+func (r *Rows) seekResults() *Error {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "query_iter", "failed to parse query response")
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return NewError(ErrorTypeInternal, "query_iter", "query response is not a JSON object")
+	}
+
+	for r.dec.More() {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return WrapError(err, ErrorTypeInternal, "query_iter", "failed to parse query response")
+		}
+
+		key, _ := tok.(string)
+		if key != "results" {
+			var skip json.RawMessage
+			if err := r.dec.Decode(&skip); err != nil {
+				return WrapError(err, ErrorTypeInternal, "query_iter", "failed to parse query response")
+			}
+			continue
+		}
+
+		tok, err = r.dec.Token()
+		if err != nil {
+			return WrapError(err, ErrorTypeInternal, "query_iter", "failed to parse query response")
+		}
+		if tok == nil {
+			r.done = true
+			return nil
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return NewError(ErrorTypeInternal, "query_iter", "query results are not a JSON array")
+		}
+		return nil
+	}
+
+	// No results field at all is treated as an empty result set
+	r.done = true
+	return nil
+}
+
+func (r *Rows) fail(err *Error) bool {
+	r.err = err
+	r.done = true
+	r.current = nil
+	return false
+}
+
+// Scan decodes the current row into dest. A struct is decoded like the
+// package-level Scan does, so swis tags apply.
+func (r *Rows) Scan(dest interface{}) error {
+	if r.current == nil {
+		return NewError(ErrorTypeValidation, "scan", "Scan called without a current row")
+	}
+	if err := decodeRows(r.current, dest, r.loc); err != nil {
+		return WrapError(err, ErrorTypeInternal, "scan", "failed to unmarshal row")
+	}
+	return nil
+}
+
+// Raw returns the JSON of the current row. It is only valid until the next
+// call to Next.
+func (r *Rows) Raw() json.RawMessage {
+	return r.current
+}
+
+// Count returns the number of rows read so far
+func (r *Rows) Count() int {
+	return r.count
+}
+
+// Err returns the error, if any, that ended iteration
+func (r *Rows) Err() error {
+	if r.err == nil {
+		return nil
+	}
+	return r.err
+}
+
+// Close releases the underlying response body. It is safe to call more than
+// once and before all rows have been read.
+func (r *Rows) Close() error {
+	r.done = true
+	r.current = nil
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body = nil
+	return err
+}
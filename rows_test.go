@@ -0,0 +1,208 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_QueryIterContext(t *testing.T) {
+	const total = 5000
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/SolarWinds/InformationService/v3/Json/Query", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"totalRows":5000,"results":[`)
+		for i := 1; i <= total; i++ {
+			if i > 1 {
+				_, _ = io.WriteString(w, ",")
+			}
+			_, _ = fmt.Fprintf(w, `{"NodeID":%d,"Caption":"node%d"}`, i, i)
+		}
+		_, _ = io.WriteString(w, `]}`)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	rows, err := client.QueryIterContext(context.Background(), "SELECT NodeID, Caption FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		var row struct {
+			NodeID  int
+			Caption string
+		}
+		require.NoError(t, rows.Scan(&row))
+		n++
+		assert.Equal(t, n, row.NodeID)
+		assert.Equal(t, fmt.Sprintf("node%d", n), row.Caption)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, total, n)
+	assert.Equal(t, total, rows.Count())
+	assert.False(t, rows.Next())
+}
+
+func TestClient_QueryIterContext_SlowBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		_, _ = io.WriteString(w, `{"results":[`)
+		flusher.Flush()
+		for i := 1; i <= 4; i++ {
+			time.Sleep(40 * time.Millisecond)
+			if i > 1 {
+				_, _ = io.WriteString(w, ",")
+			}
+			_, _ = fmt.Fprintf(w, `{"NodeID":%d}`, i)
+			flusher.Flush()
+		}
+		_, _ = io.WriteString(w, `]}`)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.Timeout = 50 * time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	// The body takes well over Timeout to arrive
+	rows, err := client.QueryIterContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, 4, n)
+}
+
+func TestClient_QueryIterContext_HeaderTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		_, _ = io.WriteString(w, `{"results":[{"NodeID":1}]}`)
+	}))
+	defer server.Close()
+
+	config := DefaultConfig()
+	config.Host = server.URL[7:]
+	config.PlainHTTP = true
+	config.Username = "admin"
+	config.Password = "password"
+	config.Timeout = 50 * time.Millisecond
+	config.RetryDelay = time.Millisecond
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+
+	rows, err := client.QueryIterContext(context.Background(), "SELECT NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	defer rows.Close()
+	assert.True(t, rows.Next())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "results only",
+			body: `{"results":[{"a":1},{"a":2}]}`,
+			want: []string{`{"a":1}`, `{"a":2}`},
+		},
+		{
+			name: "results after other fields",
+			body: `{"meta":{"x":[1,2,3]},"results":[{"a":1}]}`,
+			want: []string{`{"a":1}`},
+		},
+		{
+			name: "empty results",
+			body: `{"results":[]}`,
+		},
+		{
+			name: "null results",
+			body: `{"results":null}`,
+		},
+		{
+			name: "missing results",
+			body: `{}`,
+		},
+		{
+			name:    "not an object",
+			body:    `[{"a":1}]`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated stream",
+			body:    `{"results":[{"a":1},{"a":`,
+			want:    []string{`{"a":1}`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := newRows(io.NopCloser(strings.NewReader(tt.body)))
+			defer rows.Close()
+
+			var got []string
+			for rows.Next() {
+				got = append(got, string(rows.Raw()))
+			}
+
+			assert.Equal(t, tt.want, got)
+			if tt.wantErr {
+				assert.Error(t, rows.Err())
+			} else {
+				assert.NoError(t, rows.Err())
+			}
+		})
+	}
+}
+
+func TestRows_ScanWithoutRow(t *testing.T) {
+	rows := newRows(io.NopCloser(strings.NewReader(`{"results":[]}`)))
+	defer rows.Close()
+
+	var dest map[string]interface{}
+	assert.Error(t, rows.Scan(&dest))
+	assert.False(t, rows.Next())
+	assert.Error(t, rows.Scan(&dest))
+}
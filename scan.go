@@ -0,0 +1,403 @@
+package gosolar
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scanner maps SWIS result columns onto struct fields. Column names match
+// case-insensitively against, in order of preference:
+//
+//   - the name in a `swis:"Column"` tag
+//   - the name in a `json:"column"` tag
+//   - the field name
+//
+// A `swis:"-"` tag skips the field and `swis:"Column,optional"` exempts it
+// from the missing-column check in strict mode. Untagged embedded structs are
+// flattened, so a struct embedding a node and an interface type can receive
+// the columns of a join. A struct field with a swis tag receives the columns
+// prefixed by the tag and a dot, which is how SWIS names navigation
+// properties such as `Node.Caption`.
+//
+// Fields may be pointers, which stay nil for null values, or implement
+// sql.Scanner (sql.NullString, sql.NullInt64, sql.NullTime, ...). Values are
+// converted between JSON numbers, strings and booleans where that's lossless,
+// and time.Time and Time accept the date formats SWIS returns.
+type Scanner struct {
+	// Strict makes scanning fail if a column has no matching field or a
+	// field has no matching column
+	Strict bool
+
+	// Location is the time zone DateTime values without an offset are read
+	// in (default: UTC)
+	Location *time.Location
+}
+
+// Scan decodes SWIS query results into dest using a non-strict Scanner. data
+// may be a JSON array of rows or a single row object; dest must be a pointer
+// to a slice of structs or to a struct respectively.
+func Scan(data []byte, dest interface{}) error {
+	return (&Scanner{}).Scan(data, dest)
+}
+
+// ScanStrict is like Scan but fails on unmapped or missing columns
+func ScanStrict(data []byte, dest interface{}) error {
+	return (&Scanner{Strict: true}).Scan(data, dest)
+}
+
+// Scan decodes SWIS query results into dest. See the package-level Scan.
+func (s *Scanner) Scan(data []byte, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return NewError(ErrorTypeValidation, "scan", "destination must be a non-nil pointer")
+	}
+
+	elem := rv.Elem()
+	switch {
+	case elem.Kind() == reflect.Slice && isStructRow(elem.Type().Elem()):
+		var rows []OrderedRow
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return WrapError(err, ErrorTypeInternal, "scan", "failed to unmarshal rows")
+		}
+
+		out := reflect.MakeSlice(elem.Type(), len(rows), len(rows))
+		for i, row := range rows {
+			if err := s.ScanRow(row, out.Index(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		elem.Set(out)
+		return nil
+
+	case isStructRow(elem.Type()):
+		var row OrderedRow
+		if err := json.Unmarshal(data, &row); err != nil {
+			return WrapError(err, ErrorTypeInternal, "scan", "failed to unmarshal row")
+		}
+		return s.ScanRow(row, dest)
+	}
+
+	return NewError(ErrorTypeValidation, "scan", fmt.Sprintf("cannot scan into %s", rv.Type()))
+}
+
+// scansRows reports whether dest points to a struct or a slice of structs,
+// which are decoded column by column like Scan does rather than by
+// encoding/json, so swis tags apply
+func scansRows(dest interface{}) bool {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return false
+	}
+	t = t.Elem()
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return false
+	}
+	return isStructRow(t) || (t.Kind() == reflect.Slice && isStructRow(t.Elem()))
+}
+
+// decodeRows decodes query results into dest with a Scanner reading times in
+// loc if scansRows says so and with encoding/json otherwise
+func decodeRows(data []byte, dest interface{}, loc *time.Location) error {
+	if scansRows(dest) {
+		return (&Scanner{Location: loc}).Scan(data, dest)
+	}
+	return json.Unmarshal(data, dest)
+}
+
+// ScanRow decodes a single row into dest, which must be a pointer to a
+// struct (or to a pointer to a struct)
+func (s *Scanner) ScanRow(row OrderedRow, dest interface{}) error {
+	rv := reflect.ValueOf(dest)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return NewError(ErrorTypeValidation, "scan", "destination must be a non-nil pointer")
+	}
+
+	target := rv.Elem()
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return NewError(ErrorTypeValidation, "scan", fmt.Sprintf("cannot scan into %s", rv.Type()))
+	}
+
+	fields := fieldsOf(target.Type())
+	seen := make(map[string]bool, len(row))
+
+	var unmapped []string
+	for _, col := range row {
+		key := strings.ToLower(col.Name)
+		f, ok := fields.byName[key]
+		if !ok {
+			unmapped = append(unmapped, col.Name)
+			continue
+		}
+		seen[key] = true
+
+		if err := setField(fieldByIndex(target, f.index), col.Value, s.Location); err != nil {
+			return WrapError(err, ErrorTypeInternal, "scan", fmt.Sprintf("column %s", col.Name))
+		}
+	}
+
+	if !s.Strict {
+		return nil
+	}
+
+	var missing []string
+	for key, f := range fields.byName {
+		if !seen[key] && !f.optional {
+			missing = append(missing, f.column)
+		}
+	}
+
+	if len(unmapped) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	var parts []string
+	if len(unmapped) > 0 {
+		parts = append(parts, fmt.Sprintf("unmapped columns: %s", strings.Join(unmapped, ", ")))
+	}
+	if len(missing) > 0 {
+		parts = append(parts, fmt.Sprintf("missing columns: %s", strings.Join(missing, ", ")))
+	}
+	return NewError(ErrorTypeValidation, "scan", fmt.Sprintf("%s: %s", target.Type(), strings.Join(parts, "; ")))
+}
+
+// scanField describes where a column is stored in a struct
+type scanField struct {
+	column   string
+	index    []int
+	depth    int
+	optional bool
+}
+
+type scanFields struct {
+	byName map[string]scanField
+}
+
+var scanFieldCache sync.Map // map[reflect.Type]*scanFields
+
+var (
+	timeType        = reflect.TypeOf(time.Time{})
+	scannerType     = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// fieldsOf returns the column mapping for a struct type
+func fieldsOf(t reflect.Type) *scanFields {
+	if cached, ok := scanFieldCache.Load(t); ok {
+		return cached.(*scanFields)
+	}
+
+	fields := &scanFields{byName: make(map[string]scanField)}
+	collectFields(t, "", nil, 0, fields, map[reflect.Type]bool{})
+
+	cached, _ := scanFieldCache.LoadOrStore(t, fields)
+	return cached.(*scanFields)
+}
+
+func collectFields(t reflect.Type, prefix string, index []int, depth int, fields *scanFields, visiting map[reflect.Type]bool) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() && (!sf.Anonymous || sf.Type.Kind() == reflect.Ptr) {
+			continue
+		}
+
+		name, optional, tagged, skip := fieldName(sf)
+		if skip {
+			continue
+		}
+
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		if st := structType(sf.Type); st != nil && !isLeafType(sf.Type) {
+			switch {
+			case sf.Anonymous && !tagged:
+				collectFields(st, prefix, idx, depth+1, fields, visiting)
+				continue
+			case tagged:
+				collectFields(st, prefix+name+".", idx, depth+1, fields, visiting)
+				continue
+			}
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+
+		column := prefix + name
+		key := strings.ToLower(column)
+		if existing, ok := fields.byName[key]; ok && existing.depth <= depth {
+			continue
+		}
+		fields.byName[key] = scanField{
+			column:   column,
+			index:    idx,
+			depth:    depth,
+			optional: optional,
+		}
+	}
+}
+
+// fieldName returns the column name for a struct field and its tag options
+func fieldName(sf reflect.StructField) (name string, optional, tagged, skip bool) {
+	if tag, ok := sf.Tag.Lookup("swis"); ok {
+		if tag == "-" {
+			return "", false, false, true
+		}
+		parts := strings.Split(tag, ",")
+		for _, opt := range parts[1:] {
+			if opt == "optional" {
+				optional = true
+			}
+		}
+		if parts[0] != "" {
+			return parts[0], optional, true, false
+		}
+		return sf.Name, optional, false, false
+	}
+
+	if tag, ok := sf.Tag.Lookup("json"); ok {
+		if tag == "-" {
+			return "", false, false, true
+		}
+		if n := strings.Split(tag, ",")[0]; n != "" {
+			return n, false, false, false
+		}
+	}
+
+	return sf.Name, false, false, false
+}
+
+// isStructRow reports whether t can receive a whole row
+func isStructRow(t reflect.Type) bool {
+	return structType(t) != nil && !isLeafType(t)
+}
+
+// structType returns the struct type t is or points to, or nil
+func structType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct {
+		return t
+	}
+	return nil
+}
+
+// isLeafType reports whether a struct type holds a single value rather than
+// a group of columns
+func isLeafType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return true
+	}
+	pt := reflect.PointerTo(t)
+	return pt.Implements(scannerType) || pt.Implements(unmarshalerType)
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but allocates nil
+// embedded or nested struct pointers on the way
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// setField stores a raw JSON column value in a struct field, reading times
+// without an offset in loc
+func setField(field reflect.Value, raw json.RawMessage, loc *time.Location) error {
+	isNull := len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+
+	if field.Kind() == reflect.Ptr && !field.Type().Implements(scannerType) {
+		if isNull {
+			field.Set(reflect.Zero(field.Type()))
+			return nil
+		}
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+
+	if field.CanAddr() && field.Addr().Type().Implements(scannerType) {
+		return scanSQL(field.Addr().Interface().(sql.Scanner), raw, isNull, loc)
+	}
+
+	if isNull {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+
+	return convertValue(raw, field.Addr().Interface(), loc)
+}
+
+// scanSQL feeds a JSON value to an sql.Scanner as the driver value types
+// database/sql would use. Strings that look like SWIS dates are offered as
+// time.Time if the scanner rejects them as strings.
+func scanSQL(s sql.Scanner, raw json.RawMessage, isNull bool, loc *time.Location) error {
+	if isNull {
+		return s.Scan(nil)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return s.Scan(n)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return s.Scan(f)
+	case string:
+		err := s.Scan(v)
+		if err == nil {
+			return nil
+		}
+		if t, terr := ParseTime(v, loc); terr == nil {
+			return s.Scan(t)
+		}
+		return err
+	case bool:
+		return s.Scan(v)
+	default:
+		return s.Scan(string(raw))
+	}
+}
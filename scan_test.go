@@ -0,0 +1,165 @@
+package gosolar
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan_Tags(t *testing.T) {
+	type node struct {
+		ID       int    `swis:"NodeID"`
+		Name     string `swis:"NodeName"`
+		Vendor   string
+		IP       string `json:"ipaddress"`
+		Ignored  string `swis:"-"`
+		internal string
+	}
+
+	data := []byte(`[
+		{"NodeID":1,"NodeName":"core-1","VENDOR":"Cisco","IPAddress":"10.0.0.1","Ignored":"x","internal":"y"},
+		{"nodeid":2,"nodename":"core-2","vendor":"Juniper","ipaddress":"10.0.0.2"}
+	]`)
+
+	var nodes []node
+	require.NoError(t, Scan(data, &nodes))
+	require.Len(t, nodes, 2)
+
+	assert.Equal(t, node{ID: 1, Name: "core-1", Vendor: "Cisco", IP: "10.0.0.1"}, nodes[0])
+	assert.Equal(t, node{ID: 2, Name: "core-2", Vendor: "Juniper", IP: "10.0.0.2"}, nodes[1])
+}
+
+func TestScan_PredefinedTypes(t *testing.T) {
+	var nodes []CommonNode
+	require.NoError(t, Scan([]byte(`[{"NodeID":5,"Caption":"edge","LastBoot":"2024-05-01T12:34:56.123","ResponseTime":"12"}]`), &nodes))
+	require.Len(t, nodes, 1)
+
+	assert.Equal(t, 5, nodes[0].NodeID)
+	assert.Equal(t, "edge", nodes[0].Caption)
+	assert.Equal(t, float64(12), nodes[0].ResponseTime)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 34, 56, 123000000, time.UTC), nodes[0].LastBoot.Time)
+}
+
+func TestScan_EmbeddedAndNavigation(t *testing.T) {
+	type nodeInfo struct {
+		Caption string
+		Vendor  string
+	}
+	type ifaceBase struct {
+		InterfaceID int
+		Name        string
+	}
+	type ifaceRow struct {
+		ifaceBase
+		*Volume `swis:"-"`
+		Node    nodeInfo `swis:"Node"`
+		Speed   float64  `swis:"Speed"`
+	}
+
+	data := []byte(`{"InterfaceID":3,"Name":"Gi0/1","Node.Caption":"core-1","Node.Vendor":"Cisco","Speed":1e9}`)
+
+	var row ifaceRow
+	require.NoError(t, ScanStrict(data, &row))
+
+	assert.Equal(t, 3, row.InterfaceID)
+	assert.Equal(t, "Gi0/1", row.Name)
+	assert.Equal(t, "core-1", row.Node.Caption)
+	assert.Equal(t, "Cisco", row.Node.Vendor)
+	assert.Equal(t, float64(1e9), row.Speed)
+}
+
+func TestScan_EmbeddedPointer(t *testing.T) {
+	type Detail struct {
+		Location string
+	}
+	type row struct {
+		NodeID int
+		*Detail
+	}
+
+	var rows []row
+	require.NoError(t, Scan([]byte(`[{"NodeID":1,"Location":"DC1"},{"NodeID":2}]`), &rows))
+	require.Len(t, rows, 2)
+
+	assert.Equal(t, 1, rows[0].NodeID)
+	require.NotNil(t, rows[0].Detail)
+	assert.Equal(t, "DC1", rows[0].Location)
+	assert.Nil(t, rows[1].Detail)
+}
+
+func TestScan_Nullable(t *testing.T) {
+	type row struct {
+		Caption   sql.NullString
+		Unmanaged sql.NullBool
+		NodeID    sql.NullInt64
+		Load      sql.NullFloat64
+		LastBoot  sql.NullTime
+		Contact   *string
+		Location  *string
+	}
+
+	data := []byte(`[
+		{"Caption":"a","Unmanaged":true,"NodeID":7,"Load":0.5,"LastBoot":"2024-05-01T12:34:56","Contact":"noc","Location":null},
+		{"Caption":null,"Unmanaged":null,"NodeID":null,"Load":null,"LastBoot":null,"Contact":null,"Location":null}
+	]`)
+
+	var rows []row
+	require.NoError(t, Scan(data, &rows))
+	require.Len(t, rows, 2)
+
+	first := rows[0]
+	assert.Equal(t, sql.NullString{String: "a", Valid: true}, first.Caption)
+	assert.Equal(t, sql.NullBool{Bool: true, Valid: true}, first.Unmanaged)
+	assert.Equal(t, sql.NullInt64{Int64: 7, Valid: true}, first.NodeID)
+	assert.Equal(t, sql.NullFloat64{Float64: 0.5, Valid: true}, first.Load)
+	assert.Equal(t, sql.NullTime{Time: time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC), Valid: true}, first.LastBoot)
+	require.NotNil(t, first.Contact)
+	assert.Equal(t, "noc", *first.Contact)
+	assert.Nil(t, first.Location)
+
+	second := rows[1]
+	assert.False(t, second.Caption.Valid)
+	assert.False(t, second.Unmanaged.Valid)
+	assert.False(t, second.NodeID.Valid)
+	assert.False(t, second.Load.Valid)
+	assert.False(t, second.LastBoot.Valid)
+	assert.Nil(t, second.Contact)
+}
+
+func TestScan_Strict(t *testing.T) {
+	type row struct {
+		NodeID   int
+		Caption  string
+		Location string `swis:"Location,optional"`
+	}
+
+	var r row
+	require.NoError(t, ScanStrict([]byte(`{"NodeID":1,"Caption":"a"}`), &r))
+
+	err := ScanStrict([]byte(`{"NodeID":1,"Extra":"x"}`), &r)
+	require.Error(t, err)
+
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeValidation, swErr.Type)
+	assert.Contains(t, swErr.Message, "unmapped columns: Extra")
+	assert.Contains(t, swErr.Message, "missing columns: Caption")
+
+	// Non-strict scanning ignores both
+	assert.NoError(t, Scan([]byte(`{"NodeID":1,"Extra":"x"}`), &r))
+}
+
+func TestScan_InvalidDestination(t *testing.T) {
+	var n int
+	assert.Error(t, Scan([]byte(`[]`), &n))
+
+	var rows []struct{ NodeID int }
+	assert.Error(t, Scan([]byte(`[]`), rows))
+
+	err := Scan([]byte(`[{"NodeID":"abc"}]`), &rows)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NodeID")
+}
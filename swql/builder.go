@@ -0,0 +1,262 @@
+package swql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Query is a SELECT statement under construction. Methods modify the query
+// and return it for chaining; a Query isn't safe for concurrent use.
+type Query struct {
+	distinct bool
+	columns  []Expr
+	entity   string
+	alias    string
+	joins    []join
+	where    []Condition
+	groupBy  []Expr
+	having   []Condition
+	orderBy  []order
+	limit    int
+	offset   int
+	errs     []error
+}
+
+type join struct {
+	kind   string
+	entity string
+	alias  string
+	on     Condition
+}
+
+type order struct {
+	expr Expr
+	desc bool
+}
+
+// Select starts a query selecting the given columns. Strings are column
+// paths ("n.Caption", "n.Interfaces.Name", "*" or "n.*"); pass an Expr such
+// as As, Count or Func for anything else.
+func Select(columns ...interface{}) *Query {
+	q := &Query{}
+	for _, c := range columns {
+		q.columns = append(q.columns, toExpr(c))
+	}
+	return q
+}
+
+// Distinct makes the query SELECT DISTINCT
+func (q *Query) Distinct() *Query {
+	q.distinct = true
+	return q
+}
+
+// From sets the entity to select from, with an optional alias
+func (q *Query) From(entity string, alias ...string) *Query {
+	q.entity = entity
+	if len(alias) > 0 {
+		q.alias = alias[0]
+	}
+	if len(alias) > 1 {
+		q.errs = append(q.errs, errors.New("swql: From takes at most one alias"))
+	}
+	return q
+}
+
+// Join adds an INNER JOIN
+func (q *Query) Join(entity, alias string, on Condition) *Query {
+	q.joins = append(q.joins, join{kind: "INNER JOIN", entity: entity, alias: alias, on: on})
+	return q
+}
+
+// LeftJoin adds a LEFT JOIN
+func (q *Query) LeftJoin(entity, alias string, on Condition) *Query {
+	q.joins = append(q.joins, join{kind: "LEFT JOIN", entity: entity, alias: alias, on: on})
+	return q
+}
+
+// Where adds conditions to the WHERE clause. All conditions, including
+// those from earlier calls, must hold. Nil conditions are skipped.
+func (q *Query) Where(conds ...Condition) *Query {
+	for _, c := range conds {
+		if c != nil {
+			q.where = append(q.where, c)
+		}
+	}
+	return q
+}
+
+// In is shorthand for Where(In(col, values...))
+func (q *Query) In(col string, values ...interface{}) *Query {
+	return q.Where(In(col, values...))
+}
+
+// GroupBy adds GROUP BY expressions
+func (q *Query) GroupBy(exprs ...interface{}) *Query {
+	for _, e := range exprs {
+		q.groupBy = append(q.groupBy, toExpr(e))
+	}
+	return q
+}
+
+// Having adds conditions to the HAVING clause
+func (q *Query) Having(conds ...Condition) *Query {
+	for _, c := range conds {
+		if c != nil {
+			q.having = append(q.having, c)
+		}
+	}
+	return q
+}
+
+// OrderBy adds ascending sort expressions
+func (q *Query) OrderBy(exprs ...interface{}) *Query {
+	for _, e := range exprs {
+		q.orderBy = append(q.orderBy, order{expr: toExpr(e)})
+	}
+	return q
+}
+
+// OrderByDesc adds descending sort expressions
+func (q *Query) OrderByDesc(exprs ...interface{}) *Query {
+	for _, e := range exprs {
+		q.orderBy = append(q.orderBy, order{expr: toExpr(e), desc: true})
+	}
+	return q
+}
+
+// Limit caps the number of rows returned. Without Offset it renders as
+// SELECT TOP n; with Offset it renders as WITH ROWS.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Offset skips the first n rows, rendering as WITH ROWS. Use it together
+// with OrderBy so the skipped rows are well defined.
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
+
+// Build renders the query and its bound parameters, ready to pass to
+// Client.QueryContext
+func (q *Query) Build() (string, Parameters, error) {
+	r := &renderer{params: Parameters{}}
+	r.errs = append(r.errs, q.errs...)
+
+	if len(q.columns) == 0 {
+		r.fail("no columns selected")
+	}
+	if q.entity == "" {
+		r.fail("no entity given to From")
+	}
+	if q.limit < 0 || q.offset < 0 {
+		r.fail("limit and offset cannot be negative")
+	}
+	if q.offset > 0 && q.limit == 0 {
+		r.fail("offset requires a limit")
+	}
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	if q.distinct {
+		b.WriteString("DISTINCT ")
+	}
+	if q.limit > 0 && q.offset == 0 {
+		fmt.Fprintf(&b, "TOP %d ", q.limit)
+	}
+
+	cols := make([]string, len(q.columns))
+	for i, c := range q.columns {
+		cols[i] = c.render(r)
+	}
+	b.WriteString(strings.Join(cols, ", "))
+
+	b.WriteString(" FROM ")
+	b.WriteString(quotePath(r, q.entity))
+	if q.alias != "" {
+		b.WriteString(" ")
+		b.WriteString(quoteIdent(r, q.alias))
+	}
+
+	for _, j := range q.joins {
+		if j.on == nil {
+			r.fail("join on %s has no ON condition", j.entity)
+			continue
+		}
+		b.WriteString(" ")
+		b.WriteString(j.kind)
+		b.WriteString(" ")
+		b.WriteString(quotePath(r, j.entity))
+		if j.alias != "" {
+			b.WriteString(" ")
+			b.WriteString(quoteIdent(r, j.alias))
+		}
+		b.WriteString(" ON ")
+		b.WriteString(j.on.render(r))
+	}
+
+	if len(q.where) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(renderConditions(r, q.where))
+	}
+
+	if len(q.groupBy) > 0 {
+		exprs := make([]string, len(q.groupBy))
+		for i, e := range q.groupBy {
+			exprs[i] = e.render(r)
+		}
+		b.WriteString(" GROUP BY ")
+		b.WriteString(strings.Join(exprs, ", "))
+	}
+
+	if len(q.having) > 0 {
+		if len(q.groupBy) == 0 {
+			r.fail("HAVING requires GROUP BY")
+		}
+		b.WriteString(" HAVING ")
+		b.WriteString(renderConditions(r, q.having))
+	}
+
+	if len(q.orderBy) > 0 {
+		exprs := make([]string, len(q.orderBy))
+		for i, o := range q.orderBy {
+			exprs[i] = o.expr.render(r)
+			if o.desc {
+				exprs[i] += " DESC"
+			}
+		}
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(exprs, ", "))
+	}
+
+	if q.offset > 0 {
+		fmt.Fprintf(&b, " WITH ROWS %d TO %d", q.offset+1, q.offset+q.limit)
+	}
+
+	if len(r.errs) > 0 {
+		return "", nil, errors.Join(r.errs...)
+	}
+	return b.String(), r.params, nil
+}
+
+// String renders the query text, or a description of the error if the query
+// is invalid. It is meant for logging; use Build to run a query.
+func (q *Query) String() string {
+	query, _, err := q.Build()
+	if err != nil {
+		return err.Error()
+	}
+	return query
+}
+
+// renderConditions ANDs conditions without wrapping a single condition in
+// parentheses
+func renderConditions(r *renderer, conds []Condition) string {
+	if len(conds) == 1 {
+		return conds[0].render(r)
+	}
+	return And(conds...).render(r)
+}
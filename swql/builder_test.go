@@ -0,0 +1,176 @@
+package swql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  *Query
+		want   string
+		params Parameters
+	}{
+		{
+			name:   "simple",
+			query:  Select("NodeID", "Caption").From("Orion.Nodes"),
+			want:   "SELECT NodeID, Caption FROM Orion.Nodes",
+			params: Parameters{},
+		},
+		{
+			name: "where, in, order and limit",
+			query: Select("n.NodeID", "n.Caption", "n.Interfaces.Name").
+				From("Orion.Nodes", "n").
+				Where(Eq("n.Vendor", "Cisco")).
+				In("n.Status", 1, 3).
+				OrderBy("n.Caption").
+				Limit(50),
+			want: "SELECT TOP 50 n.NodeID, n.Caption, n.Interfaces.Name FROM Orion.Nodes n " +
+				"WHERE (n.Vendor = @p0 AND n.Status IN (@p1, @p2)) ORDER BY n.Caption",
+			params: Parameters{"p0": "Cisco", "p1": 1, "p2": 3},
+		},
+		{
+			name: "injection attempt stays a parameter",
+			query: Select("Caption").From("Orion.Nodes").
+				Where(Eq("Caption", "x' OR 1=1 --")),
+			want:   "SELECT Caption FROM Orion.Nodes WHERE Caption = @p0",
+			params: Parameters{"p0": "x' OR 1=1 --"},
+		},
+		{
+			name:   "reserved and special identifiers",
+			query:  Select("n.Order", "Custom Field", "a]b").From("Orion.Nodes", "n"),
+			want:   "SELECT n.[Order], [Custom Field], [a]]b] FROM Orion.Nodes n",
+			params: Parameters{},
+		},
+		{
+			name:   "already bracketed",
+			query:  Select("n.[My.Prop]").From("Orion.NodesCustomProperties", "n"),
+			want:   "SELECT n.[My.Prop] FROM Orion.NodesCustomProperties n",
+			params: Parameters{},
+		},
+		{
+			name: "nulls, ranges and negation",
+			query: Select("*").From("Orion.Nodes").Where(
+				Eq("Location", nil),
+				Ne("Contact", nil),
+				Between("CPULoad", 50, 90),
+				Not(Like("Caption", "lab%")),
+			),
+			want: "SELECT * FROM Orion.Nodes WHERE (Location IS NULL AND Contact IS NOT NULL AND " +
+				"CPULoad BETWEEN @p0 AND @p1 AND NOT (Caption LIKE @p2))",
+			params: Parameters{"p0": 50, "p1": 90, "p2": "lab%"},
+		},
+		{
+			name: "or with nil conditions",
+			query: Select("NodeID").From("Orion.Nodes").
+				Where(Or(Gt("CPULoad", 90), nil, Ge("PercentMemoryUsed", 95))),
+			want:   "SELECT NodeID FROM Orion.Nodes WHERE (CPULoad > @p0 OR PercentMemoryUsed >= @p1)",
+			params: Parameters{"p0": 90, "p1": 95},
+		},
+		{
+			name:   "empty in",
+			query:  Select("NodeID").From("Orion.Nodes").In("NodeID").Where(NotIn("Status")),
+			want:   "SELECT NodeID FROM Orion.Nodes WHERE (1 = 0 AND 1 = 1)",
+			params: Parameters{},
+		},
+		{
+			name: "join",
+			query: Select("n.Caption", As("i.Name", "Interface")).
+				From("Orion.Nodes", "n").
+				LeftJoin("Orion.NPM.Interfaces", "i", ColEq("i.NodeID", "n.NodeID")).
+				Where(Lt("i.InPercentUtil", 10)),
+			want: "SELECT n.Caption, i.Name AS Interface FROM Orion.Nodes n " +
+				"LEFT JOIN Orion.NPM.Interfaces i ON i.NodeID = n.NodeID WHERE i.InPercentUtil < @p0",
+			params: Parameters{"p0": 10},
+		},
+		{
+			name: "group by and having",
+			query: Select("Vendor", As(Count("NodeID"), "Total")).
+				From("Orion.Nodes").
+				GroupBy("Vendor").
+				Having(Gt(Count("NodeID"), 5)).
+				OrderByDesc(Count("NodeID")),
+			want: "SELECT Vendor, COUNT(NodeID) AS Total FROM Orion.Nodes GROUP BY Vendor " +
+				"HAVING COUNT(NodeID) > @p0 ORDER BY COUNT(NodeID) DESC",
+			params: Parameters{"p0": 5},
+		},
+		{
+			name: "distinct, offset and function params",
+			query: Select("Caption").Distinct().From("Orion.Nodes").
+				Where(Gt("LastBoot", Func("ADDDAY", Param(-7), Func("GETUTCDATE")))).
+				OrderBy("Caption").
+				Limit(10).Offset(20),
+			want: "SELECT DISTINCT Caption FROM Orion.Nodes WHERE LastBoot > ADDDAY(@p0, GETUTCDATE()) " +
+				"ORDER BY Caption WITH ROWS 21 TO 30",
+			params: Parameters{"p0": -7},
+		},
+		{
+			name: "raw condition",
+			query: Select("NodeID").From("Orion.Nodes").
+				Where(Eq("Vendor", "Cisco"), RawCond("Caption LIKE @p1", Parameters{"p1": "core%"}), Eq("Status", 1)),
+			want:   "SELECT NodeID FROM Orion.Nodes WHERE (Vendor = @p0 AND Caption LIKE @p1 AND Status = @p2)",
+			params: Parameters{"p0": "Cisco", "p1": "core%", "p2": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, err := tt.query.Build()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, query)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestBuild_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *Query
+		want  string
+	}{
+		{"no columns", Select().From("Orion.Nodes"), "no columns selected"},
+		{"no entity", Select("NodeID"), "no entity given to From"},
+		{"empty path segment", Select("n..Caption").From("Orion.Nodes"), `invalid identifier "n..Caption"`},
+		{"unterminated bracket", Select("[Caption").From("Orion.Nodes"), `invalid identifier "[Caption"`},
+		{"newline in identifier", Select("Caption\n--").From("Orion.Nodes"), "invalid identifier"},
+		{"bad function name", Select(Func("COUNT(*) --")).From("Orion.Nodes"), "invalid function name"},
+		{"offset without limit", Select("NodeID").From("Orion.Nodes").Offset(5), "offset requires a limit"},
+		{"having without group by", Select("NodeID").From("Orion.Nodes").Having(Gt("NodeID", 1)), "HAVING requires GROUP BY"},
+		{"join without on", Select("NodeID").From("Orion.Nodes").Join("Orion.Volumes", "v", nil), "has no ON condition"},
+		{
+			"raw parameter bound twice",
+			Select("NodeID").From("Orion.Nodes").Where(
+				RawCond("Vendor = @v", Parameters{"v": "a"}),
+				RawCond("Contact = @v", Parameters{"v": "b"}),
+			),
+			"parameter @v is bound more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, params, err := tt.query.Build()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+			assert.Empty(t, query)
+			assert.Nil(t, params)
+		})
+	}
+}
+
+func TestBuild_Repeatable(t *testing.T) {
+	q := Select("NodeID").From("Orion.Nodes").Where(Eq("Vendor", "Cisco"))
+
+	first, firstParams, err := q.Build()
+	require.NoError(t, err)
+	second, secondParams, err := q.Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, firstParams, secondParams)
+	assert.Equal(t, "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @p0", q.String())
+}
@@ -0,0 +1,203 @@
+package swql
+
+import "strings"
+
+// Condition is a boolean expression for WHERE, HAVING and JOIN ... ON
+type Condition interface {
+	render(r *renderer) string
+}
+
+type compare struct {
+	left  Expr
+	op    string
+	right Expr
+}
+
+func (c compare) render(r *renderer) string {
+	return c.left.render(r) + " " + c.op + " " + c.right.render(r)
+}
+
+// cmp builds a comparison of a column (or Expr) against a bound value
+func cmp(col interface{}, op string, value interface{}) Condition {
+	var right Expr
+	if e, ok := value.(Expr); ok {
+		right = e
+	} else {
+		right = param{value: value}
+	}
+	return compare{left: toExpr(col), op: op, right: right}
+}
+
+// Eq matches rows where col equals value. A nil value renders IS NULL.
+func Eq(col interface{}, value interface{}) Condition {
+	if value == nil {
+		return IsNull(col)
+	}
+	return cmp(col, "=", value)
+}
+
+// Ne matches rows where col differs from value. A nil value renders IS NOT NULL.
+func Ne(col interface{}, value interface{}) Condition {
+	if value == nil {
+		return IsNotNull(col)
+	}
+	return cmp(col, "<>", value)
+}
+
+// Gt matches rows where col is greater than value
+func Gt(col interface{}, value interface{}) Condition { return cmp(col, ">", value) }
+
+// Ge matches rows where col is greater than or equal to value
+func Ge(col interface{}, value interface{}) Condition { return cmp(col, ">=", value) }
+
+// Lt matches rows where col is less than value
+func Lt(col interface{}, value interface{}) Condition { return cmp(col, "<", value) }
+
+// Le matches rows where col is less than or equal to value
+func Le(col interface{}, value interface{}) Condition { return cmp(col, "<=", value) }
+
+// Like matches rows where col matches a LIKE pattern
+func Like(col interface{}, pattern string) Condition { return cmp(col, "LIKE", pattern) }
+
+// NotLike matches rows where col doesn't match a LIKE pattern
+func NotLike(col interface{}, pattern string) Condition { return cmp(col, "NOT LIKE", pattern) }
+
+// ColEq compares two columns, typically in a JOIN ... ON
+func ColEq(left, right string) Condition {
+	return compare{left: Col(left), op: "=", right: Col(right)}
+}
+
+type nullCheck struct {
+	expr Expr
+	not  bool
+}
+
+func (n nullCheck) render(r *renderer) string {
+	if n.not {
+		return n.expr.render(r) + " IS NOT NULL"
+	}
+	return n.expr.render(r) + " IS NULL"
+}
+
+// IsNull matches rows where col is null
+func IsNull(col interface{}) Condition { return nullCheck{expr: toExpr(col)} }
+
+// IsNotNull matches rows where col is not null
+func IsNotNull(col interface{}) Condition { return nullCheck{expr: toExpr(col), not: true} }
+
+type inList struct {
+	expr   Expr
+	values []interface{}
+	not    bool
+}
+
+func (in inList) render(r *renderer) string {
+	if len(in.values) == 0 {
+		// An empty IN list is a syntax error in SWQL, so render the
+		// condition it logically stands for
+		if in.not {
+			return "1 = 1"
+		}
+		return "1 = 0"
+	}
+
+	placeholders := make([]string, len(in.values))
+	for i, v := range in.values {
+		placeholders[i] = r.bind(v)
+	}
+
+	op := " IN ("
+	if in.not {
+		op = " NOT IN ("
+	}
+	return in.expr.render(r) + op + strings.Join(placeholders, ", ") + ")"
+}
+
+// In matches rows where col is one of values. Each value is bound
+// separately. An empty list matches nothing.
+func In(col interface{}, values ...interface{}) Condition {
+	return inList{expr: toExpr(col), values: values}
+}
+
+// NotIn matches rows where col is none of values. An empty list matches
+// everything.
+func NotIn(col interface{}, values ...interface{}) Condition {
+	return inList{expr: toExpr(col), values: values, not: true}
+}
+
+type between struct {
+	expr      Expr
+	low, high interface{}
+}
+
+func (b between) render(r *renderer) string {
+	return b.expr.render(r) + " BETWEEN " + r.bind(b.low) + " AND " + r.bind(b.high)
+}
+
+// Between matches rows where col lies between low and high inclusive
+func Between(col interface{}, low, high interface{}) Condition {
+	return between{expr: toExpr(col), low: low, high: high}
+}
+
+type junction struct {
+	op    string
+	conds []Condition
+}
+
+func (j junction) render(r *renderer) string {
+	var parts []string
+	for _, c := range j.conds {
+		if c == nil {
+			continue
+		}
+		parts = append(parts, c.render(r))
+	}
+
+	switch len(parts) {
+	case 0:
+		if j.op == "OR" {
+			return "1 = 0"
+		}
+		return "1 = 1"
+	case 1:
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " "+j.op+" ") + ")"
+}
+
+// And matches rows that satisfy every condition. Nil conditions are
+// skipped, which makes optional filters easy to assemble.
+func And(conds ...Condition) Condition { return junction{op: "AND", conds: conds} }
+
+// Or matches rows that satisfy any condition. Nil conditions are skipped.
+func Or(conds ...Condition) Condition { return junction{op: "OR", conds: conds} }
+
+type not struct{ cond Condition }
+
+func (n not) render(r *renderer) string {
+	return "NOT (" + n.cond.render(r) + ")"
+}
+
+// Not negates a condition
+func Not(cond Condition) Condition { return not{cond: cond} }
+
+type rawCond struct {
+	text   string
+	params Parameters
+}
+
+func (c rawCond) render(r *renderer) string {
+	for k, v := range c.params {
+		if _, exists := r.params[k]; exists {
+			return r.fail("parameter @%s is bound more than once", k)
+		}
+		r.params[k] = v
+	}
+	return c.text
+}
+
+// RawCond inserts a condition verbatim together with the named parameters
+// it refers to. Never build text from untrusted input.
+func RawCond(text string, params Parameters) Condition {
+	return rawCond{text: text, params: params}
+}
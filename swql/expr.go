@@ -0,0 +1,263 @@
+// Package swql builds SolarWinds Query Language statements. Values are never
+// written into the query text: every value becomes a bound @parameter, and
+// identifiers are validated and bracket-quoted where needed, so the result can
+// be passed straight to Client.QueryContext.
+//
+//	query, params, err := swql.Select("n.NodeID", "n.Caption", "n.Interfaces.Name").
+//		From("Orion.Nodes", "n").
+//		Where(swql.Eq("n.Vendor", vendor)).
+//		In("n.Status", 1, 3).
+//		OrderBy("n.Caption").
+//		Limit(50).
+//		Build()
+//	if err != nil {
+//		return err
+//	}
+//	res, err := client.QueryContext(ctx, query, params)
+package swql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Parameters holds the bound values of a built query, keyed by parameter
+// name without the leading @
+type Parameters map[string]interface{}
+
+// renderer accumulates bound parameters and errors while a query is rendered
+type renderer struct {
+	params Parameters
+	next   int
+	errs   []error
+}
+
+// bind stores a value as a new parameter and returns its placeholder
+func (r *renderer) bind(value interface{}) string {
+	for {
+		name := fmt.Sprintf("p%d", r.next)
+		r.next++
+		if _, taken := r.params[name]; !taken {
+			r.params[name] = value
+			return "@" + name
+		}
+	}
+}
+
+func (r *renderer) fail(format string, args ...interface{}) string {
+	r.errs = append(r.errs, fmt.Errorf("swql: "+format, args...))
+	return ""
+}
+
+// Expr is a value expression in a SELECT list, ORDER BY, GROUP BY or condition
+type Expr interface {
+	render(r *renderer) string
+}
+
+type column string
+
+func (c column) render(r *renderer) string {
+	return quotePath(r, string(c))
+}
+
+// Col refers to a column or navigation property by its dotted path, such as
+// "Caption", "n.Caption" or "n.Interfaces.Name"
+func Col(path string) Expr {
+	return column(path)
+}
+
+type star struct{ alias string }
+
+func (s star) render(r *renderer) string {
+	if s.alias == "" {
+		return "*"
+	}
+	return quoteIdent(r, s.alias) + ".*"
+}
+
+type raw string
+
+func (e raw) render(*renderer) string {
+	return string(e)
+}
+
+// Raw inserts SWQL text verbatim. Never build it from untrusted input; use
+// Param for values.
+func Raw(text string) Expr {
+	return raw(text)
+}
+
+type param struct{ value interface{} }
+
+func (p param) render(r *renderer) string {
+	return r.bind(p.value)
+}
+
+// Param binds a value as a parameter, for use as a function argument
+func Param(value interface{}) Expr {
+	return param{value: value}
+}
+
+type alias struct {
+	expr Expr
+	name string
+}
+
+func (a alias) render(r *renderer) string {
+	return a.expr.render(r) + " AS " + quoteIdent(r, a.name)
+}
+
+// As gives an expression a column alias. A string is treated as a column path.
+func As(expr interface{}, name string) Expr {
+	return alias{expr: toExpr(expr), name: name}
+}
+
+type function struct {
+	name string
+	args []Expr
+}
+
+func (f function) render(r *renderer) string {
+	if !identPattern.MatchString(f.name) {
+		return r.fail("invalid function name %q", f.name)
+	}
+	args := make([]string, len(f.args))
+	for i, a := range f.args {
+		args[i] = a.render(r)
+	}
+	return strings.ToUpper(f.name) + "(" + strings.Join(args, ", ") + ")"
+}
+
+// Func calls a SWQL function such as ADDDAY or TOLOCAL. String arguments are
+// treated as column paths; use Param to pass a value.
+func Func(name string, args ...interface{}) Expr {
+	exprs := make([]Expr, len(args))
+	for i, a := range args {
+		exprs[i] = toExpr(a)
+	}
+	return function{name: name, args: exprs}
+}
+
+// Count returns COUNT(expr)
+func Count(expr interface{}) Expr { return Func("COUNT", expr) }
+
+// Sum returns SUM(expr)
+func Sum(expr interface{}) Expr { return Func("SUM", expr) }
+
+// Avg returns AVG(expr)
+func Avg(expr interface{}) Expr { return Func("AVG", expr) }
+
+// Min returns MIN(expr)
+func Min(expr interface{}) Expr { return Func("MIN", expr) }
+
+// Max returns MAX(expr)
+func Max(expr interface{}) Expr { return Func("MAX", expr) }
+
+// toExpr converts a string column path or an Expr into an Expr
+func toExpr(v interface{}) Expr {
+	switch e := v.(type) {
+	case Expr:
+		return e
+	case string:
+		if e == "*" {
+			return star{}
+		}
+		if strings.HasSuffix(e, ".*") {
+			return star{alias: strings.TrimSuffix(e, ".*")}
+		}
+		return column(e)
+	default:
+		return param{value: v}
+	}
+}
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// reserved lists SWQL keywords that must be bracketed when used as names
+var reserved = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "DESC": true, "DISTINCT": true, "ELSE": true, "END": true,
+	"EXISTS": true, "FROM": true, "FULL": true, "GROUP": true, "HAVING": true,
+	"IN": true, "INNER": true, "IS": true, "JOIN": true, "LEFT": true, "LIKE": true,
+	"NOT": true, "NULL": true, "ON": true, "OR": true, "ORDER": true, "OUTER": true,
+	"RIGHT": true, "ROWS": true, "SELECT": true, "THEN": true, "TO": true,
+	"TOP": true, "TOTALROWS": true, "UNION": true, "WHEN": true, "WHERE": true,
+	"WITH": true,
+}
+
+// quoteIdent validates a single identifier and brackets it if it is a
+// keyword or contains characters other than letters, digits and underscores
+func quoteIdent(r *renderer, name string) string {
+	if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") && len(name) > 2 {
+		inner := name[1 : len(name)-1]
+		if strings.Contains(strings.ReplaceAll(inner, "]]", ""), "]") {
+			return r.fail("invalid quoted identifier %q", name)
+		}
+		return name
+	}
+	if name == "" {
+		return r.fail("empty identifier")
+	}
+	if identPattern.MatchString(name) && !reserved[strings.ToUpper(name)] {
+		return name
+	}
+	if strings.ContainsAny(name, "\r\n") {
+		return r.fail("invalid identifier %q", name)
+	}
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// quotePath quotes every segment of a dotted path such as n.Interfaces.Name.
+// Segments already in brackets may contain dots.
+func quotePath(r *renderer, path string) string {
+	segments, ok := splitPath(path)
+	if !ok {
+		return r.fail("invalid identifier %q", path)
+	}
+	for i, s := range segments {
+		segments[i] = quoteIdent(r, s)
+	}
+	return strings.Join(segments, ".")
+}
+
+// splitPath splits a dotted path, keeping bracketed segments intact
+func splitPath(path string) ([]string, bool) {
+	var segments []string
+	var cur strings.Builder
+	inBracket := false
+
+	for i := 0; i < len(path); i++ {
+		ch := path[i]
+		switch {
+		case inBracket:
+			cur.WriteByte(ch)
+			if ch == ']' {
+				if i+1 < len(path) && path[i+1] == ']' {
+					cur.WriteByte(']')
+					i++
+				} else {
+					inBracket = false
+				}
+			}
+		case ch == '[':
+			if cur.Len() > 0 {
+				return nil, false
+			}
+			inBracket = true
+			cur.WriteByte(ch)
+		case ch == '.':
+			if cur.Len() == 0 {
+				return nil, false
+			}
+			segments = append(segments, cur.String())
+			cur.Reset()
+		default:
+			cur.WriteByte(ch)
+		}
+	}
+	if inBracket || cur.Len() == 0 {
+		return nil, false
+	}
+	return append(segments, cur.String()), true
+}
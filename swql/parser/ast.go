@@ -0,0 +1,331 @@
+package parser
+
+import "strings"
+
+// Node is any element of a parsed query
+type Node interface {
+	Pos() Pos
+}
+
+// Expr is a value or boolean expression
+type Expr interface {
+	Node
+	expr()
+}
+
+type node struct {
+	Start Pos
+}
+
+// Pos returns the position of the node's first token
+func (n node) Pos() Pos { return n.Start }
+
+// Select is a SELECT statement, optionally followed by UNIONed statements
+type Select struct {
+	node
+	Distinct  bool
+	Top       Expr
+	Columns   []*Column
+	From      *Source
+	Joins     []*Join
+	Where     Expr
+	GroupBy   []Expr
+	Having    Expr
+	OrderBy   []*OrderItem
+	Rows      *RowRange
+	TotalRows bool
+	Return    string // e.g. "XML RAW" for RETURN XML RAW
+	Union     *Select
+	UnionAll  bool
+}
+
+// Column is an item of the SELECT list
+type Column struct {
+	node
+	Expr  Expr
+	Alias string
+}
+
+// Source is an entity or subquery in FROM or JOIN. Hints holds a table hint
+// list such as (nolock=true) after an entity.
+type Source struct {
+	node
+	Entity   string
+	Subquery *Select
+	Alias    string
+	Hints    []*Hint
+}
+
+// Hint is a name=value table hint, such as nolock=true
+type Hint struct {
+	node
+	Name  string
+	Value string
+}
+
+// Join joins a source to the query. Kind is "INNER", "LEFT", "RIGHT", "FULL"
+// or "CROSS"; On is nil for cross joins.
+type Join struct {
+	node
+	Kind   string
+	Source *Source
+	On     Expr
+}
+
+// OrderItem is an ORDER BY expression
+type OrderItem struct {
+	node
+	Expr Expr
+	Desc bool
+}
+
+// RowRange is a WITH ROWS first TO last clause
+type RowRange struct {
+	node
+	First Expr
+	Last  Expr
+}
+
+// Path refers to a column, an alias or a navigation property, such as
+// Caption, n.Caption or n.Interfaces.Name
+type Path struct {
+	node
+	Parts []string
+}
+
+// String returns the dotted path
+func (p *Path) String() string { return strings.Join(p.Parts, ".") }
+
+// Star is * or alias.* in a SELECT list or COUNT(*)
+type Star struct {
+	node
+	Qualifier string
+}
+
+// LiteralKind tells number, string and null literals apart
+type LiteralKind int
+
+// Literal kinds
+const (
+	Number LiteralKind = iota
+	String
+	Null
+)
+
+// Literal is a constant. Value holds the number text or the unescaped string.
+type Literal struct {
+	node
+	Kind  LiteralKind
+	Value string
+}
+
+// Param is a reference to a query parameter, without the leading @
+type Param struct {
+	node
+	Name string
+}
+
+// Binary is a binary operation such as a = b, a + b or a AND b. Op is
+// upper case.
+type Binary struct {
+	node
+	Op    string
+	Left  Expr
+	Right Expr
+}
+
+// Unary is NOT x, -x or +x
+type Unary struct {
+	node
+	Op string
+	X  Expr
+}
+
+// Call is a function call such as ADDDAY(-1, GETUTCDATE()) or
+// COUNT(DISTINCT NodeID)
+type Call struct {
+	node
+	Name     string
+	Distinct bool
+	Args     []Expr
+}
+
+// Subquery is a SELECT used as a value
+type Subquery struct {
+	node
+	Select *Select
+}
+
+// In is x [NOT] IN (list) or x [NOT] IN (subquery)
+type In struct {
+	node
+	X        Expr
+	Not      bool
+	List     []Expr
+	Subquery *Select
+}
+
+// Between is x [NOT] BETWEEN low AND high
+type Between struct {
+	node
+	X    Expr
+	Not  bool
+	Low  Expr
+	High Expr
+}
+
+// Like is x [NOT] LIKE pattern
+type Like struct {
+	node
+	X       Expr
+	Not     bool
+	Pattern Expr
+}
+
+// IsNull is x IS [NOT] NULL
+type IsNull struct {
+	node
+	X   Expr
+	Not bool
+}
+
+// Case is CASE [operand] WHEN ... THEN ... [ELSE ...] END
+type Case struct {
+	node
+	Operand Expr
+	Whens   []*When
+	Else    Expr
+}
+
+// When is a WHEN ... THEN ... branch of a CASE expression
+type When struct {
+	node
+	Cond   Expr
+	Result Expr
+}
+
+// Exists is EXISTS (subquery)
+type Exists struct {
+	node
+	Select *Select
+}
+
+// Cast is CAST(x AS type)
+type Cast struct {
+	node
+	X    Expr
+	Type string
+}
+
+func (*Path) expr()     {}
+func (*Star) expr()     {}
+func (*Literal) expr()  {}
+func (*Param) expr()    {}
+func (*Binary) expr()   {}
+func (*Unary) expr()    {}
+func (*Call) expr()     {}
+func (*Subquery) expr() {}
+func (*In) expr()       {}
+func (*Between) expr()  {}
+func (*Like) expr()     {}
+func (*IsNull) expr()   {}
+func (*Case) expr()     {}
+func (*Exists) expr()   {}
+func (*Cast) expr()     {}
+
+// Inspect traverses the tree rooted at n in depth-first order, calling f for
+// each node. If f returns false, the node's children are skipped.
+func Inspect(n Node, f func(Node) bool) {
+	if isNil(n) || !f(n) {
+		return
+	}
+
+	visit := func(children ...Node) {
+		for _, c := range children {
+			Inspect(c, f)
+		}
+	}
+
+	switch n := n.(type) {
+	case *Select:
+		visit(n.Top)
+		for _, c := range n.Columns {
+			visit(c)
+		}
+		visit(n.From)
+		for _, j := range n.Joins {
+			visit(j)
+		}
+		visit(n.Where)
+		for _, e := range n.GroupBy {
+			visit(e)
+		}
+		visit(n.Having)
+		for _, o := range n.OrderBy {
+			visit(o)
+		}
+		visit(n.Rows, n.Union)
+	case *Column:
+		visit(n.Expr)
+	case *Source:
+		visit(n.Subquery)
+		for _, h := range n.Hints {
+			visit(h)
+		}
+	case *Join:
+		visit(n.Source, n.On)
+	case *OrderItem:
+		visit(n.Expr)
+	case *RowRange:
+		visit(n.First, n.Last)
+	case *Binary:
+		visit(n.Left, n.Right)
+	case *Unary:
+		visit(n.X)
+	case *Call:
+		for _, a := range n.Args {
+			visit(a)
+		}
+	case *Subquery:
+		visit(n.Select)
+	case *In:
+		visit(n.X)
+		for _, e := range n.List {
+			visit(e)
+		}
+		visit(n.Subquery)
+	case *Between:
+		visit(n.X, n.Low, n.High)
+	case *Like:
+		visit(n.X, n.Pattern)
+	case *IsNull:
+		visit(n.X)
+	case *Case:
+		visit(n.Operand)
+		for _, w := range n.Whens {
+			visit(w)
+		}
+		visit(n.Else)
+	case *When:
+		visit(n.Cond, n.Result)
+	case *Exists:
+		visit(n.Select)
+	case *Cast:
+		visit(n.X)
+	}
+}
+
+// isNil reports whether n is nil or a typed nil pointer
+func isNil(n Node) bool {
+	switch v := n.(type) {
+	case nil:
+		return true
+	case *Select:
+		return v == nil
+	case *Source:
+		return v == nil
+	case *RowRange:
+		return v == nil
+	}
+	return false
+}
@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Pos is a position in the query text. Line and Column are 1-based; Column
+// counts characters, not bytes.
+type Pos struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("line %d, column %d", p.Line, p.Column)
+}
+
+// SyntaxError reports a query that can't be parsed
+type SyntaxError struct {
+	Pos
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("swql: syntax error at %s: %s", e.Pos, e.Msg)
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokQuoted
+	tokNumber
+	tokString
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string // raw text; for quoted identifiers and strings, the unescaped value
+	pos  Pos
+}
+
+// describe returns the token as it should appear in an error message
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokQuoted:
+		return "[" + t.text + "]"
+	case tokString:
+		return "'" + t.text + "'"
+	case tokParam:
+		return "@" + t.text
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// is reports whether t is the given keyword or symbol
+func (t token) is(text string) bool {
+	switch t.kind {
+	case tokWord:
+		return strings.EqualFold(t.text, text)
+	case tokSymbol:
+		return t.text == text
+	}
+	return false
+}
+
+type lexer struct {
+	src  string
+	off  int
+	line int
+	col  int
+}
+
+// tokenize splits a query into tokens, dropping whitespace and comments
+func tokenize(src string) ([]token, error) {
+	lx := &lexer{src: src, line: 1, col: 1}
+	var tokens []token
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (lx *lexer) pos() Pos {
+	return Pos{Offset: lx.off, Line: lx.line, Column: lx.col}
+}
+
+func (lx *lexer) peek(n int) rune {
+	off := lx.off
+	for i := 0; i < n; i++ {
+		if off >= len(lx.src) {
+			return 0
+		}
+		_, size := utf8.DecodeRuneInString(lx.src[off:])
+		off += size
+	}
+	if off >= len(lx.src) {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(lx.src[off:])
+	return r
+}
+
+func (lx *lexer) advance() rune {
+	r, size := utf8.DecodeRuneInString(lx.src[lx.off:])
+	lx.off += size
+	if r == '\n' {
+		lx.line++
+		lx.col = 1
+	} else {
+		lx.col++
+	}
+	return r
+}
+
+func (lx *lexer) errorf(pos Pos, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (lx *lexer) skipSpaceAndComments() error {
+	for lx.off < len(lx.src) {
+		r := lx.peek(0)
+		switch {
+		case unicode.IsSpace(r):
+			lx.advance()
+		case r == '-' && lx.peek(1) == '-':
+			for lx.off < len(lx.src) && lx.peek(0) != '\n' {
+				lx.advance()
+			}
+		case r == '/' && lx.peek(1) == '*':
+			start := lx.pos()
+			lx.advance()
+			lx.advance()
+			for {
+				if lx.off >= len(lx.src) {
+					return lx.errorf(start, "unterminated comment")
+				}
+				if lx.peek(0) == '*' && lx.peek(1) == '/' {
+					lx.advance()
+					lx.advance()
+					break
+				}
+				lx.advance()
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (lx *lexer) next() (token, error) {
+	if err := lx.skipSpaceAndComments(); err != nil {
+		return token{}, err
+	}
+
+	start := lx.pos()
+	if lx.off >= len(lx.src) {
+		return token{kind: tokEOF, pos: start}, nil
+	}
+
+	r := lx.peek(0)
+	switch {
+	case isWordStart(r):
+		return token{kind: tokWord, text: lx.word(), pos: start}, nil
+
+	case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(lx.peek(1))):
+		return lx.number(start)
+
+	case r == '@':
+		lx.advance()
+		if !isWordStart(lx.peek(0)) {
+			return token{}, lx.errorf(start, "expected parameter name after @")
+		}
+		return token{kind: tokParam, text: lx.word(), pos: start}, nil
+
+	case r == '\'':
+		return lx.quoted(start, '\'', tokString, "unterminated string")
+
+	case r == '[':
+		tok, err := lx.quoted(start, ']', tokQuoted, "unterminated bracketed identifier")
+		if err == nil && tok.text == "" {
+			return token{}, lx.errorf(start, "empty bracketed identifier")
+		}
+		return tok, err
+	}
+
+	lx.advance()
+	switch r {
+	case '<':
+		if n := lx.peek(0); n == '=' || n == '>' {
+			lx.advance()
+			return token{kind: tokSymbol, text: string([]rune{r, n}), pos: start}, nil
+		}
+	case '>':
+		if lx.peek(0) == '=' {
+			lx.advance()
+			return token{kind: tokSymbol, text: ">=", pos: start}, nil
+		}
+	case '!':
+		if lx.peek(0) == '=' {
+			lx.advance()
+			return token{kind: tokSymbol, text: "!=", pos: start}, nil
+		}
+		return token{}, lx.errorf(start, "unexpected character %q", r)
+	case '=', '+', '-', '*', '/', '%', '(', ')', ',', '.', ';':
+	default:
+		return token{}, lx.errorf(start, "unexpected character %q", r)
+	}
+	return token{kind: tokSymbol, text: string(r), pos: start}, nil
+}
+
+func isWordStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isWordPart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func (lx *lexer) word() string {
+	begin := lx.off
+	for lx.off < len(lx.src) && isWordPart(lx.peek(0)) {
+		lx.advance()
+	}
+	return lx.src[begin:lx.off]
+}
+
+func (lx *lexer) number(start Pos) (token, error) {
+	begin := lx.off
+	for unicode.IsDigit(lx.peek(0)) {
+		lx.advance()
+	}
+	if lx.peek(0) == '.' && unicode.IsDigit(lx.peek(1)) {
+		lx.advance()
+		for unicode.IsDigit(lx.peek(0)) {
+			lx.advance()
+		}
+	}
+	if r := lx.peek(0); r == 'e' || r == 'E' {
+		n := lx.peek(1)
+		if unicode.IsDigit(n) || ((n == '+' || n == '-') && unicode.IsDigit(lx.peek(2))) {
+			lx.advance()
+			lx.advance()
+			for unicode.IsDigit(lx.peek(0)) {
+				lx.advance()
+			}
+		}
+	}
+	if isWordStart(lx.peek(0)) {
+		return token{}, lx.errorf(start, "invalid number %q", lx.src[begin:lx.off]+string(lx.peek(0)))
+	}
+	return token{kind: tokNumber, text: lx.src[begin:lx.off], pos: start}, nil
+}
+
+// quoted reads text from the opening character up to close, where a doubled
+// close character stands for itself
+func (lx *lexer) quoted(start Pos, close rune, kind tokenKind, unterminated string) (token, error) {
+	lx.advance()
+	var b strings.Builder
+	for {
+		if lx.off >= len(lx.src) {
+			return token{}, lx.errorf(start, "%s", unterminated)
+		}
+		r := lx.advance()
+		if r == close {
+			if lx.peek(0) != close {
+				return token{kind: kind, text: b.String(), pos: start}, nil
+			}
+			lx.advance()
+		}
+		b.WriteRune(r)
+	}
+}
@@ -0,0 +1,865 @@
+// Package parser parses SolarWinds Query Language SELECT statements into a
+// syntax tree, so queries can be checked offline and inspected for the
+// entities, columns and parameters they use.
+//
+//	q, err := parser.Parse("SELECT n.Caption FROM Orion.Nodes n WHERE n.Vendor = @vendor")
+//	if err != nil {
+//		var syntaxErr *parser.SyntaxError
+//		if errors.As(err, &syntaxErr) {
+//			fmt.Println(syntaxErr.Line, syntaxErr.Column)
+//		}
+//		return err
+//	}
+//	fmt.Println(q.Entities(), q.Parameters())
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keywords can't be used as bare identifiers or aliases. Words that are only
+// special in one place, such as ROWS and TOTALROWS after WITH, aren't listed.
+var keywords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "BETWEEN": true, "BY": true,
+	"CASE": true, "CAST": true, "CROSS": true, "DESC": true, "DISTINCT": true,
+	"ELSE": true, "END": true, "EXISTS": true, "FROM": true, "FULL": true,
+	"GROUP": true, "HAVING": true, "IN": true, "INNER": true, "IS": true,
+	"JOIN": true, "LEFT": true, "LIKE": true, "NOT": true, "NULL": true, "ON": true,
+	"OR": true, "ORDER": true, "OUTER": true, "RETURN": true, "RIGHT": true,
+	"SELECT": true, "THEN": true, "TOP": true, "UNION": true, "WHEN": true,
+	"WHERE": true, "WITH": true,
+}
+
+// Parse parses a single SELECT statement, optionally followed by UNIONs and
+// a trailing semicolon. Errors are *SyntaxError values.
+func Parse(query string) (*Select, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	sel, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if p.cur().kind != tokEOF {
+		return nil, p.unexpected("end of query")
+	}
+	return sel, nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+func (p *parser) cur() token { return p.tokens[p.i] }
+
+func (p *parser) peekAt(n int) token {
+	if p.i+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.i+n]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.i]
+	if tok.kind != tokEOF {
+		p.i++
+	}
+	return tok
+}
+
+// accept consumes the current token if it is the given keyword or symbol
+func (p *parser) accept(text string) bool {
+	if p.cur().is(text) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) (token, error) {
+	if !p.cur().is(text) {
+		return token{}, p.unexpected(text)
+	}
+	return p.advance(), nil
+}
+
+func (p *parser) errorAt(pos Pos, format string, args ...interface{}) error {
+	return &SyntaxError{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) unexpected(want string) error {
+	tok := p.cur()
+	return p.errorAt(tok.pos, "expected %s, found %s", want, tok.describe())
+}
+
+// isKeyword reports whether the current token is a reserved word
+func (p *parser) isKeyword() bool {
+	tok := p.cur()
+	return tok.kind == tokWord && keywords[strings.ToUpper(tok.text)]
+}
+
+// ident reads an identifier. After a dot any word is accepted, since SWIS
+// doesn't reserve property names.
+func (p *parser) ident(afterDot bool) (string, error) {
+	tok := p.cur()
+	switch {
+	case tok.kind == tokQuoted:
+	case tok.kind == tokWord && (afterDot || !p.isKeyword()):
+	default:
+		return "", p.unexpected("identifier")
+	}
+	p.advance()
+	return tok.text, nil
+}
+
+// dottedName reads an identifier path such as Orion.NPM.Interfaces
+func (p *parser) dottedName() ([]string, error) {
+	first, err := p.ident(false)
+	if err != nil {
+		return nil, err
+	}
+	parts := []string{first}
+	for p.cur().is(".") && p.peekAt(1).kind != tokSymbol {
+		p.advance()
+		part, err := p.ident(true)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
+// alias reads an optional [AS] alias
+func (p *parser) alias() (string, error) {
+	if p.accept("AS") {
+		if p.cur().kind == tokString {
+			return p.advance().text, nil
+		}
+		return p.ident(false)
+	}
+	if tok := p.cur(); tok.kind == tokQuoted || (tok.kind == tokWord && !p.isKeyword()) {
+		p.advance()
+		return tok.text, nil
+	}
+	return "", nil
+}
+
+func (p *parser) parseSelect() (*Select, error) {
+	start, err := p.expect("SELECT")
+	if err != nil {
+		return nil, err
+	}
+	sel := &Select{node: node{start.pos}}
+
+	if p.accept("DISTINCT") {
+		sel.Distinct = true
+	} else {
+		p.accept("ALL")
+	}
+
+	if p.accept("TOP") {
+		if sel.Top, err = p.parsePrimary(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		col, err := p.parseColumn()
+		if err != nil {
+			return nil, err
+		}
+		sel.Columns = append(sel.Columns, col)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.accept("FROM") {
+		if sel.From, err = p.parseSource(); err != nil {
+			return nil, err
+		}
+		if err := p.parseJoins(sel); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.accept("WHERE") {
+		if sel.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.cur().is("GROUP") {
+		p.advance()
+		if _, err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if sel.GroupBy, err = p.parseExprList(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.accept("HAVING") {
+		if sel.Having, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.cur().is("ORDER") {
+		p.advance()
+		if _, err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if sel.OrderBy, err = p.parseOrderBy(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := p.parseWith(sel); err != nil {
+		return nil, err
+	}
+
+	if p.accept("RETURN") {
+		ret, err := p.parseReturn()
+		if err != nil {
+			return nil, err
+		}
+		sel.Return = ret
+	}
+
+	if p.accept("UNION") {
+		sel.UnionAll = p.accept("ALL")
+		if sel.Union, err = p.parseUnionOperand(); err != nil {
+			return nil, err
+		}
+	}
+
+	return sel, nil
+}
+
+// parseUnionOperand reads the SELECT after UNION, which may be wrapped in
+// parentheses and followed by more UNIONs
+func (p *parser) parseUnionOperand() (*Select, error) {
+	if !p.accept("(") {
+		return p.parseSelect()
+	}
+	sel, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	if p.accept("UNION") {
+		last := sel
+		for last.Union != nil {
+			last = last.Union
+		}
+		last.UnionAll = p.accept("ALL")
+		if last.Union, err = p.parseUnionOperand(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) parseColumn() (*Column, error) {
+	tok := p.cur()
+	col := &Column{node: node{tok.pos}}
+
+	// * and alias.* aren't expressions outside of COUNT(*)
+	if tok.is("*") {
+		p.advance()
+		col.Expr = &Star{node: node{tok.pos}}
+		return col, nil
+	}
+	if (tok.kind == tokWord || tok.kind == tokQuoted) && p.peekAt(1).is(".") && p.peekAt(2).is("*") {
+		p.advance()
+		p.advance()
+		p.advance()
+		col.Expr = &Star{node: node{tok.pos}, Qualifier: tok.text}
+		return col, nil
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	col.Expr = expr
+	if col.Alias, err = p.alias(); err != nil {
+		return nil, err
+	}
+	return col, nil
+}
+
+func (p *parser) parseSource() (*Source, error) {
+	tok := p.cur()
+	src := &Source{node: node{tok.pos}}
+
+	if tok.is("(") {
+		p.advance()
+		sub, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		src.Subquery = sub
+	} else {
+		parts, err := p.dottedName()
+		if err != nil {
+			return nil, p.unexpected("entity name")
+		}
+		src.Entity = strings.Join(parts, ".")
+	}
+
+	// Hints come straight after the entity or after its alias
+	if err := p.parseHints(src); err != nil {
+		return nil, err
+	}
+	alias, err := p.alias()
+	if err != nil {
+		return nil, err
+	}
+	src.Alias = alias
+	if err := p.parseHints(src); err != nil {
+		return nil, err
+	}
+	return src, nil
+}
+
+// parseHints reads an optional (name=value, ...) hint list after an entity
+func (p *parser) parseHints(src *Source) error {
+	if src.Entity == "" || src.Hints != nil || !p.cur().is("(") {
+		return nil
+	}
+	p.advance()
+	for {
+		tok := p.cur()
+		name, err := p.ident(false)
+		if err != nil {
+			return err
+		}
+		if _, err := p.expect("="); err != nil {
+			return err
+		}
+		value := p.cur()
+		switch value.kind {
+		case tokWord, tokNumber, tokString:
+			p.advance()
+		default:
+			return p.unexpected("hint value")
+		}
+		src.Hints = append(src.Hints, &Hint{node: node{tok.pos}, Name: name, Value: value.text})
+		if !p.accept(",") {
+			break
+		}
+	}
+	_, err := p.expect(")")
+	return err
+}
+
+func (p *parser) parseJoins(sel *Select) error {
+	for {
+		tok := p.cur()
+		var kind string
+		switch {
+		case tok.is(","):
+			p.advance()
+			kind = "CROSS"
+		case tok.is("JOIN"):
+			kind = "INNER"
+		case tok.is("INNER"), tok.is("CROSS"):
+			kind = strings.ToUpper(tok.text)
+			p.advance()
+		case tok.is("LEFT"), tok.is("RIGHT"), tok.is("FULL"):
+			kind = strings.ToUpper(tok.text)
+			p.advance()
+			p.accept("OUTER")
+		default:
+			return nil
+		}
+		if !tok.is(",") {
+			if _, err := p.expect("JOIN"); err != nil {
+				return err
+			}
+		}
+
+		src, err := p.parseSource()
+		if err != nil {
+			return err
+		}
+		join := &Join{node: node{tok.pos}, Kind: kind, Source: src}
+		if kind != "CROSS" {
+			if _, err := p.expect("ON"); err != nil {
+				return err
+			}
+			if join.On, err = p.parseExpr(); err != nil {
+				return err
+			}
+		}
+		sel.Joins = append(sel.Joins, join)
+	}
+}
+
+func (p *parser) parseOrderBy() ([]*OrderItem, error) {
+	var items []*OrderItem
+	for {
+		tok := p.cur()
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		item := &OrderItem{node: node{tok.pos}, Expr: expr}
+		if p.accept("DESC") {
+			item.Desc = true
+		} else {
+			p.accept("ASC")
+		}
+		items = append(items, item)
+		if !p.accept(",") {
+			return items, nil
+		}
+	}
+}
+
+// parseWith reads WITH ROWS a TO b and WITH TOTALROWS in either order
+func (p *parser) parseWith(sel *Select) error {
+	for p.cur().is("WITH") {
+		with := p.advance()
+		switch {
+		case p.cur().is("ROWS") && sel.Rows == nil:
+			p.advance()
+			first, err := p.parseAdditive()
+			if err != nil {
+				return err
+			}
+			if _, err := p.expect("TO"); err != nil {
+				return err
+			}
+			last, err := p.parseAdditive()
+			if err != nil {
+				return err
+			}
+			sel.Rows = &RowRange{node: node{with.pos}, First: first, Last: last}
+		case p.cur().is("TOTALROWS") && !sel.TotalRows:
+			p.advance()
+			sel.TotalRows = true
+		default:
+			return p.unexpected("ROWS or TOTALROWS")
+		}
+	}
+	return nil
+}
+
+// parseReturn reads the format after RETURN, such as XML RAW
+func (p *parser) parseReturn() (string, error) {
+	if _, err := p.expect("XML"); err != nil {
+		return "", err
+	}
+	mode := p.cur()
+	if !mode.is("RAW") && !mode.is("AUTO") && !mode.is("STRUCTURED") {
+		return "", p.unexpected("RAW, AUTO or STRUCTURED")
+	}
+	p.advance()
+	return "XML " + strings.ToUpper(mode.text), nil
+}
+
+func (p *parser) parseExprList() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		exprs = append(exprs, expr)
+		if !p.accept(",") {
+			return exprs, nil
+		}
+	}
+}
+
+// parseExpr parses an expression. Precedence from lowest to highest: OR,
+// AND, NOT, comparisons and predicates, + and -, * / and %, unary signs.
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur().is("OR") {
+		op := p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{node: node{op.pos}, Op: "OR", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur().is("AND") {
+		op := p.advance()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{node: node{op.pos}, Op: "AND", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.cur().is("NOT") {
+		op := p.advance()
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{node: node{op.pos}, Op: "NOT", X: x}, nil
+	}
+	return p.parsePredicate()
+}
+
+var comparisons = map[string]bool{"=": true, "<>": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+
+func (p *parser) parsePredicate() (Expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.cur()
+	if tok.kind == tokSymbol && comparisons[tok.text] {
+		p.advance()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &Binary{node: node{tok.pos}, Op: tok.text, Left: left, Right: right}, nil
+	}
+
+	if tok.is("IS") {
+		p.advance()
+		not := p.accept("NOT")
+		if _, err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &IsNull{node: node{tok.pos}, X: left, Not: not}, nil
+	}
+
+	not := false
+	if tok.is("NOT") {
+		next := p.peekAt(1)
+		if !next.is("IN") && !next.is("LIKE") && !next.is("BETWEEN") {
+			return left, nil
+		}
+		p.advance()
+		not = true
+	}
+
+	switch {
+	case p.cur().is("IN"):
+		p.advance()
+		return p.parseIn(tok.pos, left, not)
+
+	case p.cur().is("LIKE"):
+		p.advance()
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &Like{node: node{tok.pos}, X: left, Not: not, Pattern: pattern}, nil
+
+	case p.cur().is("BETWEEN"):
+		p.advance()
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &Between{node: node{tok.pos}, X: left, Not: not, Low: low, High: high}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseIn(pos Pos, x Expr, not bool) (Expr, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	in := &In{node: node{pos}, X: x, Not: not}
+
+	if p.cur().is("SELECT") {
+		sub, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		in.Subquery = sub
+	} else {
+		list, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		in.List = list
+	}
+
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return in, nil
+}
+
+func (p *parser) parseAdditive() (Expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur().is("+") || p.cur().is("-") {
+		op := p.advance()
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{node: node{op.pos}, Op: op.text, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseMultiplicative() (Expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.cur().is("*") || p.cur().is("/") || p.cur().is("%") {
+		op := p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &Binary{node: node{op.pos}, Op: op.text, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.cur().is("-") || p.cur().is("+") {
+		op := p.advance()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{node: node{op.pos}, Op: op.text, X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.cur()
+	at := node{tok.pos}
+
+	switch tok.kind {
+	case tokNumber:
+		p.advance()
+		return &Literal{node: at, Kind: Number, Value: tok.text}, nil
+	case tokString:
+		p.advance()
+		return &Literal{node: at, Kind: String, Value: tok.text}, nil
+	case tokParam:
+		p.advance()
+		return &Param{node: at, Name: tok.text}, nil
+	}
+
+	switch {
+	case tok.is("("):
+		p.advance()
+		var expr Expr
+		if p.cur().is("SELECT") {
+			sub, err := p.parseSelect()
+			if err != nil {
+				return nil, err
+			}
+			expr = &Subquery{node: at, Select: sub}
+		} else {
+			var err error
+			if expr, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+
+	case tok.is("NULL"):
+		p.advance()
+		return &Literal{node: at, Kind: Null}, nil
+
+	case tok.is("CASE"):
+		return p.parseCase()
+
+	case tok.is("EXISTS"):
+		p.advance()
+		if _, err := p.expect("("); err != nil {
+			return nil, err
+		}
+		sub, err := p.parseSelect()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &Exists{node: at, Select: sub}, nil
+
+	case tok.is("CAST"):
+		return p.parseCast()
+
+	case tok.kind == tokWord && p.peekAt(1).is("(") && (!p.isKeyword() || tok.is("LEFT") || tok.is("RIGHT")):
+		return p.parseCall()
+	}
+
+	if tok.kind != tokWord && tok.kind != tokQuoted || p.isKeyword() {
+		return nil, p.unexpected("expression")
+	}
+	parts, err := p.dottedName()
+	if err != nil {
+		return nil, err
+	}
+	return &Path{node: at, Parts: parts}, nil
+}
+
+func (p *parser) parseCall() (Expr, error) {
+	name := p.advance()
+	p.advance() // (
+	call := &Call{node: node{name.pos}, Name: name.text}
+
+	if p.accept(")") {
+		return call, nil
+	}
+	if star := p.cur(); star.is("*") {
+		p.advance()
+		call.Args = []Expr{&Star{node: node{star.pos}}}
+	} else {
+		call.Distinct = p.accept("DISTINCT")
+		args, err := p.parseExprList()
+		if err != nil {
+			return nil, err
+		}
+		call.Args = args
+	}
+
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return call, nil
+}
+
+func (p *parser) parseCase() (Expr, error) {
+	start := p.advance()
+	c := &Case{node: node{start.pos}}
+
+	var err error
+	if !p.cur().is("WHEN") && !p.cur().is("ELSE") && !p.cur().is("END") {
+		if c.Operand, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+
+	for p.cur().is("WHEN") {
+		when := p.advance()
+		cond, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect("THEN"); err != nil {
+			return nil, err
+		}
+		result, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		c.Whens = append(c.Whens, &When{node: node{when.pos}, Cond: cond, Result: result})
+	}
+	if len(c.Whens) == 0 {
+		return nil, p.unexpected("WHEN")
+	}
+
+	if p.accept("ELSE") {
+		if c.Else, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := p.expect("END"); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (p *parser) parseCast() (Expr, error) {
+	start := p.advance()
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	x, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("AS"); err != nil {
+		return nil, err
+	}
+
+	typeName, err := p.dottedName()
+	if err != nil {
+		return nil, p.unexpected("type name")
+	}
+	typ := strings.Join(typeName, ".")
+
+	// Allow sizes such as NVARCHAR(50)
+	if p.accept("(") {
+		var sizes []string
+		for {
+			n := p.cur()
+			if n.kind != tokNumber && !n.is("MAX") {
+				return nil, p.unexpected("size")
+			}
+			p.advance()
+			sizes = append(sizes, n.text)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		typ += "(" + strings.Join(sizes, ",") + ")"
+	}
+
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &Cast{node: node{start.pos}, X: x, Type: typ}, nil
+}
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Valid(t *testing.T) {
+	queries := map[string]string{
+		"simple":           "SELECT NodeID, Caption FROM Orion.Nodes",
+		"star":             "SELECT * FROM Orion.Nodes",
+		"alias star":       "SELECT n.*, i.Name FROM Orion.Nodes n JOIN Orion.NPM.Interfaces i ON i.NodeID = n.NodeID",
+		"top and distinct": "SELECT DISTINCT TOP 10 Vendor FROM Orion.Nodes",
+		"top parameter":    "SELECT TOP @limit Caption FROM Orion.Nodes",
+		"aliases":          "SELECT Caption AS Name, IPAddress Address, Vendor AS 'Make', [Status] AS [My Status] FROM Orion.Nodes AS n",
+		"navigation":       "SELECT n.Caption, n.Interfaces.Name, n.CustomProperties.City FROM Orion.Nodes n WHERE n.Interfaces.Status = 2",
+		"joins": `SELECT n.Caption, v.Caption, i.Name
+			FROM Orion.Nodes n
+			INNER JOIN Orion.Volumes v ON v.NodeID = n.NodeID
+			LEFT OUTER JOIN Orion.NPM.Interfaces i ON i.NodeID = n.NodeID AND i.Status <> 1
+			RIGHT JOIN Orion.Accounts a ON a.AccountID = n.Contact
+			FULL JOIN Orion.Events e ON e.NetObjectID = n.NodeID`,
+		"functions":        "SELECT TOLOCAL(LastBoot) AS Boot, ISNULL(Location, 'n/a') FROM Orion.Nodes WHERE LastBoot > ADDDAY(-7, GETUTCDATE())",
+		"aggregates":       "SELECT Vendor, COUNT(*) AS Total, COUNT(DISTINCT MachineType), AVG(CPULoad) FROM Orion.Nodes GROUP BY Vendor HAVING COUNT(NodeID) > 5 ORDER BY Total DESC, Vendor ASC",
+		"predicates":       "SELECT NodeID FROM Orion.Nodes WHERE Status IN (1, 2, 3) AND Vendor NOT IN ('a', 'b') AND Caption LIKE 'core%' AND Caption NOT LIKE '%lab%' AND CPULoad BETWEEN 10 AND 90 AND Location IS NULL AND Contact IS NOT NULL",
+		"not":              "SELECT NodeID FROM Orion.Nodes WHERE NOT (Status = 1 OR Status = 2)",
+		"arithmetic":       "SELECT TotalMemory / 1024.0 * 100 % 7 - -1 + 2.5e3 FROM Orion.Nodes",
+		"in subquery":      "SELECT Caption FROM Orion.Nodes WHERE NodeID IN (SELECT NodeID FROM Orion.Volumes WHERE VolumePercentUsed > @pct)",
+		"exists":           "SELECT Caption FROM Orion.Nodes n WHERE EXISTS (SELECT 1 FROM Orion.Volumes v WHERE v.NodeID = n.NodeID)",
+		"scalar query":     "SELECT Caption, (SELECT COUNT(*) FROM Orion.Volumes v WHERE v.NodeID = n.NodeID) AS Volumes FROM Orion.Nodes n",
+		"from subquery":    "SELECT x.Vendor FROM (SELECT Vendor FROM Orion.Nodes) x",
+		"case":             "SELECT CASE Status WHEN 1 THEN 'Up' WHEN 2 THEN 'Down' ELSE 'Other' END, CASE WHEN CPULoad > 90 THEN 1 END FROM Orion.Nodes",
+		"cast":             "SELECT CAST(NodeID AS NVARCHAR(50)), CAST(CPULoad AS System.Int32) FROM Orion.Nodes",
+		"paging":           "SELECT NodeID FROM Orion.Nodes ORDER BY NodeID\nWITH ROWS 1 TO 1000 WITH TOTALROWS",
+		"totalrows first":  "SELECT NodeID FROM Orion.Nodes WITH TOTALROWS WITH ROWS @first TO @last",
+		"return xml":       "SELECT NodeID FROM Orion.Nodes RETURN XML RAW",
+		"union":            "SELECT Caption FROM Orion.Nodes UNION ALL SELECT Caption FROM Orion.Volumes",
+		"union operand":    "SELECT Caption FROM Orion.Nodes UNION (SELECT Caption FROM Orion.Volumes) UNION ALL (SELECT Name FROM Orion.NPM.Interfaces)",
+		"table hint":       "SELECT NodeID FROM Orion.Nodes (nolock=true) WHERE Status = 1",
+		"aliased hints":    "SELECT n.NodeID FROM Orion.Nodes n (nolock=true, timeout=30) JOIN Orion.Volumes (nolock=true) v ON v.NodeID = n.NodeID",
+		"comments":         "-- nodes\nSELECT NodeID /* the id */ FROM Orion.Nodes; ",
+		"brackets":         "SELECT [Order], n.[My.Prop], [a]]b] FROM Orion.Nodes n",
+		"no from":          "SELECT GETUTCDATE() AS Now",
+		"comma join":       "SELECT n.Caption FROM Orion.Nodes n, Orion.Volumes v WHERE v.NodeID = n.NodeID",
+		"left function":    "SELECT LEFT(Caption, 3) FROM Orion.Nodes",
+		"keyword property": "SELECT n.Order FROM Orion.Nodes n",
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(query)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestParse_SyntaxErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		line   int
+		column int
+		msg    string
+	}{
+		{"misspelled FROM", "SELECT Caption FORM Orion.Nodes", 1, 21, `expected end of query, found "Orion"`},
+		{"missing column list", "SELECT FROM Orion.Nodes", 1, 8, `expected expression, found "FROM"`},
+		{"dangling comparison", "SELECT NodeID\nFROM Orion.Nodes\nWHERE Status = ", 3, 16, "expected expression, found end of query"},
+		{"unterminated string", "SELECT NodeID FROM Orion.Nodes\n  WHERE Caption = 'abc", 2, 19, "unterminated string"},
+		{"unterminated bracket", "SELECT [Caption FROM Orion.Nodes", 1, 8, "unterminated bracketed identifier"},
+		{"unterminated comment", "SELECT NodeID /* FROM Orion.Nodes", 1, 15, "unterminated comment"},
+		{"join without on", "SELECT n.NodeID FROM Orion.Nodes n JOIN Orion.Volumes v WHERE 1 = 1", 1, 57, `expected ON, found "WHERE"`},
+		{"group without by", "SELECT Vendor FROM Orion.Nodes GROUP Vendor", 1, 38, `expected BY, found "Vendor"`},
+		{"unclosed paren", "SELECT COUNT(NodeID FROM Orion.Nodes", 1, 21, `expected ), found "FROM"`},
+		{"bad character", "SELECT NodeID FROM Orion.Nodes WHERE Status == 1", 1, 46, `expected expression, found "="`},
+		{"unknown character", "SELECT NodeID FROM Orion.Nodes WHERE Status ? 1", 1, 45, `unexpected character '?'`},
+		{"bad with", "SELECT NodeID FROM Orion.Nodes WITH NOLOCK", 1, 37, `expected ROWS or TOTALROWS, found "NOLOCK"`},
+		{"rows without to", "SELECT NodeID FROM Orion.Nodes WITH ROWS 1 10", 1, 44, `expected TO, found "10"`},
+		{"empty parameter", "SELECT NodeID FROM Orion.Nodes WHERE NodeID = @", 1, 47, "expected parameter name after @"},
+		{"bad number", "SELECT 1abc FROM Orion.Nodes", 1, 8, `invalid number "1a"`},
+		{"case without when", "SELECT CASE ELSE 1 END FROM Orion.Nodes", 1, 13, `expected WHEN, found "ELSE"`},
+		{"wide characters", "SELECT 'héllo' FROM Orion.Nodes WHERE", 1, 38, "expected expression, found end of query"},
+		{"unclosed union", "SELECT 1 UNION (SELECT 2", 1, 25, "expected ), found end of query"},
+		{"hint without value", "SELECT NodeID FROM Orion.Nodes (nolock)", 1, 39, `expected =, found ")"`},
+		{"not a select", "UPDATE Orion.Nodes SET Caption = 'x'", 1, 1, `expected SELECT, found "UPDATE"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query)
+			require.Error(t, err)
+
+			var syntaxErr *SyntaxError
+			require.True(t, errors.As(err, &syntaxErr), "got %T", err)
+			assert.Equal(t, tt.line, syntaxErr.Line, "line")
+			assert.Equal(t, tt.column, syntaxErr.Column, "column")
+			assert.Equal(t, tt.msg, syntaxErr.Msg)
+		})
+	}
+}
+
+func TestParse_Tree(t *testing.T) {
+	sel, err := Parse("SELECT TOP 5 n.Caption AS Name, COUNT(*) FROM Orion.Nodes n LEFT JOIN Orion.Volumes v ON v.NodeID = n.NodeID WHERE n.Vendor = @vendor AND n.Status IN (1, 3) ORDER BY Name DESC WITH ROWS 1 TO 5")
+	require.NoError(t, err)
+
+	assert.Equal(t, &Literal{node: node{Pos{Offset: 11, Line: 1, Column: 12}}, Kind: Number, Value: "5"}, sel.Top)
+
+	require.Len(t, sel.Columns, 2)
+	assert.Equal(t, "Name", sel.Columns[0].Alias)
+	assert.Equal(t, []string{"n", "Caption"}, sel.Columns[0].Expr.(*Path).Parts)
+	count := sel.Columns[1].Expr.(*Call)
+	assert.Equal(t, "COUNT", count.Name)
+	assert.IsType(t, &Star{}, count.Args[0])
+
+	assert.Equal(t, "Orion.Nodes", sel.From.Entity)
+	assert.Equal(t, "n", sel.From.Alias)
+	require.Len(t, sel.Joins, 1)
+	assert.Equal(t, "LEFT", sel.Joins[0].Kind)
+	assert.Equal(t, "Orion.Volumes", sel.Joins[0].Source.Entity)
+
+	where := sel.Where.(*Binary)
+	assert.Equal(t, "AND", where.Op)
+	assert.Equal(t, "vendor", where.Left.(*Binary).Right.(*Param).Name)
+	assert.Len(t, where.Right.(*In).List, 2)
+
+	require.Len(t, sel.OrderBy, 1)
+	assert.True(t, sel.OrderBy[0].Desc)
+	require.NotNil(t, sel.Rows)
+	assert.Equal(t, "1", sel.Rows.First.(*Literal).Value)
+	assert.False(t, sel.TotalRows)
+}
+
+func TestParse_HintsAndUnions(t *testing.T) {
+	sel, err := Parse("SELECT n.Caption FROM Orion.Nodes (nolock=true) n UNION ALL (SELECT Caption FROM Orion.Volumes WITH ROWS 1 TO 5) UNION SELECT Name FROM Orion.NPM.Interfaces")
+	require.NoError(t, err)
+
+	assert.Equal(t, "n", sel.From.Alias)
+	require.Len(t, sel.From.Hints, 1)
+	assert.Equal(t, "nolock", sel.From.Hints[0].Name)
+	assert.Equal(t, "true", sel.From.Hints[0].Value)
+
+	assert.True(t, sel.UnionAll)
+	require.NotNil(t, sel.Union)
+	assert.Equal(t, "Orion.Volumes", sel.Union.From.Entity)
+	assert.NotNil(t, sel.Union.Rows)
+	require.NotNil(t, sel.Union.Union)
+	assert.False(t, sel.Union.UnionAll)
+	assert.Equal(t, []string{"Orion.Nodes", "Orion.Volumes", "Orion.NPM.Interfaces"}, sel.Entities())
+}
+
+func TestParse_Precedence(t *testing.T) {
+	sel, err := Parse("SELECT NodeID FROM Orion.Nodes WHERE NOT a = 1 OR b = 2 AND c + 1 * 2 > 3")
+	require.NoError(t, err)
+
+	or := sel.Where.(*Binary)
+	assert.Equal(t, "OR", or.Op)
+	assert.Equal(t, "NOT", or.Left.(*Unary).Op)
+
+	and := or.Right.(*Binary)
+	assert.Equal(t, "AND", and.Op)
+
+	gt := and.Right.(*Binary)
+	assert.Equal(t, ">", gt.Op)
+	plus := gt.Left.(*Binary)
+	assert.Equal(t, "+", plus.Op)
+	assert.Equal(t, "*", plus.Right.(*Binary).Op)
+}
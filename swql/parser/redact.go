@@ -0,0 +1,51 @@
+package parser
+
+import "strings"
+
+// Redact returns the query with its string and number literals replaced by
+// ?, so it can be logged or traced without the values written into it.
+// Comments, which can hold values as well, are dropped. Parameters,
+// identifiers and layout are kept.
+func Redact(query string) (string, error) {
+	lx := &lexer{src: query, line: 1, col: 1}
+	var b strings.Builder
+	last := 0
+	for {
+		tok, err := lx.next()
+		if err != nil {
+			return "", err
+		}
+		if tok.kind == tokEOF {
+			break
+		}
+		writeSpace(&b, query[last:tok.pos.Offset])
+		if tok.kind == tokString || tok.kind == tokNumber {
+			b.WriteByte('?')
+		} else {
+			b.WriteString(query[tok.pos.Offset:lx.off])
+		}
+		last = lx.off
+	}
+	writeSpace(&b, query[last:])
+	return b.String(), nil
+}
+
+// writeSpace writes the whitespace between two tokens, replacing block
+// comments with a space and dropping line comments
+func writeSpace(b *strings.Builder, gap string) {
+	for i := 0; i < len(gap); i++ {
+		switch {
+		case strings.HasPrefix(gap[i:], "--"):
+			end := strings.IndexByte(gap[i:], '\n')
+			if end < 0 {
+				return
+			}
+			i += end - 1
+		case strings.HasPrefix(gap[i:], "/*"):
+			i += strings.Index(gap[i+2:], "*/") + 3
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(gap[i])
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT Caption FROM Orion.Nodes", "SELECT Caption FROM Orion.Nodes"},
+		{"SELECT TOP 10 Caption FROM Orion.Nodes WHERE Vendor = 'Cisco' AND CPULoad > 80.5",
+			"SELECT TOP ? Caption FROM Orion.Nodes WHERE Vendor = ? AND CPULoad > ?"},
+		{"SELECT Caption FROM Orion.Nodes WHERE Caption = 'O''Brien''s switch' AND NodeID = @id",
+			"SELECT Caption FROM Orion.Nodes WHERE Caption = ? AND NodeID = @id"},
+		{"SELECT [Node 2].Caption\nFROM Orion.Nodes [Node 2]\nWHERE IP IN ('10.0.0.1', '10.0.0.2')",
+			"SELECT [Node 2].Caption\nFROM Orion.Nodes [Node 2]\nWHERE IP IN (?, ?)"},
+		{"SELECT Caption -- password 'hunter2'\nFROM Orion.Nodes/* token abc123 */WHERE NodeID = 5 -- trailing",
+			"SELECT Caption \nFROM Orion.Nodes WHERE NodeID = ? "},
+		{"/* leading */SELECT Caption FROM Orion.Nodes", " SELECT Caption FROM Orion.Nodes"},
+	}
+
+	for _, tt := range tests {
+		got, err := Redact(tt.query)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := Redact("SELECT 'unterminated FROM Orion.Nodes")
+	assert.Error(t, err)
+}
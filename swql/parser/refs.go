@@ -0,0 +1,158 @@
+package parser
+
+import "strings"
+
+// ColumnRef is a column or navigation property referenced by a query
+type ColumnRef struct {
+	// Entity is the entity the column belongs to, resolved through table
+	// aliases. It is empty when the entity can't be determined, such as for
+	// an unqualified column in a query with joins.
+	Entity string
+
+	// Path is the column or navigation property path, relative to Entity
+	// when that is known and as written otherwise
+	Path string
+
+	// Pos is where the column is first referenced
+	Pos Pos
+}
+
+// Entities returns the entities the query reads from, including joins and
+// subqueries, in order of first appearance
+func (s *Select) Entities() []string {
+	var entities []string
+	seen := map[string]bool{}
+	Inspect(s, func(n Node) bool {
+		if src, ok := n.(*Source); ok && src.Entity != "" {
+			key := strings.ToLower(src.Entity)
+			if !seen[key] {
+				seen[key] = true
+				entities = append(entities, src.Entity)
+			}
+		}
+		return true
+	})
+	return entities
+}
+
+// Parameters returns the names of the @parameters the query uses, without
+// the @, in order of first appearance. Names are compared case-insensitively.
+func (s *Select) Parameters() []string {
+	var params []string
+	seen := map[string]bool{}
+	Inspect(s, func(n Node) bool {
+		if p, ok := n.(*Param); ok {
+			key := strings.ToLower(p.Name)
+			if !seen[key] {
+				seen[key] = true
+				params = append(params, p.Name)
+			}
+		}
+		return true
+	})
+	return params
+}
+
+// ColumnRefs returns the columns and navigation properties the query
+// references, in order of first appearance. References to SELECT list
+// aliases and * are left out.
+func (s *Select) ColumnRefs() []ColumnRef {
+	c := &columnCollector{seen: map[string]bool{}}
+	c.collect(s, nil)
+	return c.refs
+}
+
+// scope holds the table aliases visible in one SELECT
+type scope struct {
+	aliases map[string]string
+	parent  *scope
+}
+
+func (sc *scope) lookup(alias string) (string, bool) {
+	for ; sc != nil; sc = sc.parent {
+		if entity, ok := sc.aliases[strings.ToLower(alias)]; ok {
+			return entity, true
+		}
+	}
+	return "", false
+}
+
+type columnCollector struct {
+	refs []ColumnRef
+	seen map[string]bool
+}
+
+func (c *columnCollector) collect(sel *Select, outer *scope) {
+	sc := &scope{aliases: map[string]string{}, parent: outer}
+	sources := []*Source{}
+	if sel.From != nil {
+		sources = append(sources, sel.From)
+	}
+	for _, j := range sel.Joins {
+		sources = append(sources, j.Source)
+	}
+	for _, src := range sources {
+		if src.Alias != "" {
+			sc.aliases[strings.ToLower(src.Alias)] = src.Entity
+		}
+	}
+
+	// Unqualified columns belong to the only source, if there is just one
+	var only string
+	if len(sources) == 1 {
+		only = sources[0].Entity
+	}
+
+	selectAliases := map[string]bool{}
+	for _, col := range sel.Columns {
+		if col.Alias != "" {
+			selectAliases[strings.ToLower(col.Alias)] = true
+		}
+	}
+
+	var nested []*Select
+	Inspect(sel, func(n Node) bool {
+		switch n := n.(type) {
+		case *Select:
+			if n != sel {
+				nested = append(nested, n)
+				return false
+			}
+		case *Path:
+			c.add(n, sc, only, selectAliases)
+		}
+		return true
+	})
+
+	for _, sub := range nested {
+		if sub == sel.Union {
+			c.collect(sub, outer)
+		} else {
+			c.collect(sub, sc)
+		}
+	}
+}
+
+func (c *columnCollector) add(p *Path, sc *scope, only string, selectAliases map[string]bool) {
+	ref := ColumnRef{Path: p.String(), Pos: p.Pos()}
+
+	switch entity, ok := sc.lookup(p.Parts[0]); {
+	case ok && len(p.Parts) > 1:
+		ref.Entity = entity
+		ref.Path = strings.Join(p.Parts[1:], ".")
+	case ok:
+		// A bare alias, as in COUNT(n); not a column
+		return
+	case len(p.Parts) == 1 && selectAliases[strings.ToLower(p.Parts[0])]:
+		return
+	default:
+		ref.Entity = only
+	}
+
+	key := strings.ToLower(ref.Entity + "\x00" + ref.Path)
+	if c.seen[key] {
+		return
+	}
+	c.seen[key] = true
+	c.refs = append(c.refs, ref)
+}
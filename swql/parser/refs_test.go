@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const refsQuery = `SELECT n.Caption, n.Interfaces.Name, v.VolumeSize AS Size, COUNT(n) AS Total
+FROM Orion.Nodes n
+LEFT JOIN Orion.Volumes v ON v.NodeID = n.NodeID
+WHERE n.Vendor = @vendor AND n.Status IN (@up, @warning)
+  AND n.NodeID IN (SELECT a.NodeID FROM Orion.AlertActive a WHERE a.Acknowledged = @ack)
+  AND EXISTS (SELECT 1 FROM Orion.Events e WHERE e.NetObjectID = n.NodeID AND EventType = @VENDOR)
+ORDER BY Size DESC, n.caption`
+
+func TestSelect_Entities(t *testing.T) {
+	sel, err := Parse(refsQuery)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Orion.Nodes", "Orion.Volumes", "Orion.AlertActive", "Orion.Events"}, sel.Entities())
+}
+
+func TestSelect_Parameters(t *testing.T) {
+	sel, err := Parse(refsQuery)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"vendor", "up", "warning", "ack"}, sel.Parameters())
+}
+
+func TestSelect_ColumnRefs(t *testing.T) {
+	sel, err := Parse(refsQuery)
+	require.NoError(t, err)
+
+	var got []ColumnRef
+	for _, ref := range sel.ColumnRefs() {
+		got = append(got, ColumnRef{Entity: ref.Entity, Path: ref.Path})
+	}
+
+	assert.Equal(t, []ColumnRef{
+		{Entity: "Orion.Nodes", Path: "Caption"},
+		{Entity: "Orion.Nodes", Path: "Interfaces.Name"},
+		{Entity: "Orion.Volumes", Path: "VolumeSize"},
+		{Entity: "Orion.Volumes", Path: "NodeID"},
+		{Entity: "Orion.Nodes", Path: "NodeID"},
+		{Entity: "Orion.Nodes", Path: "Vendor"},
+		{Entity: "Orion.Nodes", Path: "Status"},
+		{Entity: "Orion.AlertActive", Path: "NodeID"},
+		{Entity: "Orion.AlertActive", Path: "Acknowledged"},
+		{Entity: "Orion.Events", Path: "NetObjectID"},
+		{Entity: "Orion.Events", Path: "EventType"},
+	}, got)
+}
+
+func TestSelect_ColumnRefs_Unresolved(t *testing.T) {
+	sel, err := Parse("SELECT Caption FROM Orion.Nodes n JOIN Orion.Volumes v ON v.NodeID = n.NodeID")
+	require.NoError(t, err)
+
+	refs := sel.ColumnRefs()
+	require.NotEmpty(t, refs)
+	assert.Equal(t, "", refs[0].Entity)
+	assert.Equal(t, "Caption", refs[0].Path)
+	assert.Equal(t, Pos{Offset: 7, Line: 1, Column: 8}, refs[0].Pos)
+}
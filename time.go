@@ -0,0 +1,194 @@
+package gosolar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// swisTimeFormat is the form SWIS uses for DateTime values and accepts in
+// Update bodies and query parameters
+const swisTimeFormat = "2006-01-02T15:04:05.9999999"
+
+// dateTimeLayouts are the forms SWIS uses for DateTime values, tried in
+// order. Layouts without a zone are read in the server's time zone.
+var dateTimeLayouts = []string{
+	time.RFC3339Nano,
+	swisTimeFormat,
+	"2006-01-02 15:04:05.9999999",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// msDatePattern matches the WCF "/Date(1714566896123+0000)/" form
+var msDatePattern = regexp.MustCompile(`^/Date\((-?\d+)([+-]\d{4})?\)/$`)
+
+// Time is a time.Time that decodes every DateTime form SWIS returns: ISO
+// 8601 with or without an offset and with up to 7 fractional digits, a space
+// instead of the T, and the WCF /Date(ms)/ form. A JSON null decodes to the
+// zero time and the zero time encodes to null.
+//
+// Values without an offset are taken to be UTC, which is what SWIS normally
+// returns. A client with Config.ServerLocation set reads them in that zone
+// instead when it decodes struct rows, verb results and values itself.
+type Time struct {
+	time.Time
+}
+
+// NewTime wraps a time.Time
+func NewTime(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// ParseTime parses a SWIS DateTime string. Values without an offset are read
+// in loc, or in UTC if loc is nil.
+func ParseTime(s string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	s = strings.TrimSpace(s)
+	if m := msDatePattern.FindStringSubmatch(s); m != nil {
+		ms, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.UnixMilli(ms).UTC(), nil
+	}
+
+	var err error
+	for _, layout := range dateTimeLayouts {
+		var t time.Time
+		if t, err = time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("gosolar: cannot parse %q as a SWIS DateTime", s)
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (t *Time) UnmarshalJSON(data []byte) error {
+	return t.parseJSON(data, time.UTC)
+}
+
+// parseJSON decodes a JSON DateTime, reading values without an offset in loc
+func (t *Time) parseJSON(data []byte, loc *time.Location) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("gosolar: DateTime must be a JSON string, got %s", data)
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+
+	parsed, err := ParseTime(s, loc)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, writing the time in UTC without an
+// offset, the way SWIS itself formats DateTime values
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.String())
+}
+
+// String returns the time in the format SWIS accepts
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.format(time.UTC)
+}
+
+// format writes the time in loc in the format SWIS accepts
+func (t Time) format(loc *time.Location) string {
+	return t.In(loc).Format(swisTimeFormat)
+}
+
+// location returns the time zone of the client's SWIS server
+func (c *Client) location() *time.Location {
+	if c.config.ServerLocation != nil {
+		return c.config.ServerLocation
+	}
+	return time.UTC
+}
+
+// localParams returns query parameters or an Update body with its top-level
+// Time values written in the client's ServerLocation. Other values, and
+// time.Time values which carry their offset, are left for encoding/json.
+func (c *Client) localParams(v interface{}) interface{} {
+	loc := c.location()
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if loc == time.UTC || !rv.IsValid() {
+		return v
+	}
+
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = localTime(iter.Value().Interface(), loc)
+		}
+		return out
+
+	case isStructRow(rv.Type()):
+		data, err := json.Marshal(v)
+		if err != nil {
+			return v
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return v
+		}
+		out := make(map[string]interface{}, len(fields))
+		for name, value := range fields {
+			out[name] = value
+		}
+		for i := 0; i < rv.NumField(); i++ {
+			sf := rv.Type().Field(i)
+			name := strings.Split(sf.Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = sf.Name
+			}
+			if _, ok := out[name]; ok && sf.IsExported() {
+				out[name] = localTime(rv.Field(i).Interface(), loc)
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// localTime formats a Time value in loc, returning other values as they are
+func localTime(v interface{}, loc *time.Location) interface{} {
+	switch t := v.(type) {
+	case Time:
+		if !t.IsZero() {
+			return t.format(loc)
+		}
+	case *Time:
+		if t != nil && !t.IsZero() {
+			return t.format(loc)
+		}
+	}
+	return v
+}
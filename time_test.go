@@ -0,0 +1,172 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTime_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  time.Time
+	}{
+		{
+			name:  "zone-less with milliseconds",
+			input: `"2024-05-01T12:34:56.123"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 123000000, time.UTC),
+		},
+		{
+			name:  "zone-less with 7 fractional digits",
+			input: `"2024-05-01T12:34:56.1234567"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 123456700, time.UTC),
+		},
+		{
+			name:  "zone-less without fraction",
+			input: `"2024-05-01T12:34:56"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{
+			name:  "UTC designator",
+			input: `"2024-05-01T12:34:56.1234567Z"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 123456700, time.UTC),
+		},
+		{
+			name:  "offset",
+			input: `"2024-05-01T14:34:56+02:00"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{
+			name:  "space separator",
+			input: `"2024-05-01 12:34:56"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 0, time.UTC),
+		},
+		{
+			name:  "WCF date",
+			input: `"/Date(1714566896123+0000)/"`,
+			want:  time.Date(2024, 5, 1, 12, 34, 56, 123000000, time.UTC),
+		},
+		{
+			name:  "null",
+			input: `null`,
+		},
+		{
+			name:  "empty string",
+			input: `""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Time
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &got))
+			assert.True(t, tt.want.Equal(got.Time), "got %v, want %v", got.Time, tt.want)
+			assert.Equal(t, tt.want.IsZero(), got.IsZero())
+		})
+	}
+}
+
+func TestTime_UnmarshalJSONInvalid(t *testing.T) {
+	var got Time
+	assert.Error(t, json.Unmarshal([]byte(`"yesterday"`), &got))
+	assert.Error(t, json.Unmarshal([]byte(`12345`), &got))
+}
+
+func TestTime_MarshalJSON(t *testing.T) {
+	data, err := json.Marshal(NewTime(time.Date(2024, 5, 1, 14, 34, 56, 123456700, time.FixedZone("CEST", 2*3600))))
+	require.NoError(t, err)
+	assert.Equal(t, `"2024-05-01T12:34:56.1234567"`, string(data))
+
+	data, err = json.Marshal(Time{})
+	require.NoError(t, err)
+	assert.Equal(t, `null`, string(data))
+
+	body, err := json.Marshal(map[string]interface{}{"UnManageUntil": NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"UnManageUntil":"2024-05-01T12:00:00"}`, string(body))
+}
+
+func TestParseTime_Location(t *testing.T) {
+	est := time.FixedZone("EST", -5*3600)
+
+	parsed, err := ParseTime("2024-05-01T07:00:00", est)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(parsed))
+
+	parsed, err = ParseTime("2024-05-01T07:00:00Z", est)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC).Equal(parsed))
+
+	parsed, err = ParseTime("2024-05-01T07:00:00", nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC), parsed)
+}
+
+func TestTime_PredefinedTypes(t *testing.T) {
+	var nodes []CommonNode
+	require.NoError(t, json.Unmarshal([]byte(`[{"NodeID":1,"LastBoot":"2024-05-01T12:34:56.123"},{"NodeID":2,"LastBoot":null}]`), &nodes))
+	require.Len(t, nodes, 2)
+
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 34, 56, 123000000, time.UTC), nodes[0].LastBoot.Time)
+	assert.True(t, nodes[1].LastBoot.IsZero())
+}
+
+func TestClient_ServerLocation(t *testing.T) {
+	var received []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		received = append(received, req.Parameters)
+		_, _ = w.Write([]byte(`{"results":[{"LastBoot":"2024-05-01T07:00:00","NodeID":1,"Description":"2024-05-01T07:00:00"}]}`))
+	}))
+	defer server.Close()
+
+	utc := newTestClient(t, server)
+	est := newTestClient(t, server)
+	est.config.ServerLocation = time.FixedZone("EST", -5*3600)
+
+	since := map[string]interface{}{"since": NewTime(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))}
+	ctx := context.Background()
+
+	nodes, err := QueryAs[CommonNode](ctx, utc, "SELECT LastBoot, NodeID FROM Orion.Nodes WHERE LastBoot > @since", since)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 7, 0, 0, 0, time.UTC).Equal(nodes[0].LastBoot.Time))
+
+	nodes, err = QueryAs[CommonNode](ctx, est, "SELECT LastBoot, NodeID FROM Orion.Nodes WHERE LastBoot > @since", since)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(nodes[0].LastBoot.Time))
+	assert.Equal(t, "2024-05-01T07:00:00", nodes[0].Description, "only DateTime fields are read in the location")
+
+	var scanned []CommonNode
+	require.NoError(t, est.queryScan(ctx, "nodes", "SELECT LastBoot, NodeID FROM Orion.Nodes", nil, &scanned))
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(scanned[0].LastBoot.Time))
+
+	boot, err := QueryValueAs[time.Time](ctx, est, "SELECT LastBoot FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(boot))
+
+	rows, err := est.QueryIterContext(ctx, "SELECT LastBoot, NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next())
+	var node CommonNode
+	require.NoError(t, rows.Scan(&node))
+	assert.True(t, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Equal(node.LastBoot.Time))
+
+	raw, err := QueryAs[map[string]interface{}](ctx, est, "SELECT LastBoot FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-01T07:00:00", raw[0]["LastBoot"])
+
+	require.Len(t, received, 6)
+	assert.Equal(t, "2024-05-01T12:00:00", received[0]["since"])
+	assert.Equal(t, "2024-05-01T07:00:00", received[1]["since"])
+}
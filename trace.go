@@ -0,0 +1,198 @@
+package gosolar
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Tracer traces the calls a client makes to SWIS and every attempt of them.
+// The gosolarotel package in contrib implements it with OpenTelemetry
+// without adding the dependency to this module.
+type Tracer interface {
+	// StartCall runs before the first attempt of a call. The attempts are
+	// made with the returned context, and end is called once the call is
+	// over.
+	StartCall(ctx context.Context, call CallInfo) (_ context.Context, end func(CallResult))
+
+	// StartAttempt runs before each attempt is sent. It returns the request
+	// to send, typically req with a new context and trace headers, and a
+	// function called with the HTTP status (0 if no response was received)
+	// and the error the attempt failed with, if any.
+	StartAttempt(req *http.Request, attempt int) (_ *http.Request, end func(statusCode int, err error))
+}
+
+// CallInfo describes a call to SWIS before it's made
+type CallInfo struct {
+	// Operation, Entity and Verb are as in RequestMetrics
+	Operation string
+	Entity    string
+	Verb      string
+
+	Method   string
+	Endpoint string
+
+	// Statement is the SWQL of a query with its literals replaced by ?.
+	// Parameter values are sent separately and never included.
+	Statement string
+}
+
+// CallResult describes a call to SWIS once it's over
+type CallResult struct {
+	// StatusCode is the HTTP status of the last attempt, or 0 if no response
+	// was received
+	StatusCode int
+
+	Attempts int
+
+	// Rows is the number of rows a query returned, or -1 when it isn't known,
+	// such as for other operations and for streamed queries
+	Rows int
+
+	// Err is the *Error the call failed with, or nil
+	Err error
+}
+
+// call is a request made by send, shared by the middleware it passes
+// through
+type call struct {
+	method     string
+	endpoint   string
+	payload    []byte
+	idempotent bool
+
+	// stream leaves the response body unread for the caller to stream
+	stream bool
+
+	info       CallInfo
+	start      time.Time
+	statusCode int
+	attempts   int
+	size       int64
+	rows       int
+	endSpan    func(CallResult)
+}
+
+type callKey struct{}
+
+// callFromContext returns the call a request is made for, or nil
+func callFromContext(ctx context.Context) *call {
+	cl, _ := ctx.Value(callKey{}).(*call)
+	return cl
+}
+
+// startCall describes the call and starts its span
+func (c *Client) startCall(ctx context.Context, cl *call) context.Context {
+	cl.info = describeRequest(cl.method, cl.endpoint, cl.payload, c.queries)
+	cl.start = time.Now()
+	if c.config.Tracer != nil {
+		ctx, cl.endSpan = c.config.Tracer.StartCall(ctx, cl.info)
+	}
+	return ctx
+}
+
+// finishCall reports the call to the metrics recorder and ends its span
+func (c *Client) finishCall(ctx context.Context, cl *call, swErr *Error) {
+	if c.config.Metrics != nil {
+		m := RequestMetrics{
+			Operation:    cl.info.Operation,
+			Entity:       cl.info.Entity,
+			Verb:         cl.info.Verb,
+			Method:       cl.info.Method,
+			Endpoint:     cl.info.Endpoint,
+			StatusCode:   cl.statusCode,
+			Duration:     time.Since(cl.start),
+			ResponseSize: cl.size,
+			Attempts:     cl.attempts,
+		}
+		if swErr != nil {
+			m.ErrorType = swErr.Type
+		}
+		c.config.Metrics.RecordRequest(ctx, m)
+	}
+
+	if cl.endSpan != nil {
+		result := CallResult{StatusCode: cl.statusCode, Attempts: cl.attempts, Rows: cl.rows}
+		if swErr != nil {
+			result.Err = swErr
+		}
+		cl.endSpan(result)
+	}
+}
+
+// countResults counts the rows of a query response by scanning for the
+// elements of its top-level results array without decoding them. It returns
+// -1 if there is no results array or the response can't be read.
+func countResults(data []byte) int {
+	var (
+		depth     int
+		rows      = -1
+		atResults bool // the next value is that of the top-level results key
+		inResults bool // inside the top-level results array
+		expectRow bool // the next value at depth 2 starts a row
+	)
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch b {
+		case ' ', '\t', '\r', '\n', ':':
+			continue
+		}
+
+		if inResults && depth == 2 && expectRow && b != ']' && b != ',' {
+			rows++
+			expectRow = false
+		}
+
+		switch b {
+		case '"':
+			start := i + 1
+			if i = skipString(data, i); i < 0 {
+				return -1
+			}
+			if depth == 1 && !inResults && rows < 0 {
+				atResults = string(data[start:i]) == "results" && nextByte(data, i+1) == ':'
+				continue
+			}
+		case '{', '[':
+			if atResults && b == '[' {
+				inResults, expectRow, rows = true, true, 0
+			}
+			depth++
+		case '}', ']':
+			depth--
+			if inResults && depth == 1 {
+				return rows
+			}
+		case ',':
+			expectRow = inResults && depth == 2
+		}
+		atResults = false
+	}
+	return -1
+}
+
+// skipString returns the index of the quote closing the string opening at
+// data[i], or -1 if it isn't closed
+func skipString(data []byte, i int) int {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// nextByte returns the first byte from data[i] on that isn't whitespace, or 0
+func nextByte(data []byte, i int) byte {
+	for ; i < len(data); i++ {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return data[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,98 @@
+package gosolar
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type traceKey struct{}
+
+// recordingTracer records spans as strings
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+func (r *recordingTracer) add(span string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *recordingTracer) StartCall(ctx context.Context, call CallInfo) (context.Context, func(CallResult)) {
+	return context.WithValue(ctx, traceKey{}, call.Operation), func(res CallResult) {
+		span := fmt.Sprintf("call %s %s %q status=%d attempts=%d rows=%d", call.Operation, call.Entity, call.Statement, res.StatusCode, res.Attempts, res.Rows)
+		if res.Err != nil {
+			span += " error=" + string(res.Err.(*Error).Type)
+		}
+		r.add(span)
+	}
+}
+
+func (r *recordingTracer) StartAttempt(req *http.Request, attempt int) (*http.Request, func(int, error)) {
+	parent, _ := req.Context().Value(traceKey{}).(string)
+	req = req.Clone(req.Context())
+	req.Header.Set("Traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	return req, func(status int, err error) {
+		r.add(fmt.Sprintf("attempt %d of %s status=%d failed=%t", attempt, parent, status, err != nil))
+	}
+}
+
+func TestTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	config := instrumentedConfig(t)
+	config.Tracer = tracer
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.QueryContext(ctx, "SELECT NodeID FROM Orion.Nodes WHERE Vendor = 'Cisco' AND NodeID > @id", map[string]interface{}{"id": 7})
+	require.NoError(t, err)
+	_, err = client.ReadURIContext(ctx, MustParseURI("swis://orion/Orion/Orion.Flaky/ID=1"))
+	require.NoError(t, err)
+	_, err = client.InvokeContext(ctx, "Orion.Missing", "PollNow", []string{"N:1"})
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"attempt 1 of Query status=200 failed=false",
+		`call Query Orion.Nodes "SELECT NodeID FROM Orion.Nodes WHERE Vendor = ? AND NodeID > @id" status=200 attempts=1 rows=2`,
+		"attempt 1 of Read status=503 failed=true",
+		"attempt 2 of Read status=200 failed=false",
+		`call Read Orion.Flaky "" status=200 attempts=2 rows=-1`,
+		"attempt 1 of Invoke status=404 failed=true",
+		`call Invoke Orion.Missing "" status=404 attempts=1 rows=-1 error=not_found`,
+	}, tracer.spans)
+}
+
+func TestCountResults(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"rows", `{"results":[{"NodeID":1},{"NodeID":2}]}`, 2},
+		{"empty", `{"results":[]}`, 0},
+		{"whitespace", "{ \"results\" : [ 1 , \"a\" ,\n null ] }", 3},
+		{"nested", `{"results":[{"Tags":[1,2,3],"Node":{"results":[1]}},[4,5]]}`, 2},
+		{"brackets in strings", `{"Note":"results","results":[{"Caption":"a],[b","Path":"c:\\\"d\\\",{"}]}`, 1},
+		{"results value", `{"Caption":"results","x":[1,2]}`, -1},
+		{"no results", `{"Message":"failed"}`, -1},
+		{"truncated", `{"results":[{"NodeID":1},`, -1},
+		{"not json", `oops`, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, countResults([]byte(tt.data)))
+		})
+	}
+
+	data := []byte(`{"results":[{"NodeID":1,"Caption":"core-01"},{"NodeID":2,"Caption":"core-02"}]}`)
+	assert.Zero(t, testing.AllocsPerRun(100, func() { countResults(data) }))
+}
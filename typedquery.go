@@ -0,0 +1,213 @@
+package gosolar
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryResultAs executes a SWQL query and decodes its rows into T, recording
+// the execution time, row count and query in the result's Meta
+func QueryResultAs[T any](ctx context.Context, c *Client, query string, parameters interface{}) (*QueryResult[T], error) {
+	start := time.Now()
+
+	res, err := c.QueryContext(ctx, query, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := unmarshalQueryResult[T](res, c.location())
+	if err != nil {
+		return nil, err
+	}
+
+	result.Meta = &QueryMeta{
+		ExecutionTime: time.Since(start),
+		RowCount:      result.Count,
+		Query:         query,
+	}
+	return result, nil
+}
+
+// QueryAs executes a SWQL query and decodes its rows into a slice of T.
+// Struct rows are decoded like Scan does, so swis tags apply.
+func QueryAs[T any](ctx context.Context, c *Client, query string, parameters interface{}) ([]T, error) {
+	result, err := QueryResultAs[T](ctx, c, query, parameters)
+	if err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// QueryRowAs executes a SWQL query and decodes its first row into T. It
+// returns an ErrorTypeNotFound error if the query returns no rows.
+func QueryRowAs[T any](ctx context.Context, c *Client, query string, parameters interface{}) (T, error) {
+	var zero T
+
+	rows, err := QueryAs[json.RawMessage](ctx, c, query, parameters)
+	if err != nil {
+		return zero, err
+	}
+	if len(rows) == 0 {
+		return zero, NewError(ErrorTypeNotFound, "query_row", "query returned no rows")
+	}
+
+	var row T
+	if err := decodeRows(rows[0], &row, c.location()); err != nil {
+		return zero, WrapError(err, ErrorTypeInternal, "query_row", "failed to unmarshal row")
+	}
+	return row, nil
+}
+
+// QueryValueAs executes a SWQL query and converts the value of the first
+// column of its first row, following the order of the SELECT list, to T.
+// Numbers, strings and booleans are converted between each other where it's
+// lossless, and time.Time accepts the date formats SWIS returns. A null value
+// yields the zero value of T. It returns an ErrorTypeNotFound error if the
+// query returns no rows.
+func QueryValueAs[T any](ctx context.Context, c *Client, query string, parameters interface{}) (T, error) {
+	var zero T
+
+	row, err := QueryRowAs[OrderedRow](ctx, c, query, parameters)
+	if err != nil {
+		return zero, err
+	}
+	if len(row) == 0 {
+		return zero, NewError(ErrorTypeValidation, "query_value", "query returned a row with no columns")
+	}
+
+	var v T
+	if err := convertValue(row[0].Value, &v, c.location()); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// convertValue decodes a single JSON value into dest, converting between
+// JSON strings, numbers and booleans to suit dest's kind and reading times
+// without an offset in loc
+func convertValue(raw json.RawMessage, dest interface{}, loc *time.Location) error {
+	if len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+
+	if t, ok := dest.(*Time); ok {
+		if err := t.parseJSON(raw, loc); err != nil {
+			return WrapError(err, ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %s to Time", raw))
+		}
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return WrapError(err, ErrorTypeInternal, "convert", "failed to decode value")
+	}
+
+	if t, ok := dest.(*time.Time); ok {
+		s, ok := value.(string)
+		if !ok {
+			return NewError(ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %s to time.Time", raw))
+		}
+		parsed, err := ParseTime(s, loc)
+		if err != nil {
+			return WrapError(err, ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %q to time.Time", s))
+		}
+		*t = parsed
+		return nil
+	}
+
+	if u, ok := dest.(json.Unmarshaler); ok {
+		if err := u.UnmarshalJSON(raw); err != nil {
+			return WrapError(err, ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %s", raw))
+		}
+		return nil
+	}
+
+	rv := reflect.ValueOf(dest).Elem()
+	convErr := func() error {
+		return NewError(ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %s to %s", raw, rv.Type()))
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			rv.SetString(v)
+		case json.Number:
+			rv.SetString(v.String())
+		case bool:
+			rv.SetString(strconv.FormatBool(v))
+		default:
+			return convErr()
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(numberString(value), 10, 64)
+		if err != nil || rv.OverflowInt(n) {
+			return convErr()
+		}
+		rv.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(numberString(value), 10, 64)
+		if err != nil || rv.OverflowUint(n) {
+			return convErr()
+		}
+		rv.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(numberString(value), 64)
+		if err != nil || rv.OverflowFloat(f) {
+			return convErr()
+		}
+		rv.SetFloat(f)
+
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			rv.SetBool(v)
+		case json.Number, string:
+			b, err := strconv.ParseBool(numberString(v))
+			if err != nil {
+				return convErr()
+			}
+			rv.SetBool(b)
+		default:
+			return convErr()
+		}
+
+	default:
+		if err := json.Unmarshal(raw, dest); err != nil {
+			return WrapError(err, ErrorTypeInternal, "convert", fmt.Sprintf("cannot convert %s to %s", raw, rv.Type()))
+		}
+	}
+
+	return nil
+}
+
+// numberString returns the text of a JSON number or string value. Whole
+// floats such as "3.0" are trimmed so they parse as integers.
+func numberString(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = strings.TrimSpace(v)
+	default:
+		return ""
+	}
+
+	if i := strings.IndexByte(s, '.'); i >= 0 && strings.Trim(s[i+1:], "0") == "" {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,153 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryAs(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{"": `[{"NodeID":1,"Caption":"core-1"},{"NodeID":2,"Caption":"core-2"}]`})
+	client := newTestClient(t, server.Server)
+
+	type node struct {
+		NodeID  int
+		Caption string
+	}
+
+	nodes, err := QueryAs[node](context.Background(), client, "SELECT NodeID, Caption FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []node{{1, "core-1"}, {2, "core-2"}}, nodes)
+
+	result, err := QueryResultAs[node](context.Background(), client, "SELECT NodeID, Caption FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.Meta)
+	assert.Equal(t, 2, result.Count)
+	assert.Equal(t, 2, result.Meta.RowCount)
+	assert.Equal(t, "SELECT NodeID, Caption FROM Orion.Nodes", result.Meta.Query)
+	assert.Greater(t, result.Meta.ExecutionTime, time.Duration(0))
+}
+
+func TestQueryAs_SwisTags(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{
+		"Orion.NPM.Interfaces": `[{"InterfaceID":3,"NodeID":1,"InPercentUtil":42.5,"OutPercentUtil":7.25}]`,
+		"Orion.Volumes":        `[{"VolumeID":4,"NodeID":1,"VolumeSize":1024,"VolumePercentUsed":80.5}]`,
+	})
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+	query := "SELECT InterfaceID, NodeID, InPercentUtil, OutPercentUtil FROM Orion.NPM.Interfaces"
+
+	interfaces, err := QueryAs[Interface](ctx, client, query, nil)
+	require.NoError(t, err)
+	require.Len(t, interfaces, 1)
+	assert.Equal(t, 42.5, interfaces[0].InUtilization)
+	assert.Equal(t, 7.25, interfaces[0].OutUtilization)
+
+	volume, err := QueryRowAs[Volume](ctx, client, "SELECT VolumeID, NodeID, VolumeSize, VolumePercentUsed FROM Orion.Volumes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1024.0, volume.Size)
+	assert.Equal(t, 80.5, volume.PercentUsed)
+
+	rows, err := client.QueryIterContext(ctx, query, nil)
+	require.NoError(t, err)
+	defer rows.Close()
+	require.True(t, rows.Next())
+	var iface Interface
+	require.NoError(t, rows.Scan(&iface))
+	assert.Equal(t, 42.5, iface.InUtilization)
+
+	pages, err := client.QueryPagesContext(ctx, query+" ORDER BY InterfaceID", nil, PageOptions{PageSize: 10})
+	require.NoError(t, err)
+	require.True(t, pages.Next())
+	var page []Interface
+	require.NoError(t, pages.Scan(&page))
+	require.Len(t, page, 1)
+	assert.Equal(t, 7.25, page[0].OutUtilization)
+}
+
+func TestQueryRowAs(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{"": `[{"NodeID":7,"Caption":"edge-7"}]`})
+	client := newTestClient(t, server.Server)
+
+	row, err := QueryRowAs[struct {
+		NodeID  int
+		Caption string
+	}](context.Background(), client, "SELECT NodeID, Caption FROM Orion.Nodes WHERE NodeID = 7", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 7, row.NodeID)
+	assert.Equal(t, "edge-7", row.Caption)
+}
+
+func TestQueryRowAs_NotFound(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+
+	_, err := QueryRowAs[map[string]interface{}](context.Background(), client, "SELECT NodeID FROM Orion.Nodes WHERE NodeID = -1", nil)
+	require.Error(t, err)
+
+	var swErr *Error
+	require.ErrorAs(t, err, &swErr)
+	assert.Equal(t, ErrorTypeNotFound, swErr.Type)
+}
+
+func TestQueryValueAs(t *testing.T) {
+	ctx := context.Background()
+
+	server := newFakeSWIS(t, map[string]string{"": `[{"Total":42}]`})
+	client := newTestClient(t, server.Server)
+
+	n, err := QueryValueAs[int](ctx, client, "SELECT COUNT(NodeID) AS Total FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 42, n)
+
+	s, err := QueryValueAs[string](ctx, client, "SELECT COUNT(NodeID) AS Total FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "42", s)
+
+	dateServer := newFakeSWIS(t, map[string]string{"": `[{"LastBoot":"2024-05-01T12:34:56.1234567"}]`})
+	dateClient := newTestClient(t, dateServer.Server)
+
+	tm, err := QueryValueAs[time.Time](ctx, dateClient, "SELECT LastBoot FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 34, 56, 123456700, time.UTC), tm)
+
+	multiServer := newFakeSWIS(t, map[string]string{"": `[{"Caption":"a","NodeID":1}]`})
+	multiClient := newTestClient(t, multiServer.Server)
+
+	caption, err := QueryValueAs[string](ctx, multiClient, "SELECT Caption, NodeID FROM Orion.Nodes", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "a", caption)
+}
+
+func TestConvertValue(t *testing.T) {
+	var i int
+	require.NoError(t, convertValue(json.RawMessage(`"17"`), &i, time.UTC))
+	assert.Equal(t, 17, i)
+
+	require.NoError(t, convertValue(json.RawMessage(`3.0`), &i, time.UTC))
+	assert.Equal(t, 3, i)
+	assert.Error(t, convertValue(json.RawMessage(`3.5`), &i, time.UTC))
+
+	var i8 int8
+	assert.Error(t, convertValue(json.RawMessage(`300`), &i8, time.UTC))
+
+	var f float64
+	require.NoError(t, convertValue(json.RawMessage(`"99.5"`), &f, time.UTC))
+	assert.Equal(t, 99.5, f)
+
+	var b bool
+	require.NoError(t, convertValue(json.RawMessage(`1`), &b, time.UTC))
+	assert.True(t, b)
+
+	s := "unchanged"
+	require.NoError(t, convertValue(json.RawMessage(`null`), &s, time.UTC))
+	assert.Equal(t, "unchanged", s)
+
+	var m map[string]int
+	require.NoError(t, convertValue(json.RawMessage(`{"a":1}`), &m, time.UTC))
+	assert.Equal(t, map[string]int{"a": 1}, m)
+}
@@ -1,41 +1,40 @@
 package gosolar
 
 import (
-	"encoding/json"
 	"time"
 )
 
 // CommonNode represents a basic SolarWinds node with commonly used fields
 type CommonNode struct {
-	NodeID       int       `json:"nodeid"`
-	Caption      string    `json:"caption"`
-	IPAddress    string    `json:"ipaddress"`
-	Status       int       `json:"status"`
-	StatusLED    string    `json:"statusled"`
-	Vendor       string    `json:"vendor"`
-	MachineType  string    `json:"machinetype"`
-	Location     string    `json:"location"`
-	Contact      string    `json:"contact"`
-	Description  string    `json:"description"`
-	LastBoot     time.Time `json:"lastboot"`
-	ResponseTime float64   `json:"responsetime"`
+	NodeID       int     `json:"nodeid" swis:"NodeID"`
+	Caption      string  `json:"caption" swis:"Caption"`
+	IPAddress    string  `json:"ipaddress" swis:"IPAddress"`
+	Status       int     `json:"status" swis:"Status"`
+	StatusLED    string  `json:"statusled" swis:"StatusLED"`
+	Vendor       string  `json:"vendor" swis:"Vendor"`
+	MachineType  string  `json:"machinetype" swis:"MachineType"`
+	Location     string  `json:"location" swis:"Location"`
+	Contact      string  `json:"contact" swis:"Contact"`
+	Description  string  `json:"description" swis:"Description"`
+	LastBoot     Time    `json:"lastboot" swis:"LastBoot"`
+	ResponseTime float64 `json:"responsetime" swis:"ResponseTime"`
 }
 
 // Interface represents a SolarWinds network interface
 type Interface struct {
-	InterfaceID    int     `json:"interfaceid"`
-	NodeID         int     `json:"nodeid"`
-	Name           string  `json:"name"`
-	Caption        string  `json:"caption"`
-	Status         int     `json:"status"`
-	AdminStatus    int     `json:"adminstatus"`
-	OperStatus     int     `json:"operstatus"`
-	Speed          int64   `json:"speed"`
-	MTU            int     `json:"mtu"`
-	Type           int     `json:"type"`
-	TypeName       string  `json:"typename"`
-	InUtilization  float64 `json:"inutilization"`
-	OutUtilization float64 `json:"oututilization"`
+	InterfaceID    int     `json:"interfaceid" swis:"InterfaceID"`
+	NodeID         int     `json:"nodeid" swis:"NodeID"`
+	Name           string  `json:"name" swis:"Name"`
+	Caption        string  `json:"caption" swis:"Caption"`
+	Status         int     `json:"status" swis:"Status"`
+	AdminStatus    int     `json:"adminstatus" swis:"AdminStatus"`
+	OperStatus     int     `json:"operstatus" swis:"OperStatus"`
+	Speed          int64   `json:"speed" swis:"Speed"`
+	MTU            int     `json:"mtu" swis:"MTU"`
+	Type           int     `json:"type" swis:"Type"`
+	TypeName       string  `json:"typename" swis:"TypeName"`
+	InUtilization  float64 `json:"inutilization" swis:"InPercentUtil"`
+	OutUtilization float64 `json:"oututilization" swis:"OutPercentUtil"`
 }
 
 // CustomProperty represents a SolarWinds custom property
@@ -48,40 +47,49 @@ type CustomProperty struct {
 
 // Alert represents a SolarWinds alert
 type Alert struct {
-	AlertID     int       `json:"alertid"`
-	AlertName   string    `json:"alertname"`
-	Message     string    `json:"message"`
-	Severity    int       `json:"severity"`
-	State       int       `json:"state"`
-	NodeID      int       `json:"nodeid"`
-	ObjectName  string    `json:"objectname"`
-	TriggerTime time.Time `json:"triggertime"`
-	AckBy       string    `json:"ackby"`
-	AckTime     time.Time `json:"acktime"`
+	AlertID     int    `json:"alertid" swis:"AlertID"`
+	AlertName   string `json:"alertname" swis:"AlertName"`
+	Message     string `json:"message" swis:"Message"`
+	Severity    int    `json:"severity" swis:"Severity"`
+	State       int    `json:"state" swis:"State"`
+	NodeID      int    `json:"nodeid" swis:"NodeID"`
+	ObjectName  string `json:"objectname" swis:"ObjectName"`
+	TriggerTime Time   `json:"triggertime" swis:"TriggerTime"`
+	AckBy       string `json:"ackby" swis:"AckBy"`
+	AckTime     Time   `json:"acktime" swis:"AckTime"`
+
+	// AlertActiveID and AlertObjectID identify an active alert instance.
+	// Acknowledging, clearing and notes act on the AlertObjectID.
+	AlertActiveID int    `json:"alertactiveid" swis:"AlertActiveID"`
+	AlertObjectID int    `json:"alertobjectid" swis:"AlertObjectID"`
+	EntityURI     string `json:"entityuri" swis:"EntityUri"`
+	EntityType    string `json:"entitytype" swis:"EntityType"`
+	Acknowledged  bool   `json:"acknowledged" swis:"Acknowledged"`
+	AckNote       string `json:"acknote" swis:"AckNote"`
 }
 
 // Volume represents a SolarWinds volume/disk
 type Volume struct {
-	VolumeID    int     `json:"volumeid"`
-	NodeID      int     `json:"nodeid"`
-	Caption     string  `json:"caption"`
-	Size        float64 `json:"size"`
-	Used        float64 `json:"used"`
-	Available   float64 `json:"available"`
-	PercentUsed float64 `json:"percentused"`
-	Type        string  `json:"type"`
-	Status      int     `json:"status"`
-	FileSystem  string  `json:"filesystem"`
+	VolumeID    int     `json:"volumeid" swis:"VolumeID"`
+	NodeID      int     `json:"nodeid" swis:"NodeID"`
+	Caption     string  `json:"caption" swis:"Caption"`
+	Size        float64 `json:"size" swis:"VolumeSize"`
+	Used        float64 `json:"used" swis:"VolumeSpaceUsed"`
+	Available   float64 `json:"available" swis:"VolumeSpaceAvailable"`
+	PercentUsed float64 `json:"percentused" swis:"VolumePercentUsed"`
+	Type        string  `json:"type" swis:"VolumeType"`
+	Status      int     `json:"status" swis:"Status"`
+	FileSystem  string  `json:"filesystem" swis:"FileSystem"`
 }
 
 // Application represents a SolarWinds application monitor
 type Application struct {
-	ApplicationID int     `json:"applicationid"`
-	NodeID        int     `json:"nodeid"`
-	Name          string  `json:"name"`
-	Status        int     `json:"status"`
-	Availability  float64 `json:"availability"`
-	ResponseTime  float64 `json:"responsetime"`
+	ApplicationID int     `json:"applicationid" swis:"ApplicationID"`
+	NodeID        int     `json:"nodeid" swis:"NodeID"`
+	Name          string  `json:"name" swis:"Name"`
+	Status        int     `json:"status" swis:"Status"`
+	Availability  float64 `json:"availability" swis:"Availability"`
+	ResponseTime  float64 `json:"responsetime" swis:"ResponseTime"`
 }
 
 // QueryResult is a generic wrapper for query results with metadata
@@ -95,13 +103,20 @@ type QueryResult[T any] struct {
 type QueryMeta struct {
 	ExecutionTime time.Duration `json:"execution_time"`
 	RowCount      int           `json:"row_count"`
+	TotalRows     int           `json:"total_rows,omitempty"`
 	Query         string        `json:"query,omitempty"`
 }
 
 // UnmarshalQueryResult is a helper function to unmarshal query results into strongly typed structures
 func UnmarshalQueryResult[T any](data []byte) (*QueryResult[T], error) {
+	return unmarshalQueryResult[T](data, time.UTC)
+}
+
+// unmarshalQueryResult is UnmarshalQueryResult reading times without an
+// offset in loc
+func unmarshalQueryResult[T any](data []byte, loc *time.Location) (*QueryResult[T], error) {
 	var results []T
-	if err := json.Unmarshal(data, &results); err != nil {
+	if err := decodeRows(data, &results, loc); err != nil {
 		return nil, WrapError(err, ErrorTypeInternal, "unmarshal", "failed to unmarshal query result")
 	}
 
@@ -0,0 +1,414 @@
+package gosolar
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// URIScheme is the scheme of every SWIS URI
+const URIScheme = "swis"
+
+// DefaultURIRoot is the first path segment of SWIS URIs
+const DefaultURIRoot = "Orion"
+
+// URI is a parsed SWIS URI such as
+//
+//	swis://host/Orion/Orion.Nodes/NodeID=1/CustomProperties
+//
+// Host is "host", Root is "Orion", Entity is "Orion.Nodes", Keys holds
+// NodeID=1 and Path holds the CustomProperties sub-entity. Key values are
+// stored unquoted; String quotes them again where SWIS would.
+type URI struct {
+	Host   string
+	Root   string
+	Entity string
+	Keys   []URIKey
+	Path   []URISegment
+}
+
+// URIKey is a key property of a SWIS URI. Quoted values are written in
+// double quotes, as SWIS writes string keys; values that would otherwise be
+// mistaken for URI structure are quoted regardless.
+type URIKey struct {
+	Name   string
+	Value  string
+	Quoted bool
+}
+
+// URISegment is a navigation step below the entity, such as CustomProperties
+// or Interfaces/InterfaceID=3
+type URISegment struct {
+	Name string
+	Keys []URIKey
+}
+
+var uriNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+
+// Key returns a URI key property. Strings are quoted and times are written
+// the way SWIS writes them; everything else is formatted with fmt.
+func Key(name string, value interface{}) URIKey {
+	var s string
+	switch v := value.(type) {
+	case string:
+		return URIKey{Name: name, Value: v, Quoted: true}
+	case Time:
+		s = v.String()
+	case time.Time:
+		s = NewTime(v).String()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	return URIKey{Name: name, Value: s}
+}
+
+// NewURI builds the URI of an entity instance from its key properties
+//
+//	uri := gosolar.NewURI("orion", "Orion.Nodes", gosolar.Key("NodeID", 1))
+func NewURI(host, entity string, keys ...URIKey) URI {
+	return URI{
+		Host:   host,
+		Root:   DefaultURIRoot,
+		Entity: entity,
+		Keys:   keys,
+	}
+}
+
+// ParseURI parses a SWIS URI, rejecting anything that isn't one
+func ParseURI(s string) (URI, error) {
+	fail := func(reason string) (URI, error) {
+		return URI{}, NewError(ErrorTypeValidation, "parse_uri", fmt.Sprintf("invalid SWIS URI %q: %s", s, reason))
+	}
+
+	scheme, rest, ok := strings.Cut(s, "://")
+	if !ok || !strings.EqualFold(scheme, URIScheme) {
+		return fail("scheme must be swis://")
+	}
+
+	segments, err := splitQuoted(rest, '/')
+	if err != nil {
+		return fail(err.Error())
+	}
+	if len(segments) < 4 {
+		return fail("expected swis://host/root/entity/keys")
+	}
+
+	u := URI{Host: segments[0], Root: segments[1], Entity: segments[2]}
+	if u.Keys, err = parseURIKeys(segments[3]); err != nil {
+		return fail(err.Error())
+	}
+
+	for i := 4; i < len(segments); i++ {
+		seg := URISegment{Name: segments[i]}
+		if i+1 < len(segments) && strings.Contains(segments[i+1], "=") {
+			i++
+			if seg.Keys, err = parseURIKeys(segments[i]); err != nil {
+				return fail(err.Error())
+			}
+		}
+		u.Path = append(u.Path, seg)
+	}
+
+	if err := u.check(); err != nil {
+		return fail(err.Error())
+	}
+	return u, nil
+}
+
+// MustParseURI is like ParseURI but panics if s isn't a valid SWIS URI
+func MustParseURI(s string) URI {
+	u, err := ParseURI(s)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// String returns the URI as SWIS writes it, with key values quoted where
+// needed
+func (u URI) String() string {
+	if u.IsZero() {
+		return ""
+	}
+
+	root := u.Root
+	if root == "" {
+		root = DefaultURIRoot
+	}
+
+	var b strings.Builder
+	b.WriteString(URIScheme + "://")
+	b.WriteString(u.Host)
+	b.WriteString("/" + root + "/" + u.Entity + "/")
+	writeURIKeys(&b, u.Keys)
+	for _, seg := range u.Path {
+		b.WriteString("/" + seg.Name)
+		if len(seg.Keys) > 0 {
+			b.WriteString("/")
+			writeURIKeys(&b, seg.Keys)
+		}
+	}
+	return b.String()
+}
+
+// IsZero reports whether u is the zero URI
+func (u URI) IsZero() bool {
+	return u.Host == "" && u.Root == "" && u.Entity == "" && len(u.Keys) == 0 && len(u.Path) == 0
+}
+
+// Validate reports whether u is complete enough to send to SWIS
+func (u URI) Validate() error {
+	if err := u.check(); err != nil {
+		return NewError(ErrorTypeValidation, "validate_uri", fmt.Sprintf("invalid SWIS URI %q: %s", u.String(), err))
+	}
+	return nil
+}
+
+func (u URI) check() error {
+	switch {
+	case u.Host == "":
+		return fmt.Errorf("host cannot be empty")
+	case strings.ContainsAny(u.Host, `/"`):
+		return fmt.Errorf("host %q is not valid", u.Host)
+	case u.Root != "" && !uriNamePattern.MatchString(u.Root):
+		return fmt.Errorf("root %q is not valid", u.Root)
+	case !uriNamePattern.MatchString(u.Entity):
+		return fmt.Errorf("entity %q is not valid", u.Entity)
+	case len(u.Keys) == 0:
+		return fmt.Errorf("no key properties")
+	}
+	if err := checkURIKeys(u.Keys); err != nil {
+		return err
+	}
+	for _, seg := range u.Path {
+		if !uriNamePattern.MatchString(seg.Name) {
+			return fmt.Errorf("sub-entity %q is not valid", seg.Name)
+		}
+		if err := checkURIKeys(seg.Keys); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkURIKeys(keys []URIKey) error {
+	for _, k := range keys {
+		if !uriNamePattern.MatchString(k.Name) {
+			return fmt.Errorf("key %q is not valid", k.Name)
+		}
+	}
+	return nil
+}
+
+// Key returns the value of the entity's key property name
+func (u URI) Key(name string) (string, bool) {
+	for _, k := range u.Keys {
+		if strings.EqualFold(k.Name, name) {
+			return k.Value, true
+		}
+	}
+	return "", false
+}
+
+// Sub returns the URI of a sub-entity of u, such as its CustomProperties or
+// one of its Interfaces
+func (u URI) Sub(name string, keys ...URIKey) URI {
+	path := make([]URISegment, len(u.Path), len(u.Path)+1)
+	copy(path, u.Path)
+	u.Path = append(path, URISegment{Name: name, Keys: keys})
+	return u
+}
+
+// CustomProperties returns the URI of the entity's custom properties
+func (u URI) CustomProperties() URI {
+	if n := len(u.Path); n > 0 && u.Path[n-1].Name == "CustomProperties" && len(u.Path[n-1].Keys) == 0 {
+		return u
+	}
+	return u.Sub("CustomProperties")
+}
+
+// Base returns the URI of the entity itself, without any sub-entity
+func (u URI) Base() URI {
+	u.Path = nil
+	return u
+}
+
+// MarshalJSON encodes the URI as a JSON string
+func (u URI) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+// UnmarshalJSON decodes a URI from a JSON string. null and "" leave the
+// zero URI.
+func (u *URI) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*u = URI{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*u = URI{}
+		return nil
+	}
+	parsed, err := ParseURI(s)
+	if err != nil {
+		return err
+	}
+	*u = parsed
+	return nil
+}
+
+// parseURIs parses a list of URI strings
+func parseURIs(uris []string) ([]URI, error) {
+	parsed := make([]URI, 0, len(uris))
+	for _, s := range uris {
+		u, err := ParseURI(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, u)
+	}
+	return parsed, nil
+}
+
+// uriStrings validates a list of URIs and formats them for a request
+func uriStrings(uris []URI) ([]string, error) {
+	strs := make([]string, 0, len(uris))
+	for _, u := range uris {
+		if err := u.Validate(); err != nil {
+			return nil, err
+		}
+		strs = append(strs, u.String())
+	}
+	return strs, nil
+}
+
+// parseURIKeys parses Name=Value[,Name=Value...], unquoting the values
+func parseURIKeys(s string) ([]URIKey, error) {
+	parts, err := splitQuoted(s, ',')
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]URIKey, 0, len(parts))
+	for _, part := range parts {
+		name, raw, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("key property %q has no value", part)
+		}
+		value, quoted, err := unescapeURIValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, URIKey{Name: name, Value: value, Quoted: quoted})
+	}
+	return keys, nil
+}
+
+// splitQuoted splits s on sep outside double quotes
+func splitQuoted(s string, sep byte) ([]string, error) {
+	var parts []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	parts = append(parts, s[start:])
+
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("empty segment")
+		}
+	}
+	return parts, nil
+}
+
+// unescapeURIValue decodes a key value, which is either percent-escaped or
+// wrapped in double quotes with embedded quotes doubled, and reports whether
+// it was quoted
+func unescapeURIValue(raw string) (string, bool, error) {
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		return strings.ReplaceAll(raw[1:len(raw)-1], `""`, `"`), true, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '%' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		if i+2 >= len(raw) || !isHex(raw[i+1]) || !isHex(raw[i+2]) {
+			return "", false, fmt.Errorf("bad escape in key value %q", raw)
+		}
+		b.WriteByte(unhex(raw[i+1])<<4 | unhex(raw[i+2]))
+		i += 2
+	}
+	return b.String(), false, nil
+}
+
+func writeURIKeys(b *strings.Builder, keys []URIKey) {
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k.Name)
+		b.WriteByte('=')
+		if k.Quoted || !isPlainURIValue(k.Value) {
+			b.WriteString(`"` + strings.ReplaceAll(k.Value, `"`, `""`) + `"`)
+		} else {
+			b.WriteString(k.Value)
+		}
+	}
+}
+
+// isPlainURIValue reports whether a key value can be written without quotes
+func isPlainURIValue(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isURIValueChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isURIValueChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~' || c == ':'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
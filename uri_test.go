@@ -0,0 +1,270 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want URI
+	}{
+		{
+			name: "entity",
+			uri:  "swis://orion.example.com/Orion/Orion.Nodes/NodeID=1",
+			want: URI{Host: "orion.example.com", Root: "Orion", Entity: "Orion.Nodes", Keys: []URIKey{{"NodeID", "1", false}}},
+		},
+		{
+			name: "custom properties",
+			uri:  "swis://orion/Orion/Orion.Nodes/NodeID=1/CustomProperties",
+			want: URI{
+				Host: "orion", Root: "Orion", Entity: "Orion.Nodes",
+				Keys: []URIKey{{"NodeID", "1", false}},
+				Path: []URISegment{{Name: "CustomProperties"}},
+			},
+		},
+		{
+			name: "keyed sub-entity",
+			uri:  "swis://orion/Orion/Orion.Nodes/NodeID=1/Interfaces/InterfaceID=3",
+			want: URI{
+				Host: "orion", Root: "Orion", Entity: "Orion.Nodes",
+				Keys: []URIKey{{"NodeID", "1", false}},
+				Path: []URISegment{{Name: "Interfaces", Keys: []URIKey{{"InterfaceID", "3", false}}}},
+			},
+		},
+		{
+			name: "compound key",
+			uri:  "swis://orion/Orion/Orion.Pollers/NetObject=N:1,PollerType=N.Status.ICMP.Native",
+			want: URI{
+				Host: "orion", Root: "Orion", Entity: "Orion.Pollers",
+				Keys: []URIKey{{"NetObject", "N:1", false}, {"PollerType", "N.Status.ICMP.Native", false}},
+			},
+		},
+		{
+			name: "escaped value",
+			uri:  "swis://orion/Orion/Orion.Groups/Name=Core%2FEdge%2C%20West",
+			want: URI{Host: "orion", Root: "Orion", Entity: "Orion.Groups", Keys: []URIKey{{"Name", "Core/Edge, West", false}}},
+		},
+		{
+			name: "quoted value",
+			uri:  `swis://orion/Orion/Orion.Groups/Name="Core/Edge, ""West"""`,
+			want: URI{Host: "orion", Root: "Orion", Entity: "Orion.Groups", Keys: []URIKey{{"Name", `Core/Edge, "West"`, true}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseURI(tt.uri)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseURI_Invalid(t *testing.T) {
+	for _, uri := range []string{
+		"",
+		"Orion.Nodes/NodeID=1",
+		"https://orion/Orion/Orion.Nodes/NodeID=1",
+		"swis://orion/Orion/Orion.Nodes",
+		"swis:///Orion/Orion.Nodes/NodeID=1",
+		"swis://orion/Orion/Orion.Nodes/NodeID",
+		"swis://orion/Orion/Orion.Nodes/NodeID=1//CustomProperties",
+		"swis://orion/Orion/Orion Nodes/NodeID=1",
+		"swis://orion/Orion/Orion.Nodes/NodeID=%zz",
+		`swis://orion/Orion/Orion.Groups/Name="Core`,
+	} {
+		t.Run(uri, func(t *testing.T) {
+			_, err := ParseURI(uri)
+			require.Error(t, err)
+
+			var swErr *Error
+			require.ErrorAs(t, err, &swErr)
+			assert.Equal(t, ErrorTypeValidation, swErr.Type)
+			assert.Equal(t, "parse_uri", swErr.Operation)
+		})
+	}
+}
+
+func TestURI_String(t *testing.T) {
+	uri := NewURI("orion", "Orion.Groups", Key("Name", `Core/Edge, "West"=1%`))
+	assert.Equal(t, `swis://orion/Orion/Orion.Groups/Name="Core/Edge, ""West""=1%"`, uri.String())
+
+	parsed, err := ParseURI(uri.String())
+	require.NoError(t, err)
+	assert.Equal(t, uri, parsed)
+
+	// Unquoted values are quoted when they couldn't be written bare
+	escaped := MustParseURI("swis://orion/Orion/Orion.Groups/Name=Core%2FEdge")
+	assert.Equal(t, `swis://orion/Orion/Orion.Groups/Name="Core/Edge"`, escaped.String())
+	assert.Equal(t, "swis://orion/Orion/Orion.Nodes/NodeID=1", NewURI("orion", "Orion.Nodes", Key("NodeID", 1)).String())
+
+	node := NewURI("orion", "Orion.Nodes", Key("NodeID", 1))
+	assert.Equal(t, "swis://orion/Orion/Orion.Nodes/NodeID=1/CustomProperties", node.CustomProperties().String())
+	assert.Equal(t, node.CustomProperties(), node.CustomProperties().CustomProperties())
+	assert.Equal(t, "swis://orion/Orion/Orion.Nodes/NodeID=1/Interfaces/InterfaceID=3", node.Sub("Interfaces", Key("InterfaceID", 3)).String())
+	assert.Equal(t, node, node.CustomProperties().Base())
+	assert.Equal(t, "", URI{}.String())
+
+	id, ok := node.Key("nodeid")
+	assert.True(t, ok)
+	assert.Equal(t, "1", id)
+}
+
+func TestURI_QuotedKeyRoundTrip(t *testing.T) {
+	const raw = `swis://orion/Orion/Orion.Settings/SettingID="Some Setting, with comma"`
+	uri, err := ParseURI(raw)
+	require.NoError(t, err)
+	assert.Equal(t, []URIKey{{"SettingID", "Some Setting, with comma", true}}, uri.Keys)
+	assert.Equal(t, raw, uri.String())
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+	_, err = client.ReadContext(ctx, raw)
+	require.NoError(t, err)
+	_, err = client.ReadURIContext(ctx, uri)
+	require.NoError(t, err)
+	require.NoError(t, client.SetCustomPropertyContext(ctx, raw, "City", "Austin"))
+
+	require.Len(t, paths, 3)
+	assert.True(t, strings.HasSuffix(paths[0], "/"+raw), paths[0])
+	assert.True(t, strings.HasSuffix(paths[1], "/"+raw), paths[1])
+	assert.True(t, strings.HasSuffix(paths[2], "/"+raw+"/CustomProperties"), paths[2])
+}
+
+func TestClient_StringURIsSentAsWritten(t *testing.T) {
+	var bodies []string
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(data))
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	// Written unlike String would write it, but valid
+	const raw = `swis://orion/Orion/Orion.Groups/Name=core`
+	_, err := client.DeleteContext(ctx, raw)
+	require.NoError(t, err)
+	_, err = client.BulkDeleteContext(ctx, []string{raw})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasSuffix(paths[0], "/"+raw), paths[0])
+	assert.JSONEq(t, `{"uris":["swis://orion/Orion/Orion.Groups/Name=core"]}`, bodies[1])
+}
+
+func TestKey(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	assert.Equal(t, URIKey{"Timestamp", "2024-03-01T12:30:00", false}, Key("Timestamp", ts))
+	assert.Equal(t, URIKey{"Enabled", "true", false}, Key("Enabled", true))
+	assert.Equal(t, URIKey{"Name", "core", true}, Key("Name", "core"))
+}
+
+func TestURI_Validate(t *testing.T) {
+	assert.NoError(t, NewURI("orion", "Orion.Nodes", Key("NodeID", 1)).Validate())
+
+	for name, uri := range map[string]URI{
+		"no host":     NewURI("", "Orion.Nodes", Key("NodeID", 1)),
+		"no keys":     NewURI("orion", "Orion.Nodes"),
+		"bad entity":  NewURI("orion", "Orion/Nodes", Key("NodeID", 1)),
+		"bad key":     NewURI("orion", "Orion.Nodes", Key("Node ID", 1)),
+		"bad segment": NewURI("orion", "Orion.Nodes", Key("NodeID", 1)).Sub("Custom/Properties"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := uri.Validate()
+			require.Error(t, err)
+			assert.ErrorIs(t, err, &Error{Type: ErrorTypeValidation})
+		})
+	}
+}
+
+func TestURI_JSON(t *testing.T) {
+	var row struct {
+		URI   URI
+		Empty URI
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"URI":"swis://orion/Orion/Orion.Nodes/NodeID=7","Empty":null}`), &row))
+	assert.Equal(t, NewURI("orion", "Orion.Nodes", Key("NodeID", 7)), row.URI)
+	assert.True(t, row.Empty.IsZero())
+
+	data, err := json.Marshal(row.URI)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"swis://orion/Orion/Orion.Nodes/NodeID=7"`, string(data))
+
+	var node struct {
+		URI URI `swis:"Uri"`
+	}
+	require.NoError(t, Scan(json.RawMessage(`{"Uri":"swis://orion/Orion/Orion.Nodes/NodeID=7"}`), &node))
+	assert.Equal(t, "7", node.URI.Keys[0].Value)
+}
+
+func TestClient_URIRequests(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.EscapedPath())
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+	node := NewURI("orion", "Orion.Nodes", Key("NodeID", 1))
+
+	_, err := client.ReadURIContext(ctx, node)
+	require.NoError(t, err)
+	_, err = client.UpdateContext(ctx, node.String(), map[string]interface{}{"Caption": "core"})
+	require.NoError(t, err)
+	require.NoError(t, client.SetCustomPropertyContext(ctx, node.String(), "City", "Austin"))
+	_, err = client.DeleteURIContext(ctx, NewURI("orion", "Orion.Groups", Key("Name", "a/b")))
+	require.NoError(t, err)
+
+	require.Len(t, paths, 4)
+	assert.Regexp(t, `^GET /.*/swis://orion/Orion/Orion\.Nodes/NodeID=1$`, paths[0])
+	assert.Regexp(t, `^POST /.*/swis://orion/Orion/Orion\.Nodes/NodeID=1$`, paths[1])
+	assert.Regexp(t, `^POST /.*/swis://orion/Orion/Orion\.Nodes/NodeID=1/CustomProperties$`, paths[2])
+	assert.Regexp(t, `^DELETE /.*/swis://orion/Orion/Orion\.Groups/Name=%22a/b%22$`, paths[3])
+}
+
+func TestClient_RejectsMalformedURI(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+
+	_, err := client.ReadContext(ctx, "Orion.Nodes/NodeID=1")
+	assert.Error(t, err)
+	_, err = client.DeleteURIContext(ctx, NewURI("orion", "Orion.Nodes"))
+	assert.Error(t, err)
+	_, err = client.BulkDeleteContext(ctx, []string{"swis://orion/Orion/Orion.Nodes/NodeID=1", "bogus"})
+	assert.Error(t, err)
+	assert.Error(t, client.SetCustomPropertiesContext(ctx, "swis://orion", map[string]interface{}{"City": "Austin"}))
+	assert.Error(t, client.BulkSetCustomPropertyURIsContext(ctx, []URI{{Host: "orion"}}, "City", "Austin"))
+
+	assert.Zero(t, requests)
+}
@@ -0,0 +1,76 @@
+package gosolar
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mrxinu/gosolar/swql/parser"
+)
+
+// ValidateQuery parses a SWQL query client-side and checks that every
+// @parameter it uses has a value in parameters, which may be a map or a
+// struct that encodes to a JSON object. Syntax errors are ErrorTypeSWQL
+// errors wrapping a *parser.SyntaxError with the line and column; missing
+// parameters are ErrorTypeValidation errors.
+func ValidateQuery(query string, parameters interface{}) error {
+	stmt, err := parser.Parse(query)
+	if err != nil {
+		return WrapError(err, ErrorTypeSWQL, "validate_query", err.Error())
+	}
+
+	used := stmt.Parameters()
+	if len(used) == 0 {
+		return nil
+	}
+
+	given, err := parameterNames(parameters)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, name := range used {
+		if !given[strings.ToLower(name)] {
+			missing = append(missing, "@"+name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return NewError(ErrorTypeValidation, "validate_query",
+			fmt.Sprintf("no value for parameters: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// parameterNames returns the lower-cased names of the query parameters
+// supplied by the caller
+func parameterNames(parameters interface{}) (map[string]bool, error) {
+	names := map[string]bool{}
+	if parameters == nil {
+		return names, nil
+	}
+
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeValidation, "validate_query", "failed to encode parameters")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, WrapError(err, ErrorTypeValidation, "validate_query", "parameters must encode to a JSON object")
+	}
+	for name := range fields {
+		names[strings.ToLower(name)] = true
+	}
+	return names, nil
+}
+
+// validateQuery runs ValidateQuery if the client is configured to
+func (c *Client) validateQuery(query string, parameters interface{}) error {
+	if !c.config.ValidateQueries {
+		return nil
+	}
+	return ValidateQuery(query, parameters)
+}
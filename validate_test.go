@@ -0,0 +1,144 @@
+package gosolar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mrxinu/gosolar/swql"
+	"github.com/mrxinu/gosolar/swql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateQuery(t *testing.T) {
+	type nodeFilter struct {
+		Vendor string `json:"vendor"`
+		Status int    `json:"status"`
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		parameters interface{}
+		wantType   ErrorType
+		wantMsg    string
+	}{
+		{
+			name:  "no parameters",
+			query: "SELECT NodeID FROM Orion.Nodes",
+		},
+		{
+			name:       "map parameters",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @vendor AND Status = @status",
+			parameters: map[string]interface{}{"vendor": "Cisco", "status": 1},
+		},
+		{
+			name:       "names are case-insensitive",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @Vendor",
+			parameters: map[string]string{"vendor": "Cisco"},
+		},
+		{
+			name:       "struct parameters",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @vendor AND Status = @status",
+			parameters: nodeFilter{Vendor: "Cisco", Status: 1},
+		},
+		{
+			name:       "builder parameters",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @p0",
+			parameters: swql.Parameters{"p0": "Cisco"},
+		},
+		{
+			name:     "syntax error",
+			query:    "SELECT NodeID FORM Orion.Nodes",
+			wantType: ErrorTypeSWQL,
+			wantMsg:  `swql: syntax error at line 1, column 20: expected end of query, found "Orion"`,
+		},
+		{
+			name:       "missing parameters",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @vendor AND Status = @status AND Caption LIKE @name",
+			parameters: map[string]interface{}{"vendor": "Cisco"},
+			wantType:   ErrorTypeValidation,
+			wantMsg:    "no value for parameters: @name, @status",
+		},
+		{
+			name:     "nil parameters",
+			query:    "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @vendor",
+			wantType: ErrorTypeValidation,
+			wantMsg:  "no value for parameters: @vendor",
+		},
+		{
+			name:       "parameters not an object",
+			query:      "SELECT NodeID FROM Orion.Nodes WHERE Vendor = @vendor",
+			parameters: []string{"Cisco"},
+			wantType:   ErrorTypeValidation,
+			wantMsg:    "parameters must encode to a JSON object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateQuery(tt.query, tt.parameters)
+			if tt.wantType == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var swErr *Error
+			require.True(t, errors.As(err, &swErr))
+			assert.Equal(t, tt.wantType, swErr.Type)
+			assert.Equal(t, tt.wantMsg, swErr.Message)
+		})
+	}
+}
+
+func TestValidateQuery_SyntaxErrorPosition(t *testing.T) {
+	err := ValidateQuery("SELECT NodeID\nFROM Orion.Nodes\nWHERE Status =", nil)
+
+	var syntaxErr *parser.SyntaxError
+	require.True(t, errors.As(err, &syntaxErr))
+	assert.Equal(t, 3, syntaxErr.Line)
+	assert.Equal(t, 15, syntaxErr.Column)
+}
+
+func TestClient_ValidateQueries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	ctx := context.Background()
+	bad := "SELECT NodeID FROM Orion.Nodes WHERE"
+
+	// Off by default: the server sees the query
+	_, err := client.QueryContext(ctx, bad, nil)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	client.config.ValidateQueries = true
+
+	_, err = client.QueryContext(ctx, bad, nil)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeSWQL}))
+
+	_, err = client.QueryIterContext(ctx, bad, nil)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeSWQL}))
+
+	_, err = client.QueryPagesContext(ctx, bad, nil, PageOptions{})
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeSWQL}))
+
+	_, err = client.QueryContext(ctx, "SELECT NodeID FROM Orion.Nodes WHERE NodeID = @id", nil)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeValidation}))
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	_, err = client.QueryContext(ctx, "SELECT NodeID FROM Orion.Nodes WHERE NodeID = @id", map[string]interface{}{"id": 1})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
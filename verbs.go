@@ -0,0 +1,263 @@
+package gosolar
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Args are named verb arguments. Names match the verb's argument names
+// case-insensitively.
+type Args map[string]interface{}
+
+// Call invokes a verb with named arguments and returns its raw result. The
+// arguments are put in the positional order SWIS expects using the verb's
+// metadata, which is fetched once per client unless supplied with
+// SetVerbArguments. Optional arguments that aren't given are sent as null.
+//
+// Values are converted to what SWIS expects: time.Time is sent as a SWIS
+// DateTime, structs are sent as objects keyed by their swis (or json) tag
+// names, and scalars are converted to strings for System.String arguments.
+func (c *Client) Call(ctx context.Context, entity, verb string, args Args) (json.RawMessage, error) {
+	return c.callVerb(ctx, "call", entity, verb, args, nil)
+}
+
+// CallAs invokes a verb like Client.Call and decodes its result into T.
+// Structs and slices of structs are decoded with the struct scanner; other
+// types accept the same conversions as QueryValueAs. A null result yields the
+// zero value of T.
+func CallAs[T any](ctx context.Context, c *Client, entity, verb string, args Args) (T, error) {
+	var zero T
+
+	raw, err := c.Call(ctx, entity, verb, args)
+	if err != nil {
+		return zero, err
+	}
+
+	var v T
+	if err := decodeVerbResult(raw, &v, c.location()); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// SetVerbArguments supplies the signature of a verb so Call doesn't need to
+// look it up, for example where the account can't read Metadata.* entities
+func (c *Client) SetVerbArguments(entity, verb string, args []VerbArgument) {
+	sorted := append([]VerbArgument(nil), args...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+
+	c.metadata.mu.Lock()
+	c.metadata.args[strings.ToLower(entity+"."+verb)] = sorted
+	c.metadata.mu.Unlock()
+}
+
+// callVerb invokes a verb, using signature if given and the verb metadata
+// otherwise
+func (c *Client) callVerb(ctx context.Context, operation, entity, verb string, args Args, signature []VerbArgument) (json.RawMessage, error) {
+	if signature == nil {
+		var err error
+		if signature, err = c.VerbArguments(ctx, entity, verb); err != nil {
+			return nil, err
+		}
+	}
+
+	positional, err := orderVerbArgs(operation, entity, verb, signature, args, c.location())
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.InvokeContext(ctx, entity, verb, positional)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(result)) == 0 {
+		return json.RawMessage("null"), nil
+	}
+	return json.RawMessage(result), nil
+}
+
+// orderVerbArgs arranges named arguments in the verb's positional order,
+// writing times in loc
+func orderVerbArgs(operation, entity, verb string, signature []VerbArgument, args Args, loc *time.Location) ([]interface{}, error) {
+	byName := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		byName[strings.ToLower(name)] = value
+	}
+
+	positional := make([]interface{}, len(signature))
+	var missing []string
+	for i, arg := range signature {
+		key := strings.ToLower(arg.Name)
+		value, ok := byName[key]
+		if !ok {
+			if !arg.IsOptional {
+				missing = append(missing, arg.Name)
+			}
+			continue
+		}
+		delete(byName, key)
+
+		converted, err := verbValue(value, arg.Type, loc)
+		if err != nil {
+			return nil, WrapError(err, ErrorTypeValidation, operation, fmt.Sprintf("argument %s", arg.Name))
+		}
+		positional[i] = converted
+	}
+
+	if len(byName) > 0 {
+		unknown := make([]string, 0, len(byName))
+		for name := range args {
+			if _, ok := byName[strings.ToLower(name)]; ok {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		return nil, NewError(ErrorTypeValidation, operation,
+			fmt.Sprintf("%s.%s has no arguments named %s", entity, verb, strings.Join(unknown, ", ")))
+	}
+	if len(missing) > 0 {
+		return nil, NewError(ErrorTypeValidation, operation,
+			fmt.Sprintf("%s.%s requires arguments %s", entity, verb, strings.Join(missing, ", ")))
+	}
+	return positional, nil
+}
+
+// verbValue converts a Go value into the JSON form SWIS expects for an
+// argument of the given type, writing times in loc
+func verbValue(value interface{}, argType string, loc *time.Location) (interface{}, error) {
+	v := convertVerbValue(reflect.ValueOf(value), loc)
+	if v == nil {
+		return nil, nil
+	}
+
+	// SWIS parses System.String arguments itself, so scalars are sent as text
+	if argType == "System.String" {
+		switch s := v.(type) {
+		case bool:
+			return strconv.FormatBool(s), nil
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return fmt.Sprintf("%d", s), nil
+		case float32, float64:
+			return fmt.Sprintf("%v", s), nil
+		}
+	}
+	return v, nil
+}
+
+// convertVerbValue rewrites times and structs inside a value, leaving
+// everything else for encoding/json
+func convertVerbValue(v reflect.Value, loc *time.Location) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch t := v.Interface().(type) {
+	case time.Time:
+		if t.IsZero() {
+			return nil
+		}
+		return NewTime(t).format(loc)
+	case Time:
+		if t.IsZero() {
+			return nil
+		}
+		return t.format(loc)
+	case json.Marshaler:
+		return t
+	case driver.Valuer:
+		// sql.NullString and friends
+		value, err := t.Value()
+		if err != nil {
+			return nil
+		}
+		return convertVerbValue(reflect.ValueOf(value), loc)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{})
+		addStructFields(v, out, loc)
+		return out
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = convertVerbValue(v.Index(i), loc)
+		}
+		return out
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = convertVerbValue(iter.Value(), loc)
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// addStructFields copies the exported fields of a struct into out, named the
+// way the struct scanner names columns and flattening untagged embedded
+// structs
+func addStructFields(v reflect.Value, out map[string]interface{}, loc *time.Location) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, tagged, skip := fieldName(sf)
+		if skip {
+			continue
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && !tagged && structType(sf.Type) != nil && !isLeafType(sf.Type) {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			addStructFields(fv, out, loc)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		out[name] = convertVerbValue(fv, loc)
+	}
+}
+
+// decodeVerbResult decodes a verb's result into dest
+func decodeVerbResult(raw json.RawMessage, dest interface{}, loc *time.Location) error {
+	if len(raw) == 0 || bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+		return nil
+	}
+
+	if scansRows(dest) {
+		return (&Scanner{Location: loc}).Scan(raw, dest)
+	}
+	return convertValue(raw, dest, loc)
+}
@@ -0,0 +1,236 @@
+package gosolar
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unmanageMetadata answers metadata queries about Orion.Nodes.Unmanage
+var unmanageMetadata = map[string]string{
+	"Metadata.VerbArgument": `[
+		{"Position":1,"Name":"netObjectId","Type":"System.String","IsOptional":false},
+		{"Position":2,"Name":"unmanageTime","Type":"System.DateTime","IsOptional":false},
+		{"Position":3,"Name":"remanageTime","Type":"System.DateTime","IsOptional":false},
+		{"Position":4,"Name":"isRelative","Type":"System.Boolean","IsOptional":true}]`,
+	"Metadata.Verb ": `[{"EntityName":"Orion.Nodes","Name":"Unmanage","Summary":null}]`,
+}
+
+func TestCall(t *testing.T) {
+	server := newFakeSWIS(t, unmanageMetadata)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	from := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	result, err := client.Call(ctx, "Orion.Nodes", "Unmanage", Args{
+		"RemanageTime": from.Add(time.Hour),
+		"unmanageTime": from,
+		"netObjectId":  "N:1",
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, "null", string(result))
+	invoked := server.bodies("Invoke/Orion.Nodes/Unmanage")
+	require.Len(t, invoked, 1)
+	assert.JSONEq(t, `["N:1","2024-05-01T12:30:00","2024-05-01T13:30:00",null]`, invoked[0])
+	assert.Equal(t, 3, server.count())
+
+	// The signature is cached
+	_, err = client.Call(ctx, "Orion.Nodes", "Unmanage", Args{
+		"netObjectId": "N:2", "unmanageTime": from, "remanageTime": from, "isRelative": true,
+	})
+	require.NoError(t, err)
+	invoked = server.bodies("Invoke/Orion.Nodes/Unmanage")
+	require.Len(t, invoked, 2)
+	assert.JSONEq(t, `["N:2","2024-05-01T12:30:00","2024-05-01T12:30:00",true]`, invoked[1])
+	assert.Equal(t, 4, server.count())
+}
+
+func TestCall_ArgumentErrors(t *testing.T) {
+	server := newFakeSWIS(t, unmanageMetadata)
+	client := newTestClient(t, server.Server)
+	ctx := context.Background()
+
+	_, err := client.Call(ctx, "Orion.Nodes", "Unmanage", Args{"netObjectId": "N:1"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeValidation}))
+	assert.Contains(t, err.Error(), "Orion.Nodes.Unmanage requires arguments unmanageTime, remanageTime")
+
+	_, err = client.Call(ctx, "Orion.Nodes", "Unmanage", Args{
+		"netObjectId": "N:1", "unmanageTime": time.Now(), "remanageTime": time.Now(), "Until": 5,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Orion.Nodes.Unmanage has no arguments named Until")
+
+	_, err = client.Call(ctx, "Orion.Nodes", "Explode", nil)
+	assert.True(t, errors.Is(err, &Error{Type: ErrorTypeVerbNotFound}))
+
+	for _, call := range server.calls() {
+		assert.NotContains(t, call, "Invoke/", "no verb should have been invoked")
+	}
+}
+
+func TestSetVerbArguments(t *testing.T) {
+	server := newFakeSWIS(t, map[string]string{"Invoke/Orion.Nodes/Lookup": `{"NodeID":7,"Caption":"core-7"}`})
+	client := newTestClient(t, server.Server)
+	client.SetVerbArguments("Orion.Nodes", "Lookup", []VerbArgument{
+		{Position: 2, Name: "Caption", Type: "System.String"},
+		{Position: 1, Name: "EngineID", Type: "System.Int32"},
+	})
+
+	node, err := CallAs[CommonNode](context.Background(), client, "Orion.Nodes", "Lookup", Args{"Caption": "core-7", "EngineID": 1})
+	require.NoError(t, err)
+	assert.Equal(t, 7, node.NodeID)
+	assert.Equal(t, "core-7", node.Caption)
+	assert.Equal(t, []string{`[1,"core-7"]`}, server.bodies("Invoke/Orion.Nodes/Lookup"))
+	assert.Equal(t, 1, server.count(), "supplied signature should skip metadata")
+}
+
+func TestCallAs(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		check  func(t *testing.T, c *Client)
+	}{
+		{
+			name:   "scalar",
+			result: `"42"`,
+			check: func(t *testing.T, c *Client) {
+				v, err := CallAs[int](context.Background(), c, "Orion.Test", "Verb", nil)
+				require.NoError(t, err)
+				assert.Equal(t, 42, v)
+			},
+		},
+		{
+			name:   "null",
+			result: `null`,
+			check: func(t *testing.T, c *Client) {
+				v, err := CallAs[string](context.Background(), c, "Orion.Test", "Verb", nil)
+				require.NoError(t, err)
+				assert.Equal(t, "", v)
+			},
+		},
+		{
+			name:   "empty body",
+			result: ``,
+			check: func(t *testing.T, c *Client) {
+				v, err := CallAs[*CommonNode](context.Background(), c, "Orion.Test", "Verb", nil)
+				require.NoError(t, err)
+				assert.Nil(t, v)
+			},
+		},
+		{
+			name:   "slice of structs",
+			result: `[{"NodeID":1},{"NodeID":2}]`,
+			check: func(t *testing.T, c *Client) {
+				v, err := CallAs[[]CommonNode](context.Background(), c, "Orion.Test", "Verb", nil)
+				require.NoError(t, err)
+				require.Len(t, v, 2)
+				assert.Equal(t, 2, v[1].NodeID)
+			},
+		},
+		{
+			name:   "time",
+			result: `"2024-05-01T12:30:00"`,
+			check: func(t *testing.T, c *Client) {
+				v, err := CallAs[time.Time](context.Background(), c, "Orion.Test", "Verb", nil)
+				require.NoError(t, err)
+				assert.Equal(t, time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC), v)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSWIS(t, map[string]string{"Invoke/Orion.Test/Verb": tt.result})
+			client := newTestClient(t, server.Server)
+			client.SetVerbArguments("Orion.Test", "Verb", []VerbArgument{})
+			tt.check(t, client)
+		})
+	}
+}
+
+func TestVerbValue(t *testing.T) {
+	type Setting struct {
+		Name    string `swis:"SettingName"`
+		Value   string `json:"SettingValue"`
+		Ignored string `swis:"-"`
+		secret  string
+	}
+	type Base struct {
+		EngineID int
+	}
+	type NodeSettings struct {
+		Base
+		NodeID   int        `swis:"NodeID"`
+		Settings []Setting  `swis:"Settings"`
+		Since    *time.Time `swis:"Since"`
+	}
+
+	when := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		argType string
+		want    string
+	}{
+		{"nil", nil, "System.String", `null`},
+		{"time", when, "System.DateTime", `"2024-05-01T12:30:00"`},
+		{"time pointer", &when, "System.DateTime", `"2024-05-01T12:30:00"`},
+		{"zero time", time.Time{}, "System.DateTime", `null`},
+		{"gosolar time", NewTime(when), "System.DateTime", `"2024-05-01T12:30:00"`},
+		{"strings", []string{"a", "b"}, "System.String[]", `["a","b"]`},
+		{"int as string", 400, "System.String", `"400"`},
+		{"bool as string", true, "System.String", `"true"`},
+		{"float as string", 1.5, "System.String", `"1.5"`},
+		{"int", 400, "System.Int32", `400`},
+		{"null string", sql.NullString{}, "System.String", `null`},
+		{"valid string", sql.NullString{String: "x", Valid: true}, "System.String", `"x"`},
+		{"raw", json.RawMessage(`{"a":1}`), "System.Object", `{"a":1}`},
+		{
+			"nested struct",
+			NodeSettings{
+				Base:     Base{EngineID: 1},
+				NodeID:   5,
+				Settings: []Setting{{Name: "a", Value: "b", Ignored: "x", secret: "y"}},
+			},
+			"SolarWinds.Orion.Core.Common.Models.NodeSettings",
+			`{"EngineID":1,"NodeID":5,"Settings":[{"SettingName":"a","SettingValue":"b"}],"Since":null}`,
+		},
+		{"map", map[string]interface{}{"When": when}, "System.Object", `{"When":"2024-05-01T12:30:00"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := verbValue(tt.value, tt.argType, time.UTC)
+			require.NoError(t, err)
+
+			data, err := json.Marshal(got)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(data))
+		})
+	}
+}
+
+func TestCreateCustomProperty_Arguments(t *testing.T) {
+	server := newFakeSWIS(t, nil)
+	client := newTestClient(t, server.Server)
+	err := client.CreateCustomPropertyContext(context.Background(), CreateCustomPropertyRequest{
+		Entity:      "Orion.NodesCustomProperties",
+		Name:        "Rack",
+		Description: "Rack location",
+		Type:        CustomPropertyTypeString,
+	})
+	require.NoError(t, err)
+
+	invoked := server.bodies("Invoke/Orion.NodesCustomProperties/CreateCustomProperty")
+	require.Len(t, invoked, 1)
+	assert.JSONEq(t, `["Rack","Rack location","string",400,null,null,null,null,null,null,null,false,null]`, invoked[0])
+	assert.Equal(t, 1, server.count(), "no metadata lookup expected")
+}
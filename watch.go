@@ -0,0 +1,686 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mrxinu/gosolar/swql"
+)
+
+// DefaultWatchInterval is how often a Watcher polls when WatchOptions
+// doesn't say
+const DefaultWatchInterval = 30 * time.Second
+
+// DefaultWatchBatchSize is the number of events a Watcher fetches per query
+// when WatchOptions doesn't say
+const DefaultWatchBatchSize = 500
+
+// ChangeKind is the kind of a Change
+type ChangeKind string
+
+const (
+	// ChangeEvent is a new row in Orion.Events
+	ChangeEvent ChangeKind = "event"
+
+	// ChangeAlert is a newly triggered alert in Orion.AlertActive
+	ChangeAlert ChangeKind = "alert"
+
+	// ChangeAlertAcknowledged is an active alert that has been acknowledged.
+	// Only delivered with WatchOptions.AlertUpdates.
+	ChangeAlertAcknowledged ChangeKind = "alert_acknowledged"
+
+	// ChangeAlertReset is an alert that is no longer active. Its Alert is
+	// the alert as it was last seen. Only delivered with
+	// WatchOptions.AlertUpdates.
+	ChangeAlertReset ChangeKind = "alert_reset"
+)
+
+// Event is a row of Orion.Events
+type Event struct {
+	EventID       int64  `json:"eventid" swis:"EventID"`
+	EventTime     Time   `json:"eventtime" swis:"EventTime"`
+	EventType     int    `json:"eventtype" swis:"EventType"`
+	Message       string `json:"message" swis:"Message"`
+	NodeID        int    `json:"nodeid" swis:"NetworkNode"`
+	NetObjectType string `json:"netobjecttype" swis:"NetObjectType"`
+	NetObjectID   int    `json:"netobjectid" swis:"NetObjectID"`
+	EngineID      int    `json:"engineid" swis:"EngineID"`
+	Acknowledged  bool   `json:"acknowledged" swis:"Acknowledged"`
+}
+
+// Change is a new event or an alert change seen by a Watcher. Event is set
+// for ChangeEvent and Alert for every alert kind.
+type Change struct {
+	Kind  ChangeKind
+	Event *Event
+	Alert *Alert
+
+	ack func()
+}
+
+// Ack reports that a change received from Watcher.Changes has been handled.
+// It does nothing for changes passed to a Run or Poll handler, and calling it
+// more than once is harmless.
+func (c Change) Ack() {
+	if c.ack != nil {
+		c.ack()
+	}
+}
+
+// Checkpoint is how far a Watcher has got. Events are tracked by EventID;
+// alerts by trigger time plus the alerts already seen at that time, since
+// several can trigger in the same instant. With AlertUpdates, ActiveAlerts
+// holds every active alert as last seen, keyed by AlertActiveID.
+type Checkpoint struct {
+	EventID        int64         `json:"event_id"`
+	AlertTime      time.Time     `json:"alert_time"`
+	AlertActiveIDs []int         `json:"alert_active_ids,omitempty"`
+	ActiveAlerts   map[int]Alert `json:"active_alerts,omitempty"`
+}
+
+// seenAlert reports whether an alert is at or before the checkpoint
+func (cp *Checkpoint) seenAlert(a *Alert) bool {
+	t := a.TriggerTime.Time
+	if t.Before(cp.AlertTime) {
+		return true
+	}
+	if t.Equal(cp.AlertTime) {
+		for _, id := range cp.AlertActiveIDs {
+			if id == a.AlertActiveID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markAlert moves the checkpoint past an alert
+func (cp *Checkpoint) markAlert(a *Alert) {
+	t := a.TriggerTime.Time
+	if t.After(cp.AlertTime) {
+		cp.AlertTime = t
+		cp.AlertActiveIDs = nil
+	}
+	cp.AlertActiveIDs = append(cp.AlertActiveIDs, a.AlertActiveID)
+}
+
+// trackAlert records the state an active alert was last seen in
+func (cp *Checkpoint) trackAlert(a *Alert) {
+	if cp.ActiveAlerts == nil {
+		cp.ActiveAlerts = map[int]Alert{}
+	}
+	cp.ActiveAlerts[a.AlertActiveID] = *a
+}
+
+// advance moves the checkpoint past a change that has been handled
+func (cp *Checkpoint) advance(c Change, trackUpdates bool) {
+	switch c.Kind {
+	case ChangeEvent:
+		cp.EventID = c.Event.EventID
+	case ChangeAlert:
+		cp.markAlert(c.Alert)
+		if trackUpdates {
+			cp.trackAlert(c.Alert)
+		}
+	case ChangeAlertAcknowledged:
+		cp.trackAlert(c.Alert)
+	case ChangeAlertReset:
+		delete(cp.ActiveAlerts, c.Alert.AlertActiveID)
+	}
+}
+
+// clone returns a copy of cp sharing nothing with it
+func (cp Checkpoint) clone() Checkpoint {
+	cp.AlertActiveIDs = append([]int(nil), cp.AlertActiveIDs...)
+	if cp.ActiveAlerts != nil {
+		active := make(map[int]Alert, len(cp.ActiveAlerts))
+		for id, a := range cp.ActiveAlerts {
+			active[id] = a
+		}
+		cp.ActiveAlerts = active
+	}
+	return cp
+}
+
+// CheckpointStore persists a Watcher's checkpoint so it can resume after a
+// restart
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, or nil if there is none
+	Load(ctx context.Context) (*Checkpoint, error)
+
+	// Save replaces the saved checkpoint
+	Save(ctx context.Context, cp Checkpoint) error
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory, so it only survives
+// restarts of the Watcher, not of the process
+type MemoryCheckpointStore struct {
+	mu sync.Mutex
+	cp *Checkpoint
+}
+
+// Load returns the saved checkpoint
+func (s *MemoryCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cp == nil {
+		return nil, nil
+	}
+	cp := s.cp.clone()
+	return &cp, nil
+}
+
+// Save replaces the saved checkpoint
+func (s *MemoryCheckpointStore) Save(ctx context.Context, cp Checkpoint) error {
+	cp = cp.clone()
+	s.mu.Lock()
+	s.cp = &cp
+	s.mu.Unlock()
+	return nil
+}
+
+// FileCheckpointStore keeps the checkpoint in a JSON file. Saves write a
+// temporary file and rename it, so a crash never leaves a partial checkpoint.
+type FileCheckpointStore struct {
+	Path string
+}
+
+// NewFileCheckpointStore returns a store keeping the checkpoint at path
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{Path: path}
+}
+
+// Load returns the saved checkpoint, or nil if the file doesn't exist
+func (s *FileCheckpointStore) Load(ctx context.Context) (*Checkpoint, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "load_checkpoint", "failed to read checkpoint")
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "load_checkpoint", "failed to parse checkpoint")
+	}
+	return &cp, nil
+}
+
+// Save replaces the saved checkpoint
+func (s *FileCheckpointStore) Save(ctx context.Context, cp Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to marshal checkpoint")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to create checkpoint")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to write checkpoint")
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to write checkpoint")
+	}
+	if err := tmp.Close(); err != nil {
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to write checkpoint")
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return WrapError(err, ErrorTypeInternal, "save_checkpoint", "failed to replace checkpoint")
+	}
+	return nil
+}
+
+// WatchOptions configures a Watcher
+type WatchOptions struct {
+	// Interval is the time between polls (default: 30s)
+	Interval time.Duration
+
+	// Events and Alerts pick what to watch. If neither is set, both are.
+	Events bool
+	Alerts bool
+
+	// AlertUpdates also delivers ChangeAlertAcknowledged and
+	// ChangeAlertReset. The checkpoint then keeps every active alert, and
+	// each poll reads Orion.AlertActive in full.
+	AlertUpdates bool
+
+	// Checkpoints persists progress (default: in memory)
+	Checkpoints CheckpointStore
+
+	// FromStart delivers every existing event and active alert when there's
+	// no saved checkpoint. By default the watcher starts from the present.
+	FromStart bool
+
+	// BatchSize is the number of events fetched per query (default: 500)
+	BatchSize int
+
+	// Buffer is the capacity of the channel returned by Changes (default: 0,
+	// so each change waits for the receiver)
+	Buffer int
+
+	// OnError is called when loading the checkpoint or a poll fails. The
+	// watcher logs the error and keeps polling either way.
+	OnError func(error)
+}
+
+// Watcher polls Orion.Events and Orion.AlertActive and delivers what's new.
+// Delivery is at least once: the checkpoint is saved once per batch of
+// changes a query returns, after all of them have been handled, so a restart
+// with the same CheckpointStore loses nothing but may deliver the changes
+// handled since the last save again.
+//
+//	w := client.NewWatcher(gosolar.WatchOptions{
+//		Checkpoints: gosolar.NewFileCheckpointStore("/var/lib/bot/checkpoint.json"),
+//	})
+//	err := w.Run(ctx, func(ctx context.Context, ch gosolar.Change) error {
+//		...
+//		return nil
+//	})
+type Watcher struct {
+	client *Client
+	opts   WatchOptions
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewWatcher returns a watcher polling with the client
+func (c *Client) NewWatcher(opts WatchOptions) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultWatchInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultWatchBatchSize
+	}
+	if opts.AlertUpdates {
+		opts.Alerts = true
+	}
+	if !opts.Events && !opts.Alerts {
+		opts.Events = true
+		opts.Alerts = true
+	}
+	if opts.Checkpoints == nil {
+		opts.Checkpoints = &MemoryCheckpointStore{}
+	}
+	return &Watcher{client: c, opts: opts}
+}
+
+// handlerError marks an error returned by the handler passed to Run
+type handlerError struct{ err error }
+
+func (e handlerError) Error() string { return e.err.Error() }
+
+// Run polls until ctx is done or handle returns an error, calling handle
+// for each change in order. The next change isn't handed over until handle
+// returns. If handle fails, the changes handled before it are checkpointed
+// and the failed one is delivered again by the next Run.
+//
+// Failing to load the checkpoint or poll, say while SWIS is unavailable, is
+// reported to OnError and retried on the next tick.
+func (w *Watcher) Run(ctx context.Context, handle func(context.Context, Change) error) error {
+	return w.run(ctx, &delivery{handle: handle})
+}
+
+// delivery is where a Watcher hands its changes
+type delivery struct {
+	handle func(context.Context, Change) error
+
+	// settle, if set, waits until the changes handed over so far have been
+	// handled
+	settle func(context.Context) error
+}
+
+// run is Run delivering to d
+func (w *Watcher) run(ctx context.Context, d *delivery) error {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	var cp *Checkpoint
+	for {
+		var err error
+		if cp == nil {
+			cp, err = w.start(ctx)
+		}
+		if err == nil {
+			err = w.poll(ctx, cp, d)
+		}
+		if err != nil {
+			var herr handlerError
+			switch {
+			case ctx.Err() != nil:
+				return ctx.Err()
+			case errors.As(err, &herr):
+				return herr.err
+			}
+
+			w.client.logger.WarnContext(ctx, "watch poll failed", "error", err)
+			if w.opts.OnError != nil {
+				w.opts.OnError(err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll loads the checkpoint and delivers what's new once, like a single tick
+// of Run. Without a saved checkpoint it only records where the watcher
+// starts, unless FromStart is set.
+func (w *Watcher) Poll(ctx context.Context, handle func(context.Context, Change) error) error {
+	cp, err := w.start(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = w.poll(ctx, cp, &delivery{handle: handle})
+	var herr handlerError
+	if errors.As(err, &herr) {
+		return herr.err
+	}
+	return err
+}
+
+// Changes runs the watcher in the background and delivers changes over a
+// channel, which is closed when ctx is done or the watcher fails. Check Err
+// after the channel closes.
+//
+// Call Ack on each change once it has been handled. A batch of changes is
+// only checkpointed once all of them have been acknowledged, and the watcher
+// waits for that before it polls again, so changes that weren't acknowledged
+// when the process died are delivered again after a restart.
+func (w *Watcher) Changes(ctx context.Context) <-chan Change {
+	ch := make(chan Change, w.opts.Buffer)
+	acks := &ackTracker{}
+	go func() {
+		defer close(ch)
+		err := w.run(ctx, &delivery{
+			handle: func(ctx context.Context, c Change) error {
+				c.ack = acks.add()
+				select {
+				case ch <- c:
+					return nil
+				case <-ctx.Done():
+					c.ack()
+					return ctx.Err()
+				}
+			},
+			settle: acks.wait,
+		})
+
+		w.mu.Lock()
+		w.err = err
+		w.mu.Unlock()
+	}()
+	return ch
+}
+
+// ackTracker counts the changes handed out by Changes that haven't been
+// acknowledged yet
+type ackTracker struct {
+	mu      sync.Mutex
+	pending int
+	settled chan struct{} // closed when pending drops to zero
+}
+
+// add counts a change and returns the function acknowledging it
+func (t *ackTracker) add() func() {
+	t.mu.Lock()
+	if t.pending == 0 {
+		t.settled = make(chan struct{})
+	}
+	t.pending++
+	t.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			t.pending--
+			if t.pending == 0 {
+				close(t.settled)
+			}
+			t.mu.Unlock()
+		})
+	}
+}
+
+// wait waits until every change counted has been acknowledged
+func (t *ackTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.pending == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	settled := t.settled
+	t.mu.Unlock()
+
+	select {
+	case <-settled:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Err returns the error that stopped a watcher started with Changes
+func (w *Watcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if errors.Is(w.err, context.Canceled) {
+		return nil
+	}
+	return w.err
+}
+
+// start loads the checkpoint, or creates one at the present if there's none
+func (w *Watcher) start(ctx context.Context) (*Checkpoint, error) {
+	cp, err := w.opts.Checkpoints.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cp != nil {
+		return cp, nil
+	}
+
+	cp = &Checkpoint{}
+	if w.opts.FromStart {
+		return cp, nil
+	}
+
+	if w.opts.Events {
+		var rows []struct {
+			EventID *int64 `swis:"EventID"`
+		}
+		if err := w.client.queryScan(ctx, "watch", "SELECT MAX(EventID) AS EventID FROM Orion.Events", nil, &rows); err != nil {
+			return nil, err
+		}
+		if len(rows) > 0 && rows[0].EventID != nil {
+			cp.EventID = *rows[0].EventID
+		}
+	}
+	if w.opts.Alerts {
+		alerts, err := w.alerts(ctx, cp)
+		if err != nil {
+			return nil, err
+		}
+		for i := range alerts {
+			cp.markAlert(&alerts[i])
+			if w.opts.AlertUpdates {
+				cp.trackAlert(&alerts[i])
+			}
+		}
+	}
+
+	if err := w.opts.Checkpoints.Save(ctx, *cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// poll delivers everything new since cp, advancing cp as it goes and saving
+// it after each batch
+func (w *Watcher) poll(ctx context.Context, cp *Checkpoint, d *delivery) error {
+	if w.opts.Events {
+		for {
+			events, err := w.events(ctx, cp.EventID)
+			if err != nil {
+				return err
+			}
+			changes := make([]Change, len(events))
+			for i := range events {
+				changes[i] = Change{Kind: ChangeEvent, Event: &events[i]}
+			}
+			if err := w.deliver(ctx, cp, changes, false, d); err != nil {
+				return err
+			}
+			if len(events) < w.opts.BatchSize {
+				break
+			}
+		}
+	}
+
+	if w.opts.Alerts {
+		return w.pollAlerts(ctx, cp, d)
+	}
+	return nil
+}
+
+// pollAlerts delivers the alerts triggered since cp and, with AlertUpdates,
+// the alerts acknowledged or reset since, ordered by trigger time
+func (w *Watcher) pollAlerts(ctx context.Context, cp *Checkpoint, d *delivery) error {
+	alerts, err := w.alerts(ctx, cp)
+	if err != nil {
+		return err
+	}
+
+	var changes []Change
+	changed := false
+	active := make(map[int]bool, len(alerts))
+	for i := range alerts {
+		a := &alerts[i]
+		active[a.AlertActiveID] = true
+		if !cp.seenAlert(a) {
+			changes = append(changes, Change{Kind: ChangeAlert, Alert: a})
+			continue
+		}
+		if !w.opts.AlertUpdates {
+			continue
+		}
+
+		prev, known := cp.ActiveAlerts[a.AlertActiveID]
+		switch {
+		case known && a.Acknowledged && !prev.Acknowledged:
+			changes = append(changes, Change{Kind: ChangeAlertAcknowledged, Alert: a})
+		case !known || a.Acknowledged != prev.Acknowledged:
+			// Nothing to deliver, but a later acknowledgement is compared
+			// with this state
+			cp.trackAlert(a)
+			changed = true
+		}
+	}
+	if w.opts.AlertUpdates {
+		for id := range cp.ActiveAlerts {
+			if !active[id] {
+				a := cp.ActiveAlerts[id]
+				changes = append(changes, Change{Kind: ChangeAlertReset, Alert: &a})
+			}
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i].Alert, changes[j].Alert
+		if !a.TriggerTime.Equal(b.TriggerTime.Time) {
+			return a.TriggerTime.Before(b.TriggerTime.Time)
+		}
+		return a.AlertActiveID < b.AlertActiveID
+	})
+
+	return w.deliver(ctx, cp, changes, changed, d)
+}
+
+// deliver hands a batch of changes over in order, advancing cp past each
+// one, and saves cp once they have all been handled, or if changed is set.
+// If handling a change fails, the changes before it are still saved.
+func (w *Watcher) deliver(ctx context.Context, cp *Checkpoint, changes []Change, changed bool, d *delivery) error {
+	for _, c := range changes {
+		if err := d.handle(ctx, c); err != nil {
+			if changed {
+				_ = w.checkpoint(ctx, cp, d)
+			}
+			return handlerError{err: err}
+		}
+		cp.advance(c, w.opts.AlertUpdates)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return w.checkpoint(ctx, cp, d)
+}
+
+// checkpoint saves cp once the changes handed over so far have been handled
+func (w *Watcher) checkpoint(ctx context.Context, cp *Checkpoint, d *delivery) error {
+	if d.settle != nil {
+		if err := d.settle(ctx); err != nil {
+			return err
+		}
+	}
+	return w.opts.Checkpoints.Save(ctx, *cp)
+}
+
+// events fetches the next batch of events after id
+func (w *Watcher) events(ctx context.Context, after int64) ([]Event, error) {
+	query, params, err := swql.Select(
+		"EventID", "EventTime", "EventType", "Message", "NetworkNode",
+		"NetObjectType", "NetObjectID", "EngineID", "Acknowledged").
+		From("Orion.Events").
+		Where(swql.Gt("EventID", after)).
+		OrderBy("EventID").
+		Limit(w.opts.BatchSize).
+		Build()
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "watch", "failed to build events query")
+	}
+
+	events := []Event{}
+	if err := w.client.queryScan(ctx, "watch", query, params, &events); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// alerts fetches the active alerts triggered at or after the checkpoint, or
+// all of them with AlertUpdates, oldest first
+func (w *Watcher) alerts(ctx context.Context, cp *Checkpoint) ([]Alert, error) {
+	q := selectAlerts()
+	if !cp.AlertTime.IsZero() && !w.opts.AlertUpdates {
+		q.Where(swql.Ge("aa.TriggeredDateTime", NewTime(cp.AlertTime)))
+	}
+	query, params, err := q.OrderBy("aa.TriggeredDateTime", "aa.AlertActiveID").Build()
+	if err != nil {
+		return nil, WrapError(err, ErrorTypeInternal, "watch", "failed to build alerts query")
+	}
+
+	alerts := []Alert{}
+	if err := w.client.queryScan(ctx, "watch", query, params, &alerts); err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
@@ -0,0 +1,432 @@
+package gosolar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFeed serves Orion.Events and Orion.AlertActive rows, honoring the
+// EventID and TriggeredDateTime conditions the watcher sends
+type fakeFeed struct {
+	mu     sync.Mutex
+	events []Event
+	alerts []Alert
+}
+
+func (f *fakeFeed) addEvent(id int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.events = append(f.events, Event{EventID: id, Message: fmt.Sprintf("event %d", id)})
+}
+
+func (f *fakeFeed) addAlert(id int, triggered time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.alerts = append(f.alerts, Alert{AlertActiveID: id, TriggerTime: NewTime(triggered)})
+}
+
+func (f *fakeFeed) updateAlert(id int, update func(a *Alert)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.alerts {
+		if f.alerts[i].AlertActiveID == id {
+			update(&f.alerts[i])
+		}
+	}
+}
+
+func (f *fakeFeed) resetAlert(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.alerts {
+		if f.alerts[i].AlertActiveID == id {
+			f.alerts = append(f.alerts[:i], f.alerts[i+1:]...)
+			return
+		}
+	}
+}
+
+func (f *fakeFeed) server(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query      string                 `json:"query"`
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		var rows []map[string]interface{}
+		switch {
+		case strings.Contains(req.Query, "MAX(EventID)"):
+			var max interface{}
+			for _, e := range f.events {
+				max = e.EventID
+			}
+			rows = append(rows, map[string]interface{}{"EventID": max})
+		case strings.Contains(req.Query, "FROM Orion.Events"):
+			after := int64(req.Parameters["p0"].(float64))
+			for _, e := range f.events {
+				if e.EventID > after && len(rows) < 2 {
+					rows = append(rows, map[string]interface{}{"EventID": e.EventID, "Message": e.Message})
+				}
+			}
+		case strings.Contains(req.Query, "FROM Orion.AlertActive"):
+			var since time.Time
+			if s, ok := req.Parameters["p0"].(string); ok {
+				var err error
+				since, err = ParseTime(s, nil)
+				require.NoError(t, err)
+			}
+			for _, a := range f.alerts {
+				if !a.TriggerTime.Before(since) {
+					rows = append(rows, map[string]interface{}{"AlertActiveID": a.AlertActiveID, "TriggerTime": a.TriggerTime, "Acknowledged": a.Acknowledged})
+				}
+			}
+		default:
+			t.Errorf("unexpected query %s", req.Query)
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": rows})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// collect runs w until it has delivered n changes
+func collect(t *testing.T, w *Watcher, n int) []Change {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var changes []Change
+	err := w.Run(ctx, func(ctx context.Context, c Change) error {
+		changes = append(changes, c)
+		if len(changes) == n {
+			cancel()
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, changes, n)
+	return changes
+}
+
+func changeIDs(changes []Change) []string {
+	ids := make([]string, len(changes))
+	for i, c := range changes {
+		if c.Kind == ChangeEvent {
+			ids[i] = fmt.Sprintf("event %d", c.Event.EventID)
+		} else {
+			ids[i] = fmt.Sprintf("%s %d", c.Kind, c.Alert.AlertActiveID)
+		}
+	}
+	return ids
+}
+
+func TestWatcher_FromStart(t *testing.T) {
+	feed := &fakeFeed{}
+	t0 := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	for id := int64(1); id <= 5; id++ {
+		feed.addEvent(id)
+	}
+	feed.addAlert(10, t0)
+	feed.addAlert(11, t0)
+
+	store := &MemoryCheckpointStore{}
+	client := newTestClient(t, feed.server(t))
+	w := client.NewWatcher(WatchOptions{Interval: 10 * time.Millisecond, FromStart: true, BatchSize: 2, Checkpoints: store})
+
+	changes := collect(t, w, 7)
+	assert.Equal(t, []string{"event 1", "event 2", "event 3", "event 4", "event 5", "alert 10", "alert 11"}, changeIDs(changes))
+	assert.Equal(t, "event 1", changes[0].Event.Message)
+
+	cp, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), cp.EventID)
+	assert.True(t, cp.AlertTime.Equal(t0))
+	assert.Equal(t, []int{10, 11}, cp.AlertActiveIDs)
+}
+
+func TestWatcher_StartsFromNow(t *testing.T) {
+	feed := &fakeFeed{}
+	t0 := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	feed.addEvent(1)
+	feed.addAlert(10, t0)
+
+	client := newTestClient(t, feed.server(t))
+	w := client.NewWatcher(WatchOptions{Interval: 10 * time.Millisecond})
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		feed.addEvent(2)
+		feed.addAlert(11, t0)
+		feed.addAlert(12, t0.Add(time.Minute))
+	}()
+
+	changes := collect(t, w, 3)
+	assert.Equal(t, []string{"event 2", "alert 11", "alert 12"}, changeIDs(changes))
+}
+
+func TestWatcher_AlertUpdates(t *testing.T) {
+	ctx := context.Background()
+	feed := &fakeFeed{}
+	t0 := time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC)
+	feed.addAlert(1, t0)
+
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+	client := newTestClient(t, feed.server(t))
+	w := client.NewWatcher(WatchOptions{AlertUpdates: true, Checkpoints: store})
+
+	var changes []Change
+	poll := func() []string {
+		changes = nil
+		require.NoError(t, w.Poll(ctx, func(ctx context.Context, c Change) error {
+			changes = append(changes, c)
+			return nil
+		}))
+		return changeIDs(changes)
+	}
+	ack := func(id int, acked bool) {
+		feed.updateAlert(id, func(a *Alert) { a.Acknowledged = acked })
+	}
+
+	// The first poll only records the alerts already active
+	assert.Empty(t, poll())
+
+	feed.addAlert(2, t0)
+	assert.Equal(t, []string{"alert 2"}, poll())
+
+	ack(2, true)
+	feed.resetAlert(1)
+	assert.Equal(t, []string{"alert_reset 1", "alert_acknowledged 2"}, poll())
+	assert.Empty(t, poll())
+
+	// An alert acknowledged again after being unacknowledged is delivered
+	// again, by a watcher resuming from the checkpoint
+	ack(2, false)
+	assert.Empty(t, poll())
+	ack(2, true)
+	w = client.NewWatcher(WatchOptions{AlertUpdates: true, Checkpoints: store})
+	assert.Equal(t, []string{"alert_acknowledged 2"}, poll())
+
+	feed.resetAlert(2)
+	assert.Equal(t, []string{"alert_reset 2"}, poll())
+	assert.True(t, changes[0].Alert.Acknowledged)
+
+	cp, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, cp.ActiveAlerts)
+}
+
+func TestWatcher_Resume(t *testing.T) {
+	feed := &fakeFeed{}
+	for id := int64(1); id <= 4; id++ {
+		feed.addEvent(id)
+	}
+
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+	client := newTestClient(t, feed.server(t))
+	opts := WatchOptions{Interval: 10 * time.Millisecond, Events: true, FromStart: true, Checkpoints: store}
+
+	// The handler fails on the third event, which must be redelivered
+	failed := errors.New("downstream unavailable")
+	var seen []string
+	err := client.NewWatcher(opts).Run(context.Background(), func(ctx context.Context, c Change) error {
+		if c.Event.EventID == 3 {
+			return failed
+		}
+		seen = append(seen, changeIDs([]Change{c})[0])
+		return nil
+	})
+	require.ErrorIs(t, err, failed)
+	assert.Equal(t, []string{"event 1", "event 2"}, seen)
+
+	cp, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cp.EventID)
+
+	changes := collect(t, client.NewWatcher(opts), 2)
+	assert.Equal(t, []string{"event 3", "event 4"}, changeIDs(changes))
+}
+
+// countingStore counts the saves to a MemoryCheckpointStore
+type countingStore struct {
+	MemoryCheckpointStore
+	mu    sync.Mutex
+	saves int
+}
+
+func (s *countingStore) Save(ctx context.Context, cp Checkpoint) error {
+	s.mu.Lock()
+	s.saves++
+	s.mu.Unlock()
+	return s.MemoryCheckpointStore.Save(ctx, cp)
+}
+
+func (s *countingStore) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.saves
+}
+
+func TestWatcher_SavesOncePerBatch(t *testing.T) {
+	feed := &fakeFeed{}
+	for id := int64(1); id <= 5; id++ {
+		feed.addEvent(id)
+	}
+
+	store := &countingStore{}
+	client := newTestClient(t, feed.server(t))
+	w := client.NewWatcher(WatchOptions{Events: true, FromStart: true, BatchSize: 2, Checkpoints: store})
+
+	var handled int
+	require.NoError(t, w.Poll(context.Background(), func(ctx context.Context, c Change) error {
+		handled++
+		return nil
+	}))
+	assert.Equal(t, 5, handled)
+	assert.Equal(t, 3, store.count(), "one save per batch of 2")
+
+	cp, err := store.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), cp.EventID)
+}
+
+func TestWatcher_Changes(t *testing.T) {
+	feed := &fakeFeed{}
+	feed.addEvent(1)
+	feed.addEvent(2)
+
+	store := &countingStore{}
+	client := newTestClient(t, feed.server(t))
+	w := client.NewWatcher(WatchOptions{Interval: 10 * time.Millisecond, Events: true, FromStart: true, Checkpoints: store})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := w.Changes(ctx)
+
+	first := <-ch
+	assert.Equal(t, int64(1), first.Event.EventID)
+	second := <-ch
+	assert.Equal(t, int64(2), second.Event.EventID)
+
+	// Nothing is checkpointed until both changes have been handled
+	first.Ack()
+	time.Sleep(50 * time.Millisecond)
+	assert.Zero(t, store.count())
+
+	second.Ack()
+	second.Ack()
+	require.Eventually(t, func() bool { return store.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+	cp, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), cp.EventID)
+
+	cancel()
+	for range ch {
+	}
+	assert.NoError(t, w.Err())
+}
+
+func TestWatcher_PollErrorsKeepPolling(t *testing.T) {
+	var requests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		if n <= 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"EventID":1}]}`))
+	}))
+	defer server.Close()
+
+	var errs []error
+	client := newTestClient(t, server)
+	w := client.NewWatcher(WatchOptions{
+		Interval:  10 * time.Millisecond,
+		Events:    true,
+		FromStart: true,
+		OnError:   func(err error) { errs = append(errs, err) },
+	})
+
+	changes := collect(t, w, 1)
+	assert.Equal(t, int64(1), changes[0].Event.EventID)
+	assert.Len(t, errs, 2)
+}
+
+func TestWatcher_StartErrorsKeepPolling(t *testing.T) {
+	feed := &fakeFeed{}
+	feed.addEvent(1)
+	healthy := feed.server(t)
+
+	var requests int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		n := requests
+		mu.Unlock()
+
+		// SWIS is down while the watcher looks for the latest event
+		if n <= 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if n == 4 {
+			feed.addEvent(2)
+		}
+		healthy.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var errs []error
+	client := newTestClient(t, server)
+	w := client.NewWatcher(WatchOptions{
+		Interval: 10 * time.Millisecond,
+		Events:   true,
+		OnError:  func(err error) { errs = append(errs, err) },
+	})
+
+	changes := collect(t, w, 1)
+	assert.Equal(t, int64(2), changes[0].Event.EventID)
+	assert.Len(t, errs, 2)
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	cp, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, cp)
+
+	want := Checkpoint{EventID: 9, AlertTime: time.Date(2024, 3, 1, 22, 0, 0, 0, time.UTC), AlertActiveIDs: []int{3, 4}}
+	require.NoError(t, store.Save(ctx, want))
+
+	cp, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, want.EventID, cp.EventID)
+	assert.True(t, want.AlertTime.Equal(cp.AlertTime))
+	assert.Equal(t, want.AlertActiveIDs, cp.AlertActiveIDs)
+
+	matches, err := filepath.Glob(filepath.Join(filepath.Dir(store.Path), "*.tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}